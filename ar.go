@@ -0,0 +1,245 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Contains constants for the ar format.
+const (
+	arMagic = "!<arch>\n"
+
+	// arHeaderSize is the size of a member header.
+	arHeaderSize = 60
+
+	// arBSDLongNamePrefix is the prefix of BSD member names that are
+	// stored after the header, e.g. "#1/20".
+	arBSDLongNamePrefix = "#1/"
+)
+
+// _ ensures that ar implements the [Archiver] interface.
+var _ Archiver = (&ar{})
+
+// ar implements the [Archiver] interface for ar archives, as used by
+// static libraries and Debian packages. Both the GNU and BSD variants
+// of long member names are supported.
+type ar struct{}
+
+// Extensions returns the supported extensions for the ar extractor.
+func (a *ar) Extensions() []string {
+	return []string{"ar", "a"}
+}
+
+// Open creates a new [Archive] from the provided reader using the ar
+// format.
+func (a *ar) Open(r io.Reader, _ string) (Archive, error) {
+	return newArArchive(r)
+}
+
+// arArchive implements [Archive] for ar archives.
+type arArchive struct {
+	r io.Reader
+
+	// cur is the contents of the current member.
+	cur *io.LimitedReader
+
+	// pad is true if the current member is followed by a padding byte.
+	pad bool
+
+	// names is the GNU long name table ("//" member), if any.
+	names []byte
+}
+
+// newArArchive reads the global header of an ar archive from r.
+func newArArchive(r io.Reader) (*arArchive, error) {
+	var magic [len(arMagic)]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, fmt.Errorf("failed to read ar header: %w", err)
+	}
+
+	if string(magic[:]) != arMagic {
+		return nil, fmt.Errorf("%w: not an ar archive", ErrCorrupt)
+	}
+
+	return &arArchive{r: r, cur: &io.LimitedReader{R: r}}, nil
+}
+
+// Close implements [Archive].
+func (a *arArchive) Close() error {
+	return nil
+}
+
+// Read implements [io.Reader].
+func (a *arArchive) Read(p []byte) (int, error) {
+	n, err := a.cur.Read(p)
+	if err == io.EOF && a.cur.N > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return n, classifyError(err)
+}
+
+// skip discards the rest of the current member and its padding.
+func (a *arArchive) skip() error {
+	if _, err := io.Copy(io.Discard, a); err != nil {
+		return err
+	}
+
+	if a.pad {
+		var b [1]byte
+		if _, err := io.ReadFull(a.r, b[:]); err != nil {
+			return err
+		}
+		a.pad = false
+	}
+
+	return nil
+}
+
+// Next implements [Archive].
+func (a *arArchive) Next() (*Header, error) {
+	h, err := a.next()
+	return h, classifyError(err)
+}
+
+// next returns the header of the next entry.
+func (a *arArchive) next() (*Header, error) {
+	for {
+		if err := a.skip(); err != nil {
+			return nil, fmt.Errorf("failed to skip ar member: %w", err)
+		}
+
+		var hdr [arHeaderSize]byte
+		if _, err := io.ReadFull(a.r, hdr[:]); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+
+			return nil, fmt.Errorf("failed to read ar member header: %w", err)
+		}
+
+		if string(hdr[58:60]) != "`\n" {
+			return nil, fmt.Errorf("%w: invalid ar member header", ErrCorrupt)
+		}
+
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		fields := make([]int64, 0, 5)
+		for _, f := range []struct {
+			b    []byte
+			base int
+		}{
+			{hdr[16:28], 10}, // mtime
+			{hdr[28:34], 10}, // uid
+			{hdr[34:40], 10}, // gid
+			{hdr[40:48], 8},  // mode
+			{hdr[48:58], 10}, // size
+		} {
+			v, err := parseArNumber(f.b, f.base)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid ar member header for %q: %w", ErrCorrupt, name, err)
+			}
+			fields = append(fields, v)
+		}
+		size := fields[4]
+
+		a.cur.N = size
+		a.pad = size%2 != 0
+
+		switch {
+		case name == "//":
+			// GNU long name table.
+			names, err := io.ReadAll(a)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ar name table: %w", err)
+			}
+			a.names = names
+			continue
+		case name == "/", name == "/SYM64/", name == "__.SYMDEF", name == "__.SYMDEF SORTED":
+			// Symbol tables.
+			continue
+		case strings.HasPrefix(name, arBSDLongNamePrefix):
+			n, err := strconv.ParseInt(strings.TrimPrefix(name, arBSDLongNamePrefix), 10, 64)
+			if err != nil || n < 0 || n > size {
+				return nil, fmt.Errorf("%w: invalid ar BSD long name %q", ErrCorrupt, name)
+			}
+
+			b := make([]byte, n)
+			if _, err := io.ReadFull(a, b); err != nil {
+				return nil, fmt.Errorf("failed to read ar BSD long name: %w", err)
+			}
+			name = string(bytes.TrimRight(b, "\x00"))
+			size -= n
+
+			if strings.HasPrefix(name, "__.SYMDEF") {
+				continue
+			}
+		case len(name) > 1 && name[0] == '/':
+			// GNU long name, an offset into the name table.
+			offset, err := strconv.Atoi(name[1:])
+			if err != nil || offset < 0 || offset >= len(a.names) {
+				return nil, fmt.Errorf("%w: invalid ar GNU long name %q", ErrCorrupt, name)
+			}
+
+			name = string(a.names[offset:])
+			if i := strings.IndexByte(name, '\n'); i >= 0 {
+				name = name[:i]
+			}
+			name = strings.TrimSuffix(name, "/")
+		default:
+			// GNU terminates short names with a slash.
+			name = strings.TrimSuffix(name, "/")
+		}
+
+		return &Header{
+			Name:    name,
+			Type:    HeaderFile,
+			Size:    size,
+			Mode:    unixModeToFileMode(uint32(fields[3])), //nolint:gosec // Why: Parsed from 8 octal digits.
+			ModTime: time.Unix(fields[0], 0),
+			UID:     int(fields[1]),
+			GID:     int(fields[2]),
+		}, nil
+	}
+}
+
+// parseArNumber parses a space padded number from an ar member header.
+// Empty fields are treated as zero.
+func parseArNumber(b []byte, base int) (int64, error) {
+	s := strings.TrimSpace(string(b))
+	if s == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseInt(s, base, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if v < 0 {
+		return 0, fmt.Errorf("negative value %d", v)
+	}
+
+	return v, nil
+}
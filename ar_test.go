@@ -0,0 +1,121 @@
+package archives_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/artest"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"gotest.tools/v3/assert"
+)
+
+func TestAr(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := []artest.File{
+		{Name: "short.o", Contents: []byte("odd"), Mode: 0o100644, UID: 1000, GID: 100, ModTime: modTime},
+		{Name: "a-very-long-member-name.o", Contents: []byte("long name"), Mode: 0o100755},
+		{Name: "another-long-member-name.o", Contents: []byte("another")},
+	}
+
+	tests := []struct {
+		name    string
+		options []artest.OptionFn
+	}{
+		{"gnu", nil},
+		{"gnu symbol table", []artest.OptionFn{artest.WithSymbolTable()}},
+		{"bsd", []artest.OptionFn{artest.WithVariant(artest.VariantBSD)}},
+		{"bsd symbol table", []artest.OptionFn{artest.WithVariant(artest.VariantBSD), artest.WithSymbolTable()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := artest.Create(files, tt.options...)
+			assert.NilError(t, err)
+
+			a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+				Extension: archives.Ext("libfoo.a"),
+			})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			for _, f := range files {
+				h, err := a.Next()
+				assert.NilError(t, err)
+				assert.Equal(t, h.Name, f.Name)
+				assert.Equal(t, h.Size, int64(len(f.Contents)))
+
+				// Skip reading the contents of the first member to ensure
+				// Next handles the padding.
+				if f.Name == "short.o" {
+					assert.Equal(t, h.Mode, os.FileMode(0o644))
+					assert.Equal(t, h.UID, 1000)
+					assert.Equal(t, h.GID, 100)
+					assert.Assert(t, h.ModTime.Equal(modTime))
+					continue
+				}
+
+				contents, err := io.ReadAll(a)
+				assert.NilError(t, err)
+				assert.Equal(t, string(contents), string(f.Contents))
+			}
+
+			_, err = a.Next()
+			assert.Equal(t, err, io.EOF)
+		})
+	}
+}
+
+func TestDeb(t *testing.T) {
+	containers := []struct {
+		value tartest.Container
+		name  string
+	}{
+		{tartest.ContainerNone, "data.tar"},
+		{tartest.ContainerGz, "data.tar.gz"},
+		{tartest.ContainerXz, "data.tar.xz"},
+		{tartest.ContainerZstd, "data.tar.zst"},
+	}
+	for _, container := range containers {
+		t.Run(container.name, func(t *testing.T) {
+			control, err := tartest.Create(tartest.WithContainer(tartest.ContainerGz))
+			assert.NilError(t, err)
+			controlBytes, err := io.ReadAll(control)
+			assert.NilError(t, err)
+
+			data, err := tartest.Create(tartest.WithContainer(container.value))
+			assert.NilError(t, err)
+			dataBytes, err := io.ReadAll(data)
+			assert.NilError(t, err)
+
+			b, err := artest.Create([]artest.File{
+				{Name: "debian-binary", Contents: []byte("2.0\n"), Mode: 0o100644},
+				{Name: "control.tar.gz", Contents: controlBytes, Mode: 0o100644},
+				{Name: container.name, Contents: dataBytes, Mode: 0o100644},
+			})
+			assert.NilError(t, err)
+
+			dest := t.TempDir()
+			assert.NilError(t, archives.Extract(bytes.NewReader(b), dest, archives.ExtractOptions{
+				Extension: archives.Ext("tool_1.0_amd64.deb"),
+			}))
+
+			got, err := os.ReadFile(filepath.Join(dest, "file.txt"))
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), "hello world")
+		})
+	}
+}
+
+func TestDebMissingData(t *testing.T) {
+	b, err := artest.Create([]artest.File{
+		{Name: "debian-binary", Contents: []byte("2.0\n"), Mode: 0o100644},
+	})
+	assert.NilError(t, err)
+
+	_, err = archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".deb"})
+	assert.ErrorContains(t, err, "no data.tar member")
+}
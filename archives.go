@@ -23,15 +23,19 @@ package archives
 import (
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"os"
 	"path/filepath"
 	"strings"
+
+	"golang.org/x/text/encoding"
 )
 
 // Configures extractors supported by this package and values
 // initialized by the init function.
 var (
-	extractors = []Archiver{&tar{}, &zip{}}
+	extractors = []Archiver{&tar{}, &zip{}, &sevenzip{}, &ar{}, &deb{}, &rpm{}, &cpio{}, &iso{}, &compressed{}}
 	extensions = map[string]Archiver{}
 )
 
@@ -57,6 +61,45 @@ type OpenOptions struct {
 	// all formats (e.g., .tar.gz and .zip). If you opt to use
 	// [filepath.Ext] it will not include the second extension.
 	Extension string
+
+	// Name is the file name of the archive. It is only used by formats
+	// that do not store the names of their contents, such as single
+	// compressed files (e.g., foo.gz), where the entry is named after the
+	// archive with the extension stripped.
+	Name string
+
+	// Password is the password used to decrypt encrypted files (see
+	// [Header.Encrypted]). Currently only supported by zip archives,
+	// using traditional PKWARE encryption or WinZip AES.
+	Password string
+
+	// PasswordProvider, if set, is called to get the password of every
+	// encrypted file when its contents are first read. It takes
+	// precedence over Password.
+	PasswordProvider PasswordProviderFn
+
+	// NameDecoder, if set, decodes file names that aren't marked as
+	// UTF-8. Currently only used by zip archives, where names that aren't
+	// valid UTF-8 are decoded as CP437 by default, as done by most zip
+	// tools. The name as stored in the archive is available as
+	// [Header.RawName].
+	NameDecoder *encoding.Decoder
+}
+
+// PasswordProviderFn returns the password used to decrypt the file
+// described by the provided header.
+type PasswordProviderFn func(h *Header) (string, error)
+
+// password returns the password to decrypt the file described by h.
+func (o *OpenOptions) password(h *Header) (string, error) {
+	if o.PasswordProvider != nil {
+		return o.PasswordProvider(h)
+	}
+	if o.Password == "" {
+		return "", ErrPasswordRequired
+	}
+
+	return o.Password, nil
 }
 
 // ExtractOptions contains the options for extracting an archive.
@@ -70,8 +113,23 @@ type ExtractOptions struct {
 	// 		 .tar.gz
 	Extension string
 
+	// Name is the file name of the archive. See [OpenOptions.Name].
+	Name string
+
+	// Password is the password used to decrypt encrypted files. See
+	// [OpenOptions.Password].
+	Password string
+
+	// PasswordProvider returns the password used to decrypt encrypted
+	// files. See [OpenOptions.PasswordProvider].
+	PasswordProvider PasswordProviderFn
+
+	// NameDecoder decodes file names that aren't marked as UTF-8. See
+	// [OpenOptions.NameDecoder].
+	NameDecoder *encoding.Decoder
+
 	// PreservePermissions, if set, will preserve the permissions of the
-	// files in the archive.
+	// files in the archive, see ModeMask, AllowSetuid and ApplyUmask.
 	//
 	// Defaults to true.
 	PreservePermissions *bool
@@ -82,6 +140,95 @@ type ExtractOptions struct {
 	//
 	// Defaults to false.
 	PreserveOwnership bool
+
+	// IDMap, if set, maps the user and group IDs of entries to the IDs
+	// they're owned by when PreserveOwnership is set, e.g. to extract
+	// container images into a user namespace. Otherwise the IDs from the
+	// archive are used as is.
+	IDMap *IDMap
+
+	// ModeMask, if set, clears the permission bits not in it from the
+	// permissions preserved by PreservePermissions. For example, 0o755
+	// prevents extracted files from being writable by group and others.
+	// Only the bits of [os.ModePerm] are masked, see AllowSetuid for the
+	// setuid and setgid bits.
+	ModeMask os.FileMode
+
+	// AllowSetuid, if set, preserves the setuid and setgid bits of
+	// entries when PreservePermissions is set. Otherwise they're cleared,
+	// so that untrusted archives can't create setuid executables.
+	//
+	// Defaults to false.
+	AllowSetuid bool
+
+	// ApplyUmask, if set, clears the bits set in the umask of the process
+	// from the permissions preserved by PreservePermissions, like tar
+	// does when not run by root. Only supported on Unix systems.
+	ApplyUmask bool
+
+	// ForceFileMode, if set, is the mode of all extracted files,
+	// regardless of their mode in the archive and PreservePermissions.
+	ForceFileMode os.FileMode
+
+	// ForceDirMode, if set, is the mode of all extracted directories,
+	// regardless of their mode in the archive and PreservePermissions.
+	// Directories created because they were missing from the archive
+	// aren't affected.
+	ForceDirMode os.FileMode
+
+	// Verifier, if set, verifies a detached signature over the raw
+	// archive as it is read. The archive is always extracted into a
+	// staging directory (see Atomic) and is only moved into the
+	// destination if verification succeeds.
+	Verifier Verifier
+
+	// Atomic, if set, extracts the archive into a temporary staging
+	// directory next to the destination and only moves the extracted
+	// files into the destination once extraction has succeeded. On
+	// failure, the destination is left untouched.
+	//
+	// Always enabled when Verifier is set. Defaults to false.
+	Atomic bool
+
+	// Recurse, if greater than zero, is the maximum depth of nested
+	// archives to extract. Entries that are archives themselves (see
+	// [Ext]) are extracted into a directory named after the entry
+	// followed by "!" instead of being written as is. See [Recurse].
+	Recurse int
+
+	// MaxEntries, if greater than zero, is the maximum number of entries
+	// to extract, including nested archives. See
+	// [RecurseOptions.MaxEntries].
+	MaxEntries int
+
+	// MaxSize, if greater than zero, is the maximum number of bytes to
+	// extract, including nested archives. See [RecurseOptions.MaxSize].
+	MaxSize int64
+
+	// Layer, if set, extracts the archive as a layer of a container
+	// image, handling its whiteout files as denoted by the [LayerMode].
+	// Existing directories replaced by other types of entries, and vice
+	// versa, are removed.
+	//
+	// [LayerApply] can't be used with Atomic or Verifier, as whiteout
+	// files are applied to the destination.
+	Layer LayerMode
+
+	// OnEntryError, if set, is called when an entry fails to be
+	// extracted, such as entries escaping the destination, whose contents
+	// are corrupt or nested archives that fail to open (see Recurse). If
+	// it returns nil, the entry is skipped and extraction continues,
+	// otherwise extraction stops and the returned error is returned.
+	// Errors reading the archive itself (e.g., a truncated archive)
+	// always stop extraction.
+	//
+	// If not set, extraction stops at the first entry that fails.
+	OnEntryError func(h *Header, err error) error
+
+	// Digest, if set, returns the hash used to compute the digest of
+	// extracted files (e.g., [crypto/sha256.New]), as returned by
+	// [ExtractWithResult] in [ExtractedEntry.Digest].
+	Digest func() hash.Hash
 }
 
 // ptr returns a pointer to the provided value.
@@ -106,18 +253,30 @@ func applyDefaults(opts *ExtractOptions) {
 //	archives.Ext("file.zip")      // ".zip"
 //	archives.Ext("file.unknown")  // ".unknown"
 func Ext(name string) string {
-	// try supported extensions first
+	// try supported extensions first, preferring the longest match so
+	// that .tar.gz is not returned as .gz.
+	var match string
 	for ext := range extensions {
-		if strings.HasSuffix(name, ext) {
-			// Return leading period to match [filepath.Ext].
-			return "." + ext
+		if len(ext) > len(match) && strings.HasSuffix(name, "."+ext) {
+			match = ext
 		}
 	}
+	if match != "" {
+		// Return leading period to match [filepath.Ext].
+		return "." + match
+	}
 
 	// fallback to filepath.Ext
 	return strings.ToLower(filepath.Ext(name))
 }
 
+// Supported returns true if archives with the provided extension (see
+// [Ext]) can be opened.
+func Supported(ext string) bool {
+	_, ok := extensions[strings.TrimPrefix(ext, ".")]
+	return ok
+}
+
 // Open opens an archive from the provided reader. The underlying
 // [Archiver] is determined by the extension of the archive.
 func Open(r io.Reader, opts OpenOptions) (Archive, error) {
@@ -131,24 +290,163 @@ func Open(r io.Reader, opts OpenOptions) (Archive, error) {
 
 	archiver, ok := extensions[ext]
 	if !ok || archiver == nil {
-		return nil, fmt.Errorf("unsupported archive extension: %s", ext)
+		return nil, fmt.Errorf("%w: extension %s", ErrUnsupportedFormat, ext)
+	}
+	a, err := openArchive(archiver, r, ext, &opts)
+	return a, classifyError(err)
+}
+
+// openArchive opens r using archiver, passing opts to archivers
+// implementing [OptionsArchiver].
+func openArchive(archiver Archiver, r io.Reader, ext string, opts *OpenOptions) (Archive, error) {
+	if oa, ok := archiver.(OptionsArchiver); ok {
+		return oa.OpenWithOptions(r, ext, opts)
 	}
+
 	return archiver.Open(r, ext)
 }
 
+// OpenReaderAt opens an archive of the provided size from r. Formats
+// requiring random access (see [ReaderAtArchiver]) read the archive in
+// place, all other formats read it sequentially as if it was passed to
+// [Open].
+func OpenReaderAt(r io.ReaderAt, size int64, opts OpenOptions) (Archive, error) {
+	if r == nil {
+		return nil, fmt.Errorf("reader must not be nil")
+	} else if opts.Extension == "" {
+		return nil, fmt.Errorf("extension must be provided (set opts.Extension)")
+	}
+
+	ext := strings.TrimPrefix(opts.Extension, ".")
+
+	archiver, ok := extensions[ext]
+	if !ok || archiver == nil {
+		return nil, fmt.Errorf("%w: extension %s", ErrUnsupportedFormat, ext)
+	}
+
+	if ra, ok := archiver.(ReaderAtArchiver); ok {
+		a, err := ra.OpenReaderAt(r, size, ext, &opts)
+		return a, classifyError(err)
+	}
+
+	a, err := openArchive(archiver, io.NewSectionReader(r, 0, size), ext, &opts)
+	return a, classifyError(err)
+}
+
 // Extract extracts an archive to the provided destination. The
 // underlying [Archiver] is determined by the extension of the archive.
 func Extract(r io.Reader, dest string, opts ExtractOptions) error {
-	applyDefaults(&opts)
+	_, err := ExtractWithResult(r, dest, opts)
+	return err
+}
+
+// ExtractWithResult extracts an archive to the provided destination, as
+// done by [Extract], returning a manifest of the entries that were
+// extracted, where they were written to, and which entries were skipped
+// (see [ExtractOptions.OnEntryError]).
+//
+// The result is returned even if extraction fails, containing the
+// entries extracted before the failure. If the archive was extracted
+// into a staging directory (see [ExtractOptions.Atomic]), nothing is
+// written to dest on failure, and the result contains no written
+// entries.
+func ExtractWithResult(r io.Reader, dest string, opts ExtractOptions) (*ExtractResult, error) {
+	res := &ExtractResult{}
+	err := extractWithResult(r, dest, &opts, res)
+	res.resolve(dest)
+
+	return res, err
+}
+
+// extractWithResult implements [ExtractWithResult], recording the
+// entries in res using paths relative to dest.
+func extractWithResult(r io.Reader, dest string, opts *ExtractOptions, res *ExtractResult) error {
+	applyDefaults(opts)
+
+	if opts.Layer == LayerApply && (opts.Atomic || opts.Verifier != nil) {
+		return fmt.Errorf("layers can't be applied atomically")
+	}
+
+	if opts.Verifier != nil {
+		r = io.TeeReader(r, opts.Verifier)
+	}
 
 	a, err := Open(r, OpenOptions{
-		Extension: opts.Extension,
+		Extension:        opts.Extension,
+		Name:             opts.Name,
+		Password:         opts.Password,
+		PasswordProvider: opts.PasswordProvider,
+		NameDecoder:      opts.NameDecoder,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to open archive: %w", err)
 	}
 
-	return extract(a, dest, &opts)
+	a = Recurse(a, RecurseOptions{
+		MaxDepth:   opts.Recurse,
+		MaxEntries: opts.MaxEntries,
+		MaxSize:    opts.MaxSize,
+		OpenOptions: OpenOptions{
+			Password:         opts.Password,
+			PasswordProvider: opts.PasswordProvider,
+			NameDecoder:      opts.NameDecoder,
+		},
+	})
+	defer a.Close() //nolint:errcheck // Why: Best effort.
+
+	if !opts.Atomic && opts.Verifier == nil {
+		return extract(a, dest, opts, res)
+	}
+
+	s, err := newStaging(dest)
+	if err != nil {
+		return err
+	}
+	defer s.Cleanup() //nolint:errcheck // Why: Best effort.
+
+	// Nothing is written to dest unless the staging directory is
+	// committed.
+	committed := false
+	defer func() {
+		if !committed {
+			res.Written = nil
+		}
+	}()
+
+	// Directories stay writable until they have been moved into dest,
+	// which requires them to be writable.
+	dirs, err := extractEntries(a, s.dir, opts, res)
+	if err != nil {
+		return err
+	}
+
+	if opts.Verifier != nil {
+		// Archive formats may not read all the way to the end of the
+		// stream (e.g., tar padding), so ensure the verifier sees every
+		// byte.
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if err := opts.Verifier.Verify(); err != nil {
+			return fmt.Errorf("failed to verify archive: %w", err)
+		}
+	}
+
+	if err := s.Commit(dest); err != nil {
+		return err
+	}
+	committed = true
+
+	for i := range dirs {
+		rel, err := filepath.Rel(s.dir, dirs[i].Path)
+		if err != nil {
+			return fmt.Errorf("failed to determine path of %s: %w", dirs[i].Header.Name, err)
+		}
+		dirs[i].Path = filepath.Join(dest, rel)
+	}
+
+	return setDirsMetadata(dest, dirs, opts, res)
 }
 
 // PickFilterFn is a function that filters files in an archive.
@@ -161,12 +459,14 @@ type PickFilterFn func(*Header) bool
 // If the caller intends to pick one file from an archive, they should
 // also make sure to close the archive after they are done with the
 // returned [io.Reader] to prevent resource leaks.
+//
+// To pick files from archives nested in a, wrap it using [Recurse].
 func Pick(a Archive, filter PickFilterFn) (io.Reader, error) {
 	for {
 		h, err := a.Next()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				return nil, fmt.Errorf("file not found in archive")
+				return nil, ErrNotFound
 			}
 
 			return nil, fmt.Errorf("failed to read archive header: %w", err)
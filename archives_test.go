@@ -1,11 +1,15 @@
 package archives_test
 
 import (
+	stdtar "archive/tar"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"go.rgst.io/jaredallard/archives/v2"
 	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
@@ -53,6 +57,264 @@ func TestCanExtractFromTar(t *testing.T) {
 	assert.Equal(t, string(got), "hello world")
 }
 
+// createTar creates a tar archive containing the provided headers,
+// with the contents of regular files set to their name.
+func createTar(t *testing.T, hdrs ...*stdtar.Header) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	tw := stdtar.NewWriter(buf)
+	for _, h := range hdrs {
+		if h.Typeflag == stdtar.TypeReg {
+			h.Size = int64(len(h.Name))
+		}
+		assert.NilError(t, tw.WriteHeader(h))
+
+		if h.Typeflag == stdtar.TypeReg {
+			_, err := tw.Write([]byte(h.Name))
+			assert.NilError(t, err)
+		}
+	}
+	assert.NilError(t, tw.Close())
+
+	return buf
+}
+
+func TestExtractSymlink(t *testing.T) {
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(createTar(t,
+		&stdtar.Header{Name: "bin/tool", Typeflag: stdtar.TypeReg, Mode: 0o755},
+		&stdtar.Header{Name: "tool", Typeflag: stdtar.TypeSymlink, Linkname: "bin/tool", Uname: "root"},
+	), dest, archives.ExtractOptions{Extension: ".tar"}))
+
+	target, err := os.Readlink(filepath.Join(dest, "tool"))
+	assert.NilError(t, err)
+	assert.Equal(t, target, "bin/tool")
+
+	got, err := os.ReadFile(filepath.Join(dest, "tool"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "bin/tool")
+}
+
+func TestExtractRefusesToWriteThroughSymlink(t *testing.T) {
+	dest := t.TempDir()
+	outside := t.TempDir()
+
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "escape", Typeflag: stdtar.TypeSymlink, Linkname: outside},
+		&stdtar.Header{Name: "escape/file.txt", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), dest, archives.ExtractOptions{Extension: ".tar"})
+	assert.ErrorContains(t, err, "refusing to write through symlink")
+
+	_, err = os.Stat(filepath.Join(outside, "file.txt"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestExtractReplacesSymlink(t *testing.T) {
+	dest := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilError(t, os.WriteFile(outside, []byte("outside"), 0o644))
+
+	assert.NilError(t, archives.Extract(createTar(t,
+		&stdtar.Header{Name: "file.txt", Typeflag: stdtar.TypeSymlink, Linkname: outside},
+		&stdtar.Header{Name: "file.txt", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), dest, archives.ExtractOptions{Extension: ".tar"}))
+
+	got, err := os.ReadFile(outside)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "outside")
+
+	got, err = os.ReadFile(filepath.Join(dest, "file.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "file.txt")
+}
+
+func TestExtractHardlink(t *testing.T) {
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(createTar(t,
+		&stdtar.Header{Name: "bin/tool", Typeflag: stdtar.TypeReg, Mode: 0o755},
+		&stdtar.Header{Name: "tool", Typeflag: stdtar.TypeLink, Linkname: "bin/tool"},
+	), dest, archives.ExtractOptions{Extension: ".tar"}))
+
+	a, err := os.Stat(filepath.Join(dest, "bin", "tool"))
+	assert.NilError(t, err)
+	b, err := os.Stat(filepath.Join(dest, "tool"))
+	assert.NilError(t, err)
+	assert.Assert(t, os.SameFile(a, b))
+}
+
+func TestExtractRefusesHardlinkOutsideDestination(t *testing.T) {
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "passwd", Typeflag: stdtar.TypeLink, Linkname: "../../etc/passwd"},
+	), t.TempDir(), archives.ExtractOptions{Extension: ".tar"})
+	assert.ErrorContains(t, err, "content filepath is tainted")
+}
+
+func TestExtractDirectoryMetadata(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't supported on Windows")
+	}
+
+	roTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	dirTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	tarArchive := createTar(t,
+		&stdtar.Header{Name: "ro/", Typeflag: stdtar.TypeDir, Mode: 0o555, ModTime: roTime},
+		&stdtar.Header{Name: "ro/file", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "dir/", Typeflag: stdtar.TypeDir, Mode: 0o750, ModTime: dirTime},
+		&stdtar.Header{Name: "dir/sub/file", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	).Bytes()
+
+	for _, atomic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("atomic=%v", atomic), func(t *testing.T) {
+			dest := t.TempDir()
+			t.Cleanup(func() {
+				// Allow the temporary directory to be removed.
+				assert.NilError(t, os.Chmod(filepath.Join(dest, "ro"), 0o755))
+			})
+
+			// Extracting twice merges the staging directory into the
+			// existing directory, which has to be writable like it has to
+			// be when extracting without staging.
+			for range 2 {
+				_ = os.Chmod(filepath.Join(dest, "ro"), 0o755) //nolint:errcheck // Why: Doesn't exist the first time.
+
+				assert.NilError(t, archives.Extract(bytes.NewReader(tarArchive), dest, archives.ExtractOptions{
+					Extension: ".tar",
+					Atomic:    atomic,
+				}))
+			}
+
+			_, err := os.Stat(filepath.Join(dest, "ro", "file"))
+			assert.NilError(t, err)
+
+			fi, err := os.Stat(filepath.Join(dest, "ro"))
+			assert.NilError(t, err)
+			assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o555))
+			assert.Assert(t, fi.ModTime().Equal(roTime), "mtime is %v", fi.ModTime())
+
+			fi, err = os.Stat(filepath.Join(dest, "dir"))
+			assert.NilError(t, err)
+			assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o750))
+			assert.Assert(t, fi.ModTime().Equal(dirTime), "mtime is %v", fi.ModTime())
+		})
+	}
+}
+
+func TestExtractMergesExistingDirectories(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't supported on Windows")
+	}
+
+	dirTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	tarArchive := createTar(t,
+		&stdtar.Header{Name: "dir/", Typeflag: stdtar.TypeDir, Mode: 0o750, ModTime: dirTime},
+		&stdtar.Header{Name: "implicit/file", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	).Bytes()
+
+	for _, preserve := range []bool{false, true} {
+		for _, atomic := range []bool{false, true} {
+			t.Run(fmt.Sprintf("preserve=%v/atomic=%v", preserve, atomic), func(t *testing.T) {
+				dest := t.TempDir()
+				for _, dir := range []string{"dir", "implicit"} {
+					assert.NilError(t, os.Mkdir(filepath.Join(dest, dir), 0o700))
+					assert.NilError(t, os.Chmod(filepath.Join(dest, dir), 0o700))
+				}
+
+				assert.NilError(t, archives.Extract(bytes.NewReader(tarArchive), dest, archives.ExtractOptions{
+					Extension:           ".tar",
+					Atomic:              atomic,
+					PreservePermissions: &preserve,
+				}))
+
+				want := os.FileMode(0o700)
+				if preserve {
+					want = 0o750
+				}
+
+				fi, err := os.Stat(filepath.Join(dest, "dir"))
+				assert.NilError(t, err)
+				assert.Equal(t, fi.Mode().Perm(), want)
+				assert.Assert(t, fi.ModTime().Equal(dirTime), "mtime is %v", fi.ModTime())
+
+				// Directories that aren't entries of the archive are left as
+				// they are.
+				fi, err = os.Stat(filepath.Join(dest, "implicit"))
+				assert.NilError(t, err)
+				assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o700))
+			})
+		}
+	}
+}
+
+func TestExtractStagedReadOnlyDirectories(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't supported on Windows")
+	}
+
+	roTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tarArchive := createTar(t,
+		&stdtar.Header{Name: "ro/", Typeflag: stdtar.TypeDir, Mode: 0o555, ModTime: roTime},
+		&stdtar.Header{Name: "ro/sub/", Typeflag: stdtar.TypeDir, Mode: 0o555, ModTime: roTime},
+		&stdtar.Header{Name: "ro/sub/file", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	).Bytes()
+
+	for _, dest := range []string{"missing", "existing"} {
+		t.Run(dest, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), dest)
+			if filepath.Base(dest) == "existing" {
+				assert.NilError(t, os.Mkdir(dest, 0o755))
+			}
+			t.Cleanup(func() {
+				// Allow the temporary directory to be removed.
+				assert.NilError(t, os.Chmod(filepath.Join(dest, "ro"), 0o755))
+				assert.NilError(t, os.Chmod(filepath.Join(dest, "ro", "sub"), 0o755))
+			})
+
+			// Moving read-only directories out of the staging directory
+			// fails for non-root users unless they're still writable.
+			assert.NilError(t, archives.Extract(bytes.NewReader(tarArchive), dest, archives.ExtractOptions{
+				Extension: ".tar",
+				Atomic:    true,
+			}))
+
+			_, err := os.Stat(filepath.Join(dest, "ro", "sub", "file"))
+			assert.NilError(t, err)
+
+			for _, dir := range []string{"ro", filepath.Join("ro", "sub")} {
+				fi, err := os.Stat(filepath.Join(dest, dir))
+				assert.NilError(t, err)
+				assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o555))
+				assert.Assert(t, fi.ModTime().Equal(roTime), "mtime is %v", fi.ModTime())
+			}
+		})
+	}
+}
+
+func TestExtractClearsSetuid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't supported on Windows")
+	}
+
+	tarArchive := createTar(t,
+		&stdtar.Header{Name: "suid", Typeflag: stdtar.TypeReg, Mode: 0o4755},
+	).Bytes()
+
+	for _, allow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allow=%v", allow), func(t *testing.T) {
+			dest := t.TempDir()
+			assert.NilError(t, archives.Extract(bytes.NewReader(tarArchive), dest, archives.ExtractOptions{
+				Extension:   ".tar",
+				AllowSetuid: allow,
+			}))
+
+			fi, err := os.Stat(filepath.Join(dest, "suid"))
+			assert.NilError(t, err)
+			assert.Equal(t, fi.Mode()&os.ModeSetuid != 0, allow)
+			assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o755))
+		})
+	}
+}
+
 func TestExt(t *testing.T) {
 	type testCase struct {
 		name     string // defaults to filename if not set
@@ -69,6 +331,14 @@ func TestExt(t *testing.T) {
 			filename: "file.zip",
 			expected: ".zip",
 		},
+		{
+			filename: "file.gz",
+			expected: ".gz",
+		},
+		{
+			filename: "file.tgz",
+			expected: ".tgz",
+		},
 		{
 			filename: "file.unknown",
 			expected: ".unknown",
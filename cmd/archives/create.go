@@ -0,0 +1,89 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// runCreate implements the create command.
+func runCreate(c *cli, args []string) (retErr error) {
+	flags := c.newFlagSet()
+	output := flags.String("o", "", "path of the archive to create, - for stdout (required)")
+	ext := flags.String("ext", "", "extension of the archive (e.g. .tar.gz), determined from its name if not set")
+	dir := flags.String("C", "", "directory the paths are relative to")
+	reproducible := flags.Bool("reproducible", false, "create a reproducible archive, with sorted paths and normalized times (SOURCE_DATE_EPOCH), owners and permissions")
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+	if *output == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	if *ext == "" {
+		if *output == "-" {
+			return fmt.Errorf("-ext is required when writing to stdout")
+		}
+		*ext = archives.Ext(*output)
+	}
+
+	var w io.Writer = c.stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := f.Close(); retErr == nil {
+				retErr = err
+			}
+			// Don't leave incomplete archives behind.
+			if retErr != nil {
+				os.Remove(*output) //nolint:errcheck,gosec // Why: Best effort.
+			}
+		}()
+		w = f
+	}
+
+	aw, err := archives.Create(w, archives.CreateOptions{Extension: *ext, Reproducible: *reproducible})
+	if err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	if *reproducible {
+		paths = slices.Sorted(slices.Values(paths))
+	}
+
+	for _, p := range paths {
+		if err := archives.AddPath(aw, filepath.Join(*dir, p), filepath.Clean(p)); err != nil {
+			return errors.Join(err, aw.Close())
+		}
+	}
+
+	return aw.Close()
+}
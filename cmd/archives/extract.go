@@ -0,0 +1,254 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// runExtract implements the extract command.
+func runExtract(c *cli, args []string) error {
+	var of openFlags
+	var vf verifyFlags
+	flags := c.newFlagSet()
+	of.register(flags, false)
+	vf.register(flags)
+	preservePermissions := flags.Bool("preserve-permissions", true, "preserve the permissions of the extracted files")
+	allowSetuid := flags.Bool("allow-setuid", false, "preserve the setuid and setgid bits of the extracted files")
+	umask := flags.Bool("umask", false, "apply the umask to the permissions of the extracted files")
+	modeMask := modeFlag(flags, "mode-mask", "octal `mask` of the permission bits to preserve, e.g. 755")
+	fileMode := modeFlag(flags, "file-mode", "octal `mode` of all extracted files, regardless of the archive")
+	dirMode := modeFlag(flags, "dir-mode", "octal `mode` of all extracted directories, regardless of the archive")
+	preserveOwnership := flags.Bool("preserve-ownership", false, "preserve the ownership of the extracted files")
+	var idMap archives.IDMap
+	idRangesFlag(flags, &idMap.UIDs, "uid-map", "map user IDs using the `range` archive:host:size, may be repeated (implies -preserve-ownership)")
+	idRangesFlag(flags, &idMap.GIDs, "gid-map", "map group IDs using the `range` archive:host:size, may be repeated (implies -preserve-ownership)")
+	flags.BoolVar(&idMap.ByName, "map-by-name", false, "map owners to the users and groups of the system with the same name (implies -preserve-ownership)")
+	flags.Func("id-fallback", "`policy` for IDs not mapped by -uid-map, -gid-map or -map-by-name: error, keep or uid:gid (default error)", func(s string) error {
+		return parseIDFallback(s, &idMap)
+	})
+	layer := archives.LayerNone
+	flags.Func("layer", "extract the archive as a container image layer, handling whiteout files using `mode` apply or overlay", func(s string) error {
+		var err error
+		layer, err = parseLayerMode(s)
+		return err
+	})
+	atomic := flags.Bool("atomic", false, "extract into a staging directory, only moved into place once extraction succeeded")
+	manifest := flags.String("manifest", "", "write a JSON manifest of the extracted paths and their sha256 digests to this file, - for stdout")
+	keepGoing := flags.Bool("keep-going", false, "skip entries that fail to be extracted instead of stopping, reporting them")
+	if err := parse(flags, args, 2); err != nil {
+		return err
+	}
+
+	path, dest := flags.Arg(0), flags.Arg(1)
+	openOpts, err := of.options(path)
+	if err != nil {
+		return err
+	}
+
+	v, err := vf.verifier(path)
+	if err != nil {
+		return err
+	}
+
+	r, ext, closeFn, err := of.input(c, path)
+	if err != nil {
+		return err
+	}
+	defer closeFn() //nolint:errcheck // Why: Best effort.
+
+	opts := archives.ExtractOptions{
+		Extension:           ext,
+		Name:                openOpts.Name,
+		Password:            openOpts.Password,
+		PreservePermissions: preservePermissions,
+		PreserveOwnership:   *preserveOwnership,
+		ModeMask:            *modeMask,
+		AllowSetuid:         *allowSetuid,
+		ApplyUmask:          *umask,
+		ForceFileMode:       *fileMode,
+		ForceDirMode:        *dirMode,
+		Layer:               layer,
+		Verifier:            v,
+		Atomic:              *atomic,
+		Recurse:             of.recurse,
+		MaxEntries:          of.maxEntries,
+		MaxSize:             of.maxSize,
+	}
+	if len(idMap.UIDs) > 0 || len(idMap.GIDs) > 0 || idMap.ByName {
+		opts.PreserveOwnership = true
+		opts.IDMap = &idMap
+	}
+	if *manifest != "" {
+		opts.Digest = sha256.New
+	}
+	if *keepGoing {
+		opts.OnEntryError = func(h *archives.Header, err error) error {
+			fmt.Fprintf(c.stderr, "archives extract: skipping %s: %v\n", h.Name, err)
+			return nil
+		}
+	}
+
+	res, err := archives.ExtractWithResult(r, dest, opts)
+	if err != nil {
+		return err
+	}
+
+	if *manifest != "" {
+		if err := writeManifest(c, *manifest, res); err != nil {
+			return err
+		}
+	}
+	if len(res.Skipped) > 0 {
+		return fmt.Errorf("%d entries could not be extracted", len(res.Skipped))
+	}
+
+	return nil
+}
+
+// manifestEntry is an entry of the manifest written by the extract
+// command using -manifest.
+type manifestEntry struct {
+	entry
+	Path     string `json:"path"`
+	Written  int64  `json:"written"`
+	SHA256   string `json:"sha256,omitempty"`
+	Implicit bool   `json:"implicit,omitempty"`
+}
+
+// writeManifest writes the manifest of the entries extracted according
+// to res to path, or stdout if path is "-".
+func writeManifest(c *cli, path string, res *archives.ExtractResult) error {
+	entries := make([]manifestEntry, 0, len(res.Written))
+	for i := range res.Written {
+		e := &res.Written[i]
+		entries = append(entries, manifestEntry{
+			entry:    newEntry(&e.Header),
+			Path:     e.Path,
+			Written:  e.Size,
+			SHA256:   hex.EncodeToString(e.Digest),
+			Implicit: e.Implicit,
+		})
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	if path == "-" {
+		_, err = c.stdout.Write(b)
+		return err
+	}
+
+	if err := os.WriteFile(path, b, 0o644); err != nil { //nolint:gosec // Why: The manifest isn't sensitive.
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// modeFlag defines a flag on flags holding a permission mode in octal.
+func modeFlag(flags *flag.FlagSet, name, usage string) *os.FileMode {
+	var mode os.FileMode
+	flags.Func(name, usage, func(s string) error {
+		m, err := strconv.ParseUint(s, 8, 32)
+		if err != nil || m == 0 || m > uint64(os.ModePerm) {
+			return fmt.Errorf("invalid mode %q, expected octal permissions (e.g. 755)", s)
+		}
+
+		mode = os.FileMode(m)
+		return nil
+	})
+	return &mode
+}
+
+// idRangesFlag defines a repeatable flag on flags appending the ID
+// ranges it is set to, in the form archive:host:size, to ranges.
+func idRangesFlag(flags *flag.FlagSet, ranges *[]archives.IDRange, name, usage string) {
+	flags.Func(name, usage, func(s string) error {
+		parts := strings.Split(s, ":")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid ID range %q, expected archive:host:size", s)
+		}
+
+		var ids [3]int
+		for i, p := range parts {
+			id, err := strconv.Atoi(p)
+			if err != nil || id < 0 {
+				return fmt.Errorf("invalid ID range %q, expected archive:host:size", s)
+			}
+			ids[i] = id
+		}
+		if ids[2] == 0 {
+			return fmt.Errorf("invalid ID range %q, size must be positive", s)
+		}
+
+		*ranges = append(*ranges, archives.IDRange{ArchiveID: ids[0], HostID: ids[1], Size: ids[2]})
+		return nil
+	})
+}
+
+// parseIDFallback sets the fallback of m to s, one of "error", "keep"
+// or the IDs to map to in the form uid:gid.
+func parseIDFallback(s string, m *archives.IDMap) error {
+	switch s {
+	case "error":
+		m.Fallback = archives.IDFallbackError
+	case "keep":
+		m.Fallback = archives.IDFallbackKeep
+	default:
+		uid, gid, ok := strings.Cut(s, ":")
+		var err error
+		if ok {
+			if m.FallbackUID, err = strconv.Atoi(uid); err == nil {
+				m.FallbackGID, err = strconv.Atoi(gid)
+			}
+		}
+		if !ok || err != nil {
+			return fmt.Errorf("invalid ID fallback %q, expected error, keep or uid:gid", s)
+		}
+		m.Fallback = archives.IDFallbackFixed
+	}
+
+	return nil
+}
+
+// parseLayerMode parses the layer mode s.
+func parseLayerMode(s string) (archives.LayerMode, error) {
+	switch s {
+	case "none":
+		return archives.LayerNone, nil
+	case "apply":
+		return archives.LayerApply, nil
+	case "overlay":
+		return archives.LayerOverlay, nil
+	default:
+		return archives.LayerNone, fmt.Errorf("invalid layer mode %q, expected apply or overlay", s)
+	}
+}
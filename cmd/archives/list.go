@@ -0,0 +1,221 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// entry is an entry of an archive, as output by the list command using
+// -json.
+type entry struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Size      int64     `json:"size"`
+	Mode      string    `json:"mode"`
+	Linkname  string    `json:"linkname,omitempty"`
+	ModTime   time.Time `json:"modTime"`
+	UID       int       `json:"uid"`
+	GID       int       `json:"gid"`
+	Uname     string    `json:"uname,omitempty"`
+	Gname     string    `json:"gname,omitempty"`
+	Encrypted bool      `json:"encrypted,omitempty"`
+}
+
+// newEntry returns the entry of h.
+func newEntry(h *archives.Header) entry {
+	return entry{
+		Name:      h.Name,
+		Type:      h.Type.String(),
+		Size:      h.Size,
+		Mode:      h.Mode.String(),
+		Linkname:  h.Linkname,
+		ModTime:   h.ModTime.UTC(),
+		UID:       h.UID,
+		GID:       h.GID,
+		Uname:     h.Uname,
+		Gname:     h.Gname,
+		Encrypted: h.Encrypted,
+	}
+}
+
+// runList implements the list command.
+func runList(c *cli, args []string) error {
+	var of openFlags
+	flags := c.newFlagSet()
+	of.register(flags, true)
+	jsonOutput := flags.Bool("json", false, "write the entries as a JSON array")
+	long := flags.Bool("l", false, "write the mode, size and modification time of entries")
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	a, err := of.open(c, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	entries := []entry{}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 1, ' ', 0)
+	err = archives.Walk(a, func(h *archives.Header) error {
+		switch {
+		case *jsonOutput:
+			entries = append(entries, newEntry(h))
+		case *long:
+			name := h.Name
+			if h.Linkname != "" {
+				name += " -> " + h.Linkname
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", h.Mode, h.Size, h.ModTime.UTC().Format(time.DateTime), name)
+		default:
+			fmt.Fprintln(tw, h.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	return tw.Flush()
+}
+
+// runCat implements the cat command.
+func runCat(c *cli, args []string) error {
+	var of openFlags
+	flags := c.newFlagSet()
+	of.register(flags, true)
+	if err := parse(flags, args, 2); err != nil {
+		return err
+	}
+
+	a, err := of.open(c, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	// The files are written in the order they are stored in the archive,
+	// as it is read sequentially.
+	remaining := flags.Args()[1:]
+	err = archives.Walk(a, func(h *archives.Header) error {
+		i := slices.Index(remaining, h.Name)
+		if i < 0 || h.Type != archives.HeaderFile {
+			return nil
+		}
+		remaining = slices.Delete(remaining, i, i+1)
+
+		if _, err := io.Copy(c.stdout, a); err != nil {
+			return fmt.Errorf("failed to read %s: %w", h.Name, err)
+		}
+		if len(remaining) == 0 {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(remaining) > 0 {
+		return fmt.Errorf("%w: %s", archives.ErrNotFound, remaining[0])
+	}
+	return nil
+}
+
+// runDetect implements the detect command.
+func runDetect(c *cli, args []string) error {
+	var of openFlags
+	flags := c.newFlagSet()
+	flags.StringVar(&of.ext, "ext", "", "extension of the archive (e.g. .tar.gz), detected from its contents if not set")
+	flags.StringVar(&of.name, "name", "", "file name of the archive, used to name the contents of single compressed files")
+	jsonOutput := flags.Bool("json", false, "write the information as JSON")
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+	r := c.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	ext := of.ext
+	if ext == "" {
+		var err error
+		if ext, r, err = archives.Detect(r); err != nil {
+			return err
+		}
+	}
+
+	name := of.name
+	if name == "" && path != "-" {
+		name = path
+	}
+
+	info, err := archives.Info(r, archives.OpenOptions{Extension: ext, Name: name})
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Extension string `json:"extension"`
+			*archives.ArchiveInfo
+		}{ext, info})
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "extension:\t%s\n", ext)
+	if info.Format != "" {
+		fmt.Fprintf(tw, "format:\t%s\n", info.Format)
+	}
+	if info.Codec != "" {
+		fmt.Fprintf(tw, "codec:\t%s\n", info.Codec)
+	}
+	fmt.Fprintf(tw, "entries:\t%d\n", info.Entries)
+	fmt.Fprintf(tw, "size:\t%d\n", info.Size)
+	fmt.Fprintf(tw, "compressed size:\t%d\n", info.CompressedSize)
+	fmt.Fprintf(tw, "ratio:\t%.2f\n", info.Ratio)
+	if info.Comment != "" {
+		fmt.Fprintf(tw, "comment:\t%s\n", info.Comment)
+	}
+	return tw.Flush()
+}
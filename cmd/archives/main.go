@@ -0,0 +1,171 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+// Command archives extracts, lists, creates and verifies archives
+// supported by the archives package.
+//
+// Usage:
+//
+//	archives <command> [flags] [arguments]
+//
+// Run archives help for the list of commands, or archives <command> -h
+// for the flags of a command. Archives are read from stdin if their
+// path is "-".
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	name  string
+	usage string
+	short string
+	run   func(c *cli, args []string) error
+}
+
+// commands contains all subcommands, in the order they are shown in
+// the usage.
+var commands = []command{
+	{"extract", "[flags] <archive> <dest>", "extract an archive into a directory", runExtract},
+	{"list", "[flags] <archive>", "list the entries of an archive", runList},
+	{"cat", "[flags] <archive> <name>...", "write the contents of files in an archive to stdout", runCat},
+	{"create", "[flags] -o <archive> <path>...", "create an archive from files and directories", runCreate},
+	{"detect", "[flags] <archive>", "detect the format of an archive and summarize it", runDetect},
+	{"verify", "[flags] <archive>", "verify the detached signature of an archive", runVerify},
+}
+
+// aliases contains alternative names of commands.
+var aliases = map[string]string{
+	"pick": "cat",
+	"ls":   "list",
+	"x":    "extract",
+}
+
+// cli contains the standard streams used by commands.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// cmd is the command being run.
+	cmd command
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run runs the command denoted by args and returns the exit code.
+func run(args []string, c *cli) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	name := args[0]
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		c.cmd = cmd
+		if err := cmd.run(c, args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			if errors.Is(err, errUsage) {
+				return 2
+			}
+
+			fmt.Fprintf(c.stderr, "archives %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(c.stderr, "archives: unknown command %q\n\n", args[0])
+	c.usage()
+	return 2
+}
+
+// usage writes the usage of the CLI to stderr.
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "Usage: archives <command> [flags] [arguments]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-8s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Run archives <command> -h for the flags of a command.")
+}
+
+// errUsage is returned by commands when invoked incorrectly, after the
+// usage has been written.
+var errUsage = errors.New("usage error")
+
+// newFlagSet returns a new [flag.FlagSet] for the command being run,
+// writing its usage to stderr.
+func (c *cli) newFlagSet() *flag.FlagSet {
+	cmd := c.cmd
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: archives %s %s\n\n%s.\n\nFlags:\n", cmd.name, cmd.usage, capitalize(cmd.short))
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args using fs, ensuring that at least minArgs arguments
+// are left.
+func parse(fs *flag.FlagSet, args []string, minArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	if fs.NArg() < minArgs {
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
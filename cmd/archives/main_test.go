@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// runCLI runs the CLI with args, returning its exit code and output.
+func runCLI(t *testing.T, stdin []byte, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	code = run(args, &cli{stdin: bytes.NewReader(stdin), stdout: &out, stderr: &errOut})
+	return code, out.String(), errOut.String()
+}
+
+// createSource creates a directory containing files to archive.
+func createSource(t *testing.T) string {
+	t.Helper()
+
+	src := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(src, "dir"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "dir", "a.txt"), []byte("hello"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "b.txt"), []byte("world"), 0o644))
+	return src
+}
+
+func TestCLI(t *testing.T) {
+	src := createSource(t)
+	archive := filepath.Join(t.TempDir(), "out.tar.gz")
+
+	code, _, stderr := runCLI(t, nil, "create", "-o", archive, "-C", src, "dir", "b.txt")
+	assert.Equal(t, code, 0, stderr)
+
+	code, stdout, stderr := runCLI(t, nil, "list", "-json", archive)
+	assert.Equal(t, code, 0, stderr)
+	var entries []entry
+	assert.NilError(t, json.Unmarshal([]byte(stdout), &entries))
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name+" "+e.Type)
+	}
+	assert.DeepEqual(t, names, []string{"dir/ dir", "dir/a.txt file", "b.txt file"})
+
+	code, stdout, stderr = runCLI(t, nil, "ls", archive)
+	assert.Equal(t, code, 0, stderr)
+	assert.Equal(t, stdout, "dir/\ndir/a.txt\nb.txt\n")
+
+	code, stdout, stderr = runCLI(t, nil, "pick", archive, "b.txt", "dir/a.txt")
+	assert.Equal(t, code, 0, stderr)
+	assert.Equal(t, stdout, "helloworld")
+
+	code, _, stderr = runCLI(t, nil, "cat", archive, "missing.txt")
+	assert.Equal(t, code, 1)
+	assert.Assert(t, strings.Contains(stderr, "file not found in archive: missing.txt"), stderr)
+
+	dest := t.TempDir()
+	code, _, stderr = runCLI(t, nil, "extract", archive, dest)
+	assert.Equal(t, code, 0, stderr)
+	b, err := os.ReadFile(filepath.Join(dest, "dir", "a.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "hello")
+
+	code, stdout, stderr = runCLI(t, nil, "detect", "-json", archive)
+	assert.Equal(t, code, 0, stderr)
+	var info struct {
+		Extension string `json:"extension"`
+		Format    string `json:"format"`
+		Codec     string `json:"codec"`
+		Entries   int    `json:"entries"`
+		Size      int64  `json:"size"`
+	}
+	assert.NilError(t, json.Unmarshal([]byte(stdout), &info))
+	assert.Equal(t, info.Extension, ".tar.gz")
+	assert.Equal(t, info.Format, "tar")
+	assert.Equal(t, info.Codec, "gz")
+	assert.Equal(t, info.Entries, 3)
+	assert.Equal(t, info.Size, int64(10))
+}
+
+func TestCLIStdin(t *testing.T) {
+	src := createSource(t)
+
+	code, archive, stderr := runCLI(t, nil, "create", "-o", "-", "-ext", ".zip", "-C", src, ".")
+	assert.Equal(t, code, 0, stderr)
+
+	// The format is detected from the contents of the archive.
+	code, stdout, stderr := runCLI(t, []byte(archive), "cat", "-", "dir/a.txt")
+	assert.Equal(t, code, 0, stderr)
+	assert.Equal(t, stdout, "hello")
+
+	dest := t.TempDir()
+	code, _, stderr = runCLI(t, []byte(archive), "x", "-", dest)
+	assert.Equal(t, code, 0, stderr)
+	b, err := os.ReadFile(filepath.Join(dest, "b.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "world")
+}
+
+func TestCLIUsage(t *testing.T) {
+	code, _, stderr := runCLI(t, nil)
+	assert.Equal(t, code, 2)
+	assert.Assert(t, strings.Contains(stderr, "Usage: archives <command>"), stderr)
+
+	code, _, stderr = runCLI(t, nil, "unknown")
+	assert.Equal(t, code, 2)
+	assert.Assert(t, strings.Contains(stderr, `unknown command "unknown"`), stderr)
+
+	code, _, stderr = runCLI(t, nil, "extract", "only-one-arg")
+	assert.Equal(t, code, 2)
+	assert.Assert(t, strings.Contains(stderr, "Usage: archives extract"), stderr)
+
+	code, _, stderr = runCLI(t, nil, "create", "b.txt")
+	assert.Equal(t, code, 2)
+	assert.Assert(t, strings.Contains(stderr, "Usage: archives create"), stderr)
+}
+
+func TestCLIExtractKeepGoing(t *testing.T) {
+	src := createSource(t)
+	archive := filepath.Join(t.TempDir(), "out.zip")
+	code, _, stderr := runCLI(t, nil, "create", "-o", archive, "-C", src, "b.txt", "dir")
+	assert.Equal(t, code, 0, stderr)
+
+	// Corrupt the last byte of the contents of b.txt, which precede its
+	// data descriptor.
+	b, err := os.ReadFile(archive)
+	assert.NilError(t, err)
+	i := bytes.Index(b, []byte("PK\x07\x08"))
+	b[i-1] ^= 0xff
+	assert.NilError(t, os.WriteFile(archive, b, 0o600))
+
+	code, _, _ = runCLI(t, nil, "extract", archive, t.TempDir())
+	assert.Equal(t, code, 1)
+
+	dest := t.TempDir()
+	code, _, stderr = runCLI(t, nil, "extract", "-keep-going", archive, dest)
+	assert.Equal(t, code, 1)
+	assert.Assert(t, strings.Contains(stderr, "skipping b.txt"), stderr)
+	assert.Assert(t, strings.Contains(stderr, "1 entries could not be extracted"), stderr)
+
+	got, err := os.ReadFile(filepath.Join(dest, "dir", "a.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello")
+}
+
+func TestCLIExtractManifest(t *testing.T) {
+	src := createSource(t)
+	archive := filepath.Join(t.TempDir(), "out.tar")
+	code, _, stderr := runCLI(t, nil, "create", "-o", archive, "-C", src, "dir/a.txt")
+	assert.Equal(t, code, 0, stderr)
+
+	dest := t.TempDir()
+	code, stdout, stderr := runCLI(t, nil, "extract", "-manifest", "-", archive, dest)
+	assert.Equal(t, code, 0, stderr)
+
+	var manifest []struct {
+		Name     string `json:"name"`
+		Path     string `json:"path"`
+		Written  int64  `json:"written"`
+		SHA256   string `json:"sha256"`
+		Implicit bool   `json:"implicit"`
+	}
+	assert.NilError(t, json.Unmarshal([]byte(stdout), &manifest))
+	assert.Equal(t, len(manifest), 2)
+	assert.Equal(t, manifest[0].Path, filepath.Join(dest, "dir"))
+	assert.Assert(t, manifest[0].Implicit)
+	assert.Equal(t, manifest[1].Name, "dir/a.txt")
+	assert.Equal(t, manifest[1].Written, int64(5))
+	assert.Equal(t, manifest[1].SHA256, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+}
+
+func TestCLIExtractModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't supported on Windows")
+	}
+
+	src := createSource(t)
+	archive := filepath.Join(t.TempDir(), "out.tar")
+	code, _, stderr := runCLI(t, nil, "create", "-o", archive, "-C", src, "dir")
+	assert.Equal(t, code, 0, stderr)
+
+	dest := t.TempDir()
+	code, _, stderr = runCLI(t, nil, "extract", "-file-mode", "600", "-dir-mode", "700", archive, dest)
+	assert.Equal(t, code, 0, stderr)
+
+	fi, err := os.Stat(filepath.Join(dest, "dir"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o700))
+
+	fi, err = os.Stat(filepath.Join(dest, "dir", "a.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o600))
+
+	dest = t.TempDir()
+	code, _, stderr = runCLI(t, nil, "extract", "-mode-mask", "640", archive, dest)
+	assert.Equal(t, code, 0, stderr)
+
+	fi, err = os.Stat(filepath.Join(dest, "dir", "a.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Perm(), os.FileMode(0o640))
+}
+
+func TestCLIExtractLayer(t *testing.T) {
+	src := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(src, ".wh.b.txt"), nil, 0o644))
+	archive := filepath.Join(t.TempDir(), "layer.tar")
+	code, _, stderr := runCLI(t, nil, "create", "-o", archive, "-C", src, ".wh.b.txt")
+	assert.Equal(t, code, 0, stderr)
+
+	dest := createSource(t)
+	code, _, stderr = runCLI(t, nil, "extract", "-layer", "apply", archive, dest)
+	assert.Equal(t, code, 0, stderr)
+
+	_, err := os.Lstat(filepath.Join(dest, "b.txt"))
+	assert.Assert(t, os.IsNotExist(err), "whiteout wasn't applied: %v", err)
+	_, err = os.Lstat(filepath.Join(dest, ".wh.b.txt"))
+	assert.Assert(t, os.IsNotExist(err), "whiteout was extracted: %v", err)
+}
+
+func TestCLIExtractInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-mode-mask", "999"},
+		{"-file-mode", "rw"},
+		{"-uid-map", "0:1000"},
+		{"-gid-map", "0:1000:0"},
+		{"-id-fallback", "nobody"},
+		{"-layer", "squash"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			code, _, stderr := runCLI(t, nil, append([]string{"extract"}, append(args, "in.tar", "out")...)...)
+			assert.Equal(t, code, 2)
+			assert.Assert(t, strings.Contains(stderr, "invalid"), stderr)
+		})
+	}
+}
+
+func TestCLICreateReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	var archives []string
+	for range 2 {
+		src := createSource(t)
+		code, stdout, stderr := runCLI(t, nil, "create", "-reproducible", "-o", "-", "-ext", ".tar.gz", "-C", src, "dir", "b.txt")
+		assert.Equal(t, code, 0, stderr)
+		archives = append(archives, stdout)
+	}
+	assert.Assert(t, archives[0] == archives[1], "archives differ")
+}
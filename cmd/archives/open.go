@@ -0,0 +1,178 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// passwordEnv is the environment variable the password of encrypted
+// archives is read from, if not provided using -password-file.
+const passwordEnv = "ARCHIVES_PASSWORD"
+
+// openFlags contains the flags used to open archives.
+type openFlags struct {
+	ext          string
+	name         string
+	passwordFile string
+	volumes      bool
+
+	recurse    int
+	maxEntries int
+	maxSize    int64
+}
+
+// register registers the flags on fs. volumes registers the -volumes
+// flag, for commands opening archives using [openFlags.open].
+func (o *openFlags) register(fs *flag.FlagSet, volumes bool) {
+	fs.StringVar(&o.ext, "ext", "", "extension of the archive (e.g. .tar.gz), detected from its name or contents if not set")
+	fs.StringVar(&o.name, "name", "", "file name of the archive, used to name the contents of single compressed files")
+	fs.StringVar(&o.passwordFile, "password-file", "", "file containing the password of encrypted entries (default $"+passwordEnv+")")
+	if volumes {
+		fs.BoolVar(&o.volumes, "volumes", false, "treat the archive path as a glob pattern matching the volumes of a split archive")
+	}
+	fs.IntVar(&o.recurse, "recurse", 0, "maximum depth of nested archives to descend into")
+	fs.IntVar(&o.maxEntries, "max-entries", 0, "maximum number of entries to read, 0 for no limit")
+	fs.Int64Var(&o.maxSize, "max-size", 0, "maximum number of bytes to read from entries, 0 for no limit")
+}
+
+// options returns the [archives.OpenOptions] for the archive at path.
+func (o *openFlags) options(path string) (archives.OpenOptions, error) {
+	opts := archives.OpenOptions{Extension: o.ext, Name: o.name}
+	if opts.Name == "" && path != "-" {
+		opts.Name = path
+	}
+
+	password := os.Getenv(passwordEnv)
+	if o.passwordFile != "" {
+		b, err := os.ReadFile(o.passwordFile)
+		if err != nil {
+			return opts, fmt.Errorf("failed to read password file: %w", err)
+		}
+		password = strings.TrimRight(string(b), "\r\n")
+	}
+	opts.Password = password
+
+	return opts, nil
+}
+
+// input returns a reader of the archive at path, or stdin if path is
+// "-", and its extension. If the extension isn't set and can't be
+// determined from the name of the archive, it is detected from its
+// contents.
+func (o *openFlags) input(c *cli, path string) (io.Reader, string, func() error, error) {
+	r := c.stdin
+	closeFn := func() error { return nil }
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		r, closeFn = f, f.Close
+	}
+
+	ext := o.ext
+	if ext == "" && path != "-" && archives.Supported(archives.Ext(path)) {
+		ext = archives.Ext(path)
+	}
+	if ext == "" {
+		var err error
+		if ext, r, err = archives.Detect(r); err != nil {
+			closeFn() //nolint:errcheck // Why: Best effort.
+			return nil, "", nil, fmt.Errorf("%w, set the extension using -ext", err)
+		}
+	}
+
+	return r, ext, closeFn, nil
+}
+
+// open opens the archive at path, or stdin if path is "-". The returned
+// archive descends into nested archives if requested.
+func (o *openFlags) open(c *cli, path string) (archives.Archive, error) {
+	opts, err := o.options(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var a archives.Archive
+	if o.volumes {
+		a, err = archives.OpenVolumeFiles(path, opts)
+	} else {
+		a, err = o.openFile(c, path, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return archives.Recurse(a, archives.RecurseOptions{
+		MaxDepth:    o.recurse,
+		MaxEntries:  o.maxEntries,
+		MaxSize:     o.maxSize,
+		OpenOptions: opts,
+	}), nil
+}
+
+// openFile opens the archive at path, or stdin if path is "-". Files
+// are opened using [archives.OpenReaderAt], so that formats requiring
+// random access don't read them into memory.
+func (o *openFlags) openFile(c *cli, path string, opts archives.OpenOptions) (archives.Archive, error) {
+	r, ext, closeFn, err := o.input(c, path)
+	if err != nil {
+		return nil, err
+	}
+	opts.Extension = ext
+
+	var a archives.Archive
+	if f, ok := r.(*os.File); ok && path != "-" {
+		var info os.FileInfo
+		if info, err = f.Stat(); err == nil {
+			a, err = archives.OpenReaderAt(f, info.Size(), opts)
+		}
+	} else {
+		a, err = archives.Open(r, opts)
+	}
+	if err != nil {
+		closeFn() //nolint:errcheck // Why: Best effort.
+		return nil, err
+	}
+
+	return &fileArchive{a, closeFn}, nil
+}
+
+// fileArchive is an archive read from a file, which is closed once the
+// archive is closed.
+type fileArchive struct {
+	archives.Archive
+	closeFn func() error
+}
+
+// Close closes the archive and the file.
+func (f *fileArchive) Close() error {
+	err := f.Archive.Close()
+	if cerr := f.closeFn(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// verifyFlags contains the flags used to verify detached signatures.
+type verifyFlags struct {
+	key       string
+	sig       string
+	sigType   string
+	namespace string
+}
+
+// register registers the flags on fs.
+func (v *verifyFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&v.key, "key", "", "public key file to verify the signature of the archive with")
+	flags.StringVar(&v.sig, "sig", "", "detached signature file of the archive (default <archive>.minisig, .sig)")
+	flags.StringVar(&v.sigType, "sig-type", "minisign", "type of the signature: minisign, signify or ssh")
+	flags.StringVar(&v.namespace, "namespace", "file", "namespace of ssh signatures")
+}
+
+// verifier returns the [archives.Verifier] for the archive at path, or
+// nil if no key was provided.
+func (v *verifyFlags) verifier(path string) (archives.Verifier, error) {
+	if v.key == "" {
+		return nil, nil //nolint:nilnil // Why: Verification is optional.
+	}
+
+	sigPath := v.sig
+	if sigPath == "" {
+		if path == "-" {
+			return nil, fmt.Errorf("-sig is required when reading from stdin")
+		}
+
+		sigPath = path + ".sig"
+		if v.sigType == "minisign" {
+			sigPath = path + ".minisig"
+		}
+	}
+
+	key, err := os.ReadFile(v.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	switch v.sigType {
+	case "minisign":
+		return archives.NewMinisignVerifier(key, sig)
+	case "signify":
+		return archives.NewSignifyVerifier(key, sig)
+	case "ssh":
+		return archives.NewSSHSignatureVerifier(key, sig, v.namespace)
+	default:
+		return nil, fmt.Errorf("unsupported signature type %q", v.sigType)
+	}
+}
+
+// runVerify implements the verify command.
+func runVerify(c *cli, args []string) error {
+	var vf verifyFlags
+	flags := c.newFlagSet()
+	vf.register(flags)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+	if vf.key == "" {
+		return fmt.Errorf("-key is required")
+	}
+
+	path := flags.Arg(0)
+	v, err := vf.verifier(path)
+	if err != nil {
+		return err
+	}
+
+	r := c.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if _, err := io.Copy(v, r); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if err := v.Verify(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "%s: signature verified\n", path)
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// _ ensures that compressed implements the [Archiver] interface.
+var _ Archiver = (&compressed{})
+
+// _ ensures that compressed implements the [OptionsArchiver] interface.
+var _ OptionsArchiver = (&compressed{})
+
+// compressed implements the [Archiver] interface for single files
+// compressed without an archive format (e.g., foo.gz or binary.zst).
+// The file is exposed as an archive containing a single file.
+type compressed struct{}
+
+// Extensions returns the supported extensions for the compressed
+// extractor.
+func (c *compressed) Extensions() []string {
+	return containerExtensions
+}
+
+// Open creates a new [Archive] from the provided compressed stream. The
+// name of the file is taken from the gzip header if present, otherwise
+// it is derived from [OpenOptions.Name] by stripping the extension.
+func (c *compressed) Open(r io.Reader, ext string) (Archive, error) {
+	return c.OpenWithOptions(r, ext, &OpenOptions{})
+}
+
+// OpenWithOptions implements [OptionsArchiver].
+func (c *compressed) OpenWithOptions(r io.Reader, ext string, opts *OpenOptions) (Archive, error) {
+	container, err := newContainerReader(r, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	var modTime time.Time
+	if gr, ok := container.(*gzip.Reader); ok {
+		if gr.Name != "" {
+			name = path.Base(filepath.ToSlash(gr.Name))
+		}
+		modTime = gr.ModTime
+	}
+
+	if name == "" && opts.Name != "" {
+		name = strings.TrimSuffix(filepath.Base(opts.Name), "."+ext)
+	}
+
+	if name == "" {
+		_ = container.Close() //nolint:errcheck // Why: Best effort.
+		return nil, fmt.Errorf("unable to determine file name for compressed stream (set opts.Name)")
+	}
+
+	return &compressedArchive{
+		container: container,
+		header: &Header{
+			Name:    name,
+			Type:    HeaderFile,
+			Size:    -1,
+			Mode:    0o644,
+			ModTime: modTime,
+		},
+	}, nil
+}
+
+// compressedArchive implements [Archive] for a single compressed file.
+type compressedArchive struct {
+	container io.ReadCloser
+	header    *Header
+
+	// read is true once Next has returned the header.
+	read bool
+}
+
+// Close implements [Archive].
+func (c *compressedArchive) Close() error {
+	return c.container.Close()
+}
+
+// Next implements [Archive]. The only file in the archive is returned
+// on the first call, subsequent calls return [io.EOF].
+func (c *compressedArchive) Next() (*Header, error) {
+	if c.read {
+		return nil, io.EOF
+	}
+
+	c.read = true
+	return c.header, nil
+}
+
+// Read implements [io.Reader].
+func (c *compressedArchive) Read(p []byte) (int, error) {
+	if !c.read {
+		return 0, io.EOF
+	}
+
+	n, err := c.container.Read(p)
+	return n, classifyError(err)
+}
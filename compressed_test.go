@@ -0,0 +1,63 @@
+package archives_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"go.rgst.io/jaredallard/archives/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompressedUsesGzipName(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	gw.Name = "tool"
+	_, err := gw.Write([]byte("hello world"))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	a, err := archives.Open(buf, archives.OpenOptions{
+		Extension: archives.Ext("download.gz"),
+	})
+	assert.NilError(t, err)
+
+	r, err := archives.Pick(a, archives.PickFilterByName("tool"))
+	assert.NilError(t, err)
+
+	b, err := io.ReadAll(r)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "hello world")
+}
+
+func TestCompressedExtractUsesName(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw, err := zstd.NewWriter(buf)
+	assert.NilError(t, err)
+	_, err = zw.Write([]byte("hello world"))
+	assert.NilError(t, err)
+	assert.NilError(t, zw.Close())
+
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(buf, dest, archives.ExtractOptions{
+		Extension: archives.Ext("binary.zst"),
+		Name:      "/tmp/downloads/binary.zst",
+	}))
+
+	got, err := os.ReadFile(filepath.Join(dest, "binary"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello world")
+}
+
+func TestCompressedRequiresName(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	assert.NilError(t, gw.Close())
+
+	_, err := archives.Open(buf, archives.OpenOptions{Extension: ".gz"})
+	assert.ErrorContains(t, err, "unable to determine file name")
+}
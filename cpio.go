@@ -0,0 +1,564 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Contains the magic numbers of the supported cpio formats.
+const (
+	// cpioMagicNewc is the "new" portable format (SVR4) without
+	// checksums.
+	cpioMagicNewc = "070701"
+
+	// cpioMagicCRC is the "new" portable format (SVR4) with checksums.
+	cpioMagicCRC = "070702"
+
+	// cpioMagicOdc is the "old" portable format (POSIX.1).
+	cpioMagicOdc = "070707"
+
+	// cpioMagicStripped is the stripped format used by RPM for large
+	// files, the metadata is stored in the RPM header instead.
+	cpioMagicStripped = "07070X"
+
+	// cpioTrailer is the name of the last entry in a cpio archive.
+	cpioTrailer = "TRAILER!!!"
+
+	// cpioMaxNameSize is the maximum supported size of a file name,
+	// guarding against corrupt headers causing large allocations.
+	cpioMaxNameSize = 1 << 16
+)
+
+// _ ensures that cpio implements the [Archiver] interface.
+var _ Archiver = (&cpio{})
+
+// cpio implements the [Archiver] interface for cpio archives in the
+// newc, crc and odc formats, and their compressed variants.
+type cpio struct{}
+
+// Extensions returns the supported extensions for the cpio extractor.
+func (c *cpio) Extensions() []string {
+	exts := []string{"cpio"}
+	for _, ext := range containerExtensions {
+		exts = append(exts, "cpio."+ext)
+	}
+
+	return exts
+}
+
+// Open creates a new [Archive] from the provided reader using the cpio
+// format.
+func (c *cpio) Open(r io.Reader, ext string) (Archive, error) {
+	var codec string
+	if ext != "cpio" {
+		codec = strings.TrimPrefix(ext, "cpio.")
+	}
+
+	container, err := newContainerReader(r, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCPIOArchive(container, nil), nil
+}
+
+// cpioEntry is a raw cpio entry header.
+type cpioEntry struct {
+	magic string
+
+	ino      uint32
+	mode     uint32
+	uid      uint32
+	gid      uint32
+	nlink    uint32
+	mtime    int64
+	size     int64
+	devMajor uint32
+	devMinor uint32
+	check    uint32
+	name     string
+
+	// uname and gname are not part of cpio headers, but may be provided
+	// by formats embedding cpio archives.
+	uname string
+	gname string
+}
+
+// header converts the entry into a [Header].
+func (e *cpioEntry) header() *Header {
+	h := &Header{
+		Name:    e.name,
+		Type:    HeaderFile,
+		Size:    e.size,
+		Mode:    unixModeToFileMode(e.mode),
+		ModTime: time.Unix(e.mtime, 0),
+		UID:     int(e.uid),
+		GID:     int(e.gid),
+		Uname:   e.uname,
+		Gname:   e.gname,
+	}
+
+	switch {
+	case h.Mode.IsDir():
+		h.Type = HeaderDir
+		h.Size = 0
+		if len(h.Name) > 0 && h.Name[len(h.Name)-1] != '/' {
+			h.Name += "/"
+		}
+	case h.Mode&os.ModeSymlink != 0:
+		// The target is stored as the contents of the entry.
+		h.Type = HeaderSymlink
+	case h.Mode&specialModes != 0:
+		h.Type = HeaderSpecial
+	}
+
+	return h
+}
+
+// cpioInode identifies the inode of an entry, which is shared by
+// hardlinked entries.
+type cpioInode struct {
+	devMajor, devMinor, ino uint32
+}
+
+// cpioArchive implements [Archive] for cpio archives, resolving
+// hardlinks into [HeaderHardlink] entries.
+type cpioArchive struct {
+	r      *cpioReader
+	closer io.Closer
+
+	// resolve, if set, is called for every entry before it is converted
+	// into a [Header]. It is used by formats embedding cpio archives to
+	// fill in metadata stored outside of the archive.
+	resolve func(e *cpioEntry) error
+
+	// hasData is true if the current header has contents to read.
+	hasData bool
+
+	// links maps inodes to the name of the entry that contains their
+	// contents.
+	links map[cpioInode]string
+
+	// held contains hardlinks whose contents have not been seen yet, in
+	// the order they were first seen. In the newc format, only the last
+	// link of an inode contains its contents.
+	held      map[cpioInode][]*Header
+	heldOrder []cpioInode
+
+	// queue contains headers to return before reading further entries.
+	queue []*Header
+
+	// done is true once the trailer has been read.
+	done bool
+}
+
+// newCPIOArchive returns a new cpioArchive reading from r.
+func newCPIOArchive(r io.ReadCloser, resolve func(e *cpioEntry) error) *cpioArchive {
+	return &cpioArchive{
+		r:       newCPIOReader(r),
+		closer:  r,
+		resolve: resolve,
+		links:   make(map[cpioInode]string),
+		held:    make(map[cpioInode][]*Header),
+	}
+}
+
+// Close implements [Archive].
+func (a *cpioArchive) Close() error {
+	return a.closer.Close()
+}
+
+// Read implements [io.Reader].
+func (a *cpioArchive) Read(p []byte) (int, error) {
+	if !a.hasData {
+		return 0, io.EOF
+	}
+
+	n, err := a.r.Read(p)
+	return n, classifyError(err)
+}
+
+// Next implements [Archive].
+func (a *cpioArchive) Next() (*Header, error) {
+	h, err := a.next()
+	return h, classifyError(err)
+}
+
+// next returns the header of the next entry.
+func (a *cpioArchive) next() (*Header, error) {
+	for {
+		if len(a.queue) > 0 {
+			h := a.queue[0]
+			a.queue = a.queue[1:]
+			a.hasData = false
+			return h, nil
+		}
+
+		if a.done {
+			return nil, io.EOF
+		}
+
+		e, err := a.r.next()
+		if errors.Is(err, io.EOF) {
+			a.done = true
+			a.flushHeld()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if a.resolve != nil {
+			if err := a.resolve(e); err != nil {
+				return nil, err
+			}
+		}
+
+		h := e.header()
+		a.hasData = true
+
+		if h.Type == HeaderSymlink {
+			target, err := io.ReadAll(a.r)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read symlink target: %w", err)
+			}
+			h.Linkname = string(target)
+			h.Size = 0
+			a.hasData = false
+			return h, nil
+		}
+
+		// Only regular files can be hardlinks, directories always have
+		// more than one link.
+		if h.Type != HeaderFile || e.nlink < 2 || e.mode&0o170000 != 0o100000 {
+			return h, nil
+		}
+
+		inode := cpioInode{e.devMajor, e.devMinor, e.ino}
+		if target, ok := a.links[inode]; ok {
+			// The contents have already been returned, any contents of
+			// this entry (as written by the odc format) are skipped.
+			h.Type = HeaderHardlink
+			h.Linkname = target
+			h.Size = 0
+			a.hasData = false
+			return h, nil
+		}
+
+		if e.size == 0 {
+			// Wait for the entry containing the contents.
+			if _, ok := a.held[inode]; !ok {
+				a.heldOrder = append(a.heldOrder, inode)
+			}
+			a.held[inode] = append(a.held[inode], h)
+			continue
+		}
+
+		a.links[inode] = h.Name
+		a.queueHeld(inode, h.Name)
+		return h, nil
+	}
+}
+
+// queueHeld queues the held hardlinks of inode as links to target.
+func (a *cpioArchive) queueHeld(inode cpioInode, target string) {
+	for _, h := range a.held[inode] {
+		h.Type = HeaderHardlink
+		h.Linkname = target
+		a.queue = append(a.queue, h)
+	}
+	delete(a.held, inode)
+}
+
+// flushHeld queues all remaining held hardlinks. These belong to empty
+// files, so the first link of each inode is returned as a file.
+func (a *cpioArchive) flushHeld() {
+	for _, inode := range a.heldOrder {
+		hdrs, ok := a.held[inode]
+		if !ok {
+			continue
+		}
+
+		a.queue = append(a.queue, hdrs[0])
+		a.held[inode] = hdrs[1:]
+		a.queueHeld(inode, hdrs[0].Name)
+	}
+	a.heldOrder = nil
+}
+
+// cpioReader reads entries from a cpio archive.
+type cpioReader struct {
+	r io.Reader
+
+	// off is the number of bytes read from r, used for alignment.
+	off int64
+
+	// align is the alignment of headers and contents of the current
+	// format.
+	align int64
+
+	// cur is the contents of the current entry.
+	cur io.LimitedReader
+
+	// checksum is true if the contents of the current entry should be
+	// verified against want, sum contains the checksum so far.
+	checksum bool
+	sum      uint32
+	want     uint32
+}
+
+// newCPIOReader returns a new cpioReader reading from r.
+func newCPIOReader(r io.Reader) *cpioReader {
+	c := &cpioReader{align: 1}
+	c.r = &countingReader{r: r, n: &c.off}
+	c.cur.R = c.r
+	return c
+}
+
+// countingReader counts the number of bytes read into n.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+// Read implements [io.Reader].
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// Read implements [io.Reader] for the contents of the current entry.
+func (c *cpioReader) Read(p []byte) (int, error) {
+	n, err := c.cur.Read(p)
+	if err == io.EOF && c.cur.N > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if c.checksum {
+		for _, b := range p[:n] {
+			c.sum += uint32(b)
+		}
+
+		if c.cur.N == 0 && c.sum != c.want {
+			c.checksum = false
+			return n, fmt.Errorf("%w: cpio checksum mismatch: expected %08x, got %08x", ErrCorrupt, c.want, c.sum)
+		}
+	}
+
+	return n, err
+}
+
+// setSize sets the size of the current entry. This is required for
+// entries in the stripped format, which don't contain a size.
+func (c *cpioReader) setSize(size int64) {
+	c.cur.N = size
+}
+
+// pad discards bytes until the offset is aligned.
+func (c *cpioReader) pad() error {
+	if pad := (c.align - c.off%c.align) % c.align; pad > 0 {
+		if _, err := io.CopyN(io.Discard, c.r, pad); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// next returns the next entry in the archive, skipping the rest of the
+// current entry. It returns [io.EOF] once the trailer is reached.
+func (c *cpioReader) next() (*cpioEntry, error) {
+	if _, err := io.Copy(io.Discard, c); err != nil {
+		return nil, fmt.Errorf("failed to skip cpio entry: %w", err)
+	}
+
+	if err := c.pad(); err != nil {
+		return nil, fmt.Errorf("failed to skip cpio padding: %w", err)
+	}
+
+	var magic [6]byte
+	if _, err := io.ReadFull(c.r, magic[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		return nil, fmt.Errorf("failed to read cpio header: %w", err)
+	}
+
+	var e *cpioEntry
+	var err error
+	switch string(magic[:]) {
+	case cpioMagicNewc, cpioMagicCRC:
+		c.align = 4
+		e, err = c.readNewc()
+	case cpioMagicOdc:
+		c.align = 1
+		e, err = c.readOdc()
+	case cpioMagicStripped:
+		c.align = 4
+		e, err = c.readStripped()
+	default:
+		return nil, fmt.Errorf("%w: cpio header magic %q", ErrUnsupportedFormat, magic[:])
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.magic = string(magic[:])
+
+	if e.name == cpioTrailer {
+		return nil, io.EOF
+	}
+
+	c.checksum = e.magic == cpioMagicCRC && e.size > 0
+	c.sum = 0
+	c.want = e.check
+
+	return e, nil
+}
+
+// readFields reads numbers of the provided widths in the provided base.
+func (c *cpioReader) readFields(widths []int, base int) ([]uint64, error) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+
+	hdr := make([]byte, total)
+	if _, err := io.ReadFull(c.r, hdr); err != nil {
+		return nil, fmt.Errorf("failed to read cpio header: %w", err)
+	}
+
+	fields := make([]uint64, len(widths))
+	for i, w := range widths {
+		v, err := strconv.ParseUint(string(hdr[:w]), base, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cpio header: %w", ErrCorrupt, err)
+		}
+		fields[i] = v
+		hdr = hdr[w:]
+	}
+
+	return fields, nil
+}
+
+// readName reads a NUL terminated file name of size bytes followed by
+// padding.
+func (c *cpioReader) readName(size uint64) (string, error) {
+	if size > cpioMaxNameSize {
+		return "", fmt.Errorf("%w: cpio file name too long (%d bytes)", ErrCorrupt, size)
+	}
+
+	name := make([]byte, size)
+	if _, err := io.ReadFull(c.r, name); err != nil {
+		return "", fmt.Errorf("failed to read cpio file name: %w", err)
+	}
+
+	if err := c.pad(); err != nil {
+		return "", fmt.Errorf("failed to read cpio header padding: %w", err)
+	}
+
+	return string(bytes.TrimRight(name, "\x00")), nil
+}
+
+// readNewc reads the rest of a newc or crc header after the magic.
+func (c *cpioReader) readNewc() (*cpioEntry, error) {
+	widths := make([]int, 13)
+	for i := range widths {
+		widths[i] = 8
+	}
+
+	f, err := c.readFields(widths, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // Why: Fields are at most 32 bits.
+	e := &cpioEntry{
+		ino:      uint32(f[0]),
+		mode:     uint32(f[1]),
+		uid:      uint32(f[2]),
+		gid:      uint32(f[3]),
+		nlink:    uint32(f[4]),
+		mtime:    int64(f[5]),
+		size:     int64(f[6]),
+		devMajor: uint32(f[7]),
+		devMinor: uint32(f[8]),
+		check:    uint32(f[12]),
+	}
+
+	if e.name, err = c.readName(f[11]); err != nil {
+		return nil, err
+	}
+	c.cur.N = e.size
+
+	return e, nil
+}
+
+// readOdc reads the rest of an odc header after the magic.
+func (c *cpioReader) readOdc() (*cpioEntry, error) {
+	// dev, ino, mode, uid, gid, nlink, rdev, mtime, namesize, filesize
+	f, err := c.readFields([]int{6, 6, 6, 6, 6, 6, 6, 11, 6, 11}, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // Why: Fields are at most 33 bits.
+	e := &cpioEntry{
+		devMajor: uint32(f[0]),
+		ino:      uint32(f[1]),
+		mode:     uint32(f[2]),
+		uid:      uint32(f[3]),
+		gid:      uint32(f[4]),
+		nlink:    uint32(f[5]),
+		mtime:    int64(f[7]),
+		size:     int64(f[9]),
+	}
+
+	if e.name, err = c.readName(f[8]); err != nil {
+		return nil, err
+	}
+	c.cur.N = e.size
+
+	return e, nil
+}
+
+// readStripped reads the rest of a stripped header after the magic. The
+// only field is the index of the file in the RPM header, which is
+// stored in ino.
+func (c *cpioReader) readStripped() (*cpioEntry, error) {
+	f, err := c.readFields([]int{8}, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.pad(); err != nil {
+		return nil, fmt.Errorf("failed to read cpio header padding: %w", err)
+	}
+
+	return &cpioEntry{ino: uint32(f[0]), size: -1}, nil //nolint:gosec // Why: Field is 32 bits.
+}
@@ -0,0 +1,175 @@
+package archives_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/cpiotest"
+	"gotest.tools/v3/assert"
+)
+
+// cpioEntry is a simplified header used to compare cpio archives.
+type cpioEntry struct {
+	Name     string
+	Type     archives.HeaderType
+	Linkname string
+	Contents string
+}
+
+// readCPIO returns all entries of the provided cpio archive.
+func readCPIO(t *testing.T, r io.Reader, ext string) []cpioEntry {
+	t.Helper()
+
+	a, err := archives.Open(r, archives.OpenOptions{Extension: ext})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	var entries []cpioEntry
+	for {
+		h, err := a.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+
+		contents, err := io.ReadAll(a)
+		assert.NilError(t, err)
+		entries = append(entries, cpioEntry{h.Name, h.Type, h.Linkname, string(contents)})
+	}
+
+	return entries
+}
+
+func TestCPIO(t *testing.T) {
+	formats := []struct {
+		name   string
+		format cpiotest.Format
+	}{
+		{"newc", cpiotest.FormatNewc},
+		{"crc", cpiotest.FormatCRC},
+		{"odc", cpiotest.FormatOdc},
+	}
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			b, err := cpiotest.Create([]cpiotest.File{
+				{Name: "bin", Mode: 0o40755, Nlink: 2},
+				{Name: "bin/sh", Contents: []byte("shell"), Mode: 0o100755},
+				{Name: "init", Contents: []byte("bin/sh"), Mode: 0o120777},
+				{Name: "odd", Contents: []byte("x"), Mode: 0o100644},
+				{Name: "fifo", Mode: 0o10644},
+			}, cpiotest.WithFormat(format.format))
+			assert.NilError(t, err)
+
+			assert.DeepEqual(t, readCPIO(t, bytes.NewReader(b), ".cpio"), []cpioEntry{
+				{"bin/", archives.HeaderDir, "", ""},
+				{"bin/sh", archives.HeaderFile, "", "shell"},
+				{"init", archives.HeaderSymlink, "bin/sh", ""},
+				{"odd", archives.HeaderFile, "", "x"},
+				{"fifo", archives.HeaderSpecial, "", ""},
+			})
+		})
+	}
+}
+
+func TestCPIOCompressed(t *testing.T) {
+	b, err := cpiotest.Create([]cpiotest.File{
+		{Name: "init", Contents: []byte("hello world"), Mode: 0o100755},
+	})
+	assert.NilError(t, err)
+
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	_, err = gw.Write(b)
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	assert.DeepEqual(t, readCPIO(t, buf, archives.Ext("initramfs.cpio.gz")), []cpioEntry{
+		{"init", archives.HeaderFile, "", "hello world"},
+	})
+}
+
+func TestCPIOHardlinks(t *testing.T) {
+	t.Run("newc", func(t *testing.T) {
+		// Only the last link of an inode contains the contents.
+		b, err := cpiotest.Create([]cpiotest.File{
+			{Name: "a", Mode: 0o100644, Ino: 10, Nlink: 3},
+			{Name: "other", Contents: []byte("other"), Mode: 0o100644, Ino: 11},
+			{Name: "b", Mode: 0o100644, Ino: 10, Nlink: 3},
+			{Name: "c", Contents: []byte("shared"), Mode: 0o100644, Ino: 10, Nlink: 3},
+			{Name: "empty1", Mode: 0o100644, Ino: 12, Nlink: 2},
+			{Name: "empty2", Mode: 0o100644, Ino: 12, Nlink: 2},
+		})
+		assert.NilError(t, err)
+
+		assert.DeepEqual(t, readCPIO(t, bytes.NewReader(b), ".cpio"), []cpioEntry{
+			{"other", archives.HeaderFile, "", "other"},
+			{"c", archives.HeaderFile, "", "shared"},
+			{"a", archives.HeaderHardlink, "c", ""},
+			{"b", archives.HeaderHardlink, "c", ""},
+			{"empty1", archives.HeaderFile, "", ""},
+			{"empty2", archives.HeaderHardlink, "empty1", ""},
+		})
+	})
+
+	t.Run("odc", func(t *testing.T) {
+		// Every link contains the contents.
+		b, err := cpiotest.Create([]cpiotest.File{
+			{Name: "a", Contents: []byte("shared"), Mode: 0o100644, Ino: 10, Nlink: 2},
+			{Name: "b", Contents: []byte("shared"), Mode: 0o100644, Ino: 10, Nlink: 2},
+		}, cpiotest.WithFormat(cpiotest.FormatOdc))
+		assert.NilError(t, err)
+
+		assert.DeepEqual(t, readCPIO(t, bytes.NewReader(b), ".cpio"), []cpioEntry{
+			{"a", archives.HeaderFile, "", "shared"},
+			{"b", archives.HeaderHardlink, "a", ""},
+		})
+	})
+}
+
+func TestCPIOExtractHardlinks(t *testing.T) {
+	b, err := cpiotest.Create([]cpiotest.File{
+		{Name: "bin/a", Mode: 0o100755, Ino: 10, Nlink: 2},
+		{Name: "bin/b", Contents: []byte("shared"), Mode: 0o100755, Ino: 10, Nlink: 2},
+	})
+	assert.NilError(t, err)
+
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(b), dest, archives.ExtractOptions{
+		Extension: ".cpio",
+	}))
+
+	a, err := os.Stat(filepath.Join(dest, "bin", "a"))
+	assert.NilError(t, err)
+	bi, err := os.Stat(filepath.Join(dest, "bin", "b"))
+	assert.NilError(t, err)
+	assert.Assert(t, os.SameFile(a, bi))
+
+	got, err := os.ReadFile(filepath.Join(dest, "bin", "a"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "shared")
+}
+
+func TestCPIOChecksumMismatch(t *testing.T) {
+	b, err := cpiotest.Create([]cpiotest.File{
+		{Name: "file", Contents: []byte("hello world"), Mode: 0o100644},
+	}, cpiotest.WithFormat(cpiotest.FormatCRC))
+	assert.NilError(t, err)
+
+	i := bytes.Index(b, []byte("hello world"))
+	b[i] ^= 0xff
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".cpio"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = a.Next()
+	assert.NilError(t, err)
+
+	_, err = io.ReadAll(a)
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
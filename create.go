@@ -0,0 +1,175 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CreateOptions contains options for creating an archive.
+type CreateOptions struct {
+	// Extension is the extension of the archive to create, see
+	// [OpenOptions.Extension]. This is required.
+	Extension string
+
+	// Reproducible, if set, creates the archive so that the same entries
+	// result in byte-for-byte identical archives, for reproducible
+	// builds. The headers of entries are normalized: their modification
+	// time is set to ModTime, access times, owner IDs and names are
+	// cleared, and their mode is set to 0755 for directories and
+	// executable files, 0777 for symbolic links and 0644 otherwise.
+	// Compressors use fixed levels and settings, and gzip headers don't
+	// contain a modification time or operating system.
+	//
+	// Entries aren't reordered: they are written in the order they are
+	// added, which must be stable for archives to be reproducible.
+	// [AddPath] adds the entries of directories sorted by name.
+	Reproducible bool
+
+	// ModTime is the modification time of all entries if Reproducible is
+	// set. If zero, it is read from the SOURCE_DATE_EPOCH environment
+	// variable, defaulting to 1980-01-01 00:00:00 UTC, the earliest time
+	// supported by all formats.
+	ModTime time.Time
+}
+
+// Create returns an [ArchiveWriter] writing an archive to w. The format
+// is determined by the extension of the archive. Currently tar
+// (including compressed variants, except bzip2) and zip archives can be
+// created.
+//
+// The returned writer must be closed to finish the archive.
+func Create(w io.Writer, opts CreateOptions) (ArchiveWriter, error) {
+	if w == nil {
+		return nil, fmt.Errorf("writer must not be nil")
+	} else if opts.Extension == "" {
+		return nil, fmt.Errorf("extension must be provided (set opts.Extension)")
+	}
+
+	ext := strings.TrimPrefix(opts.Extension, ".")
+
+	archiver, ok := extensions[ext].(WriterArchiver)
+	if !ok {
+		return nil, fmt.Errorf("%w: extension %s for writing", ErrUnsupportedFormat, ext)
+	}
+
+	if !opts.Reproducible {
+		return archiver.Create(w, ext, &opts)
+	}
+
+	modTime, err := reproducibleModTime(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	aw, err := archiver.Create(w, ext, &opts)
+	if err != nil {
+		return nil, err
+	}
+	return &reproducibleWriter{aw, modTime}, nil
+}
+
+// AddPath adds the file, directory or symbolic link at p to aw. Symbolic
+// links are added as is, and directories are added recursively. The
+// entry of p is named name, and entries below it are named name
+// followed by their path relative to p, using forward slashes. Leading
+// slashes are removed from name, and if name is ".", no entry is added
+// for p itself (e.g., to add the contents of a directory).
+func AddPath(aw ArchiveWriter, p, name string) error {
+	name = strings.TrimLeft(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "" {
+		name = "."
+	}
+
+	return filepath.WalkDir(p, func(fp string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(p, fp)
+		if err != nil {
+			return err
+		}
+
+		entryName := name
+		if rel != "." {
+			entryName = path.Join(name, filepath.ToSlash(rel))
+		} else if name == "." {
+			return nil
+		}
+
+		return addFile(aw, fp, entryName)
+	})
+}
+
+// addFile adds the file at fp to aw, named name.
+func addFile(aw ArchiveWriter, fp, name string) error {
+	info, err := os.Lstat(fp)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", fp, err)
+	}
+
+	h := &Header{
+		Name:    name,
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+	}
+	fileOwner(info, h)
+
+	switch {
+	case info.Mode().IsRegular():
+		h.Type = HeaderFile
+		h.Size = info.Size()
+	case info.IsDir():
+		h.Type = HeaderDir
+	case info.Mode()&os.ModeSymlink != 0:
+		h.Type = HeaderSymlink
+		if h.Linkname, err = os.Readlink(fp); err != nil {
+			return fmt.Errorf("failed to read symlink %s: %w", fp, err)
+		}
+	default:
+		return fmt.Errorf("%w: %v at %s", ErrUnsupportedEntryType, info.Mode().Type(), fp)
+	}
+
+	if err := aw.WriteHeader(h); err != nil {
+		return fmt.Errorf("failed to write header of %s: %w", name, err)
+	}
+	if h.Type != HeaderFile {
+		return nil
+	}
+
+	f, err := os.Open(fp)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", fp, err)
+	}
+	defer f.Close() //nolint:errcheck // Why: Only read from.
+
+	if _, err := io.CopyN(aw, f, h.Size); err != nil {
+		return fmt.Errorf("failed to write contents of %s: %w", name, err)
+	}
+
+	return nil
+}
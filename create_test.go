@@ -0,0 +1,108 @@
+package archives_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.rgst.io/jaredallard/archives/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestCreate(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, ext := range []string{".tar", ".tar.gz", ".tar.zst", ".tar.xz", ".zip"} {
+		t.Run(ext, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			aw, err := archives.Create(buf, archives.CreateOptions{Extension: ext})
+			assert.NilError(t, err)
+
+			assert.NilError(t, aw.WriteHeader(&archives.Header{
+				Name: "dir", Type: archives.HeaderDir, Mode: os.ModeDir | 0o755, ModTime: modTime,
+			}))
+			assert.NilError(t, aw.WriteHeader(&archives.Header{
+				Name: "dir/a.txt", Type: archives.HeaderFile, Size: 5, Mode: 0o644, ModTime: modTime,
+			}))
+			_, err = aw.Write([]byte("hello"))
+			assert.NilError(t, err)
+			assert.NilError(t, aw.WriteHeader(&archives.Header{
+				Name: "link", Type: archives.HeaderSymlink, Linkname: "dir/a.txt", Mode: os.ModeSymlink | 0o777, ModTime: modTime,
+			}))
+			assert.NilError(t, aw.Close())
+
+			a, err := archives.Open(bytes.NewReader(buf.Bytes()), archives.OpenOptions{Extension: ext})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			type entry struct {
+				name     string
+				typ      archives.HeaderType
+				perm     os.FileMode
+				linkname string
+				contents string
+			}
+			var got []entry
+			err = archives.Walk(a, func(h *archives.Header) error {
+				b, err := io.ReadAll(a)
+				if err != nil {
+					return err
+				}
+				assert.Assert(t, h.ModTime.Equal(modTime), "%s: %v", h.Name, h.ModTime)
+
+				got = append(got, entry{h.Name, h.Type, h.Mode.Perm(), h.Linkname, string(b)})
+				return nil
+			})
+			assert.NilError(t, err)
+
+			link := entry{"link", archives.HeaderSymlink, 0o777, "dir/a.txt", ""}
+			if ext == ".zip" {
+				// Symbolic links are stored as files containing their target,
+				// which are read as regular files.
+				link = entry{"link", archives.HeaderFile, 0o777, "", "dir/a.txt"}
+			}
+			assert.DeepEqual(t, got, []entry{
+				{"dir/", archives.HeaderDir, 0o755, "", ""},
+				{"dir/a.txt", archives.HeaderFile, 0o644, "", "hello"},
+				link,
+			}, cmp.AllowUnexported(entry{}))
+		})
+	}
+}
+
+func TestCreateUnsupported(t *testing.T) {
+	_, err := archives.Create(new(bytes.Buffer), archives.CreateOptions{Extension: ".7z"})
+	assert.ErrorIs(t, err, archives.ErrUnsupportedFormat)
+}
+
+func TestAddPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require privileges on Windows")
+	}
+
+	src := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(src, "sub"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("hello"), 0o600))
+	assert.NilError(t, os.Symlink("sub/a.txt", filepath.Join(src, "link")))
+
+	buf := new(bytes.Buffer)
+	aw, err := archives.Create(buf, archives.CreateOptions{Extension: ".tar.gz"})
+	assert.NilError(t, err)
+	assert.NilError(t, archives.AddPath(aw, src, "/root/"))
+	assert.NilError(t, aw.Close())
+
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(buf.Bytes()), dest, archives.ExtractOptions{Extension: ".tar.gz"}))
+
+	b, err := os.ReadFile(filepath.Join(dest, "root", "link"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "hello")
+
+	info, err := os.Stat(filepath.Join(dest, "root", "sub", "a.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+//go:build unix
+
+package archives
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner sets the owner of the file described by info on h.
+func fileOwner(info os.FileInfo, h *Header) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		h.UID = int(st.Uid)
+		h.GID = int(st.Gid)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// debDataPrefix is the name prefix of the member of a Debian package
+// that contains the files installed by the package.
+const debDataPrefix = "data."
+
+// _ ensures that deb implements the [Archiver] interface.
+var _ Archiver = (&deb{})
+
+// deb implements the [Archiver] interface for Debian packages. A Debian
+// package is an ar archive containing a control.tar.* and a data.tar.*
+// member, the latter of which is exposed as the archive.
+type deb struct{}
+
+// Extensions returns the supported extensions for the deb extractor.
+func (d *deb) Extensions() []string {
+	return []string{"deb", "udeb"}
+}
+
+// Open creates a new [Archive] from the provided Debian package. The
+// returned archive contains the files of the data.tar.* member, which
+// is read with the tar extractor based on its extension.
+func (d *deb) Open(r io.Reader, _ string) (Archive, error) {
+	a, err := newArArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		h, err := a.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: no data.tar member found in deb package", ErrCorrupt)
+			}
+
+			return nil, err
+		}
+
+		if !strings.HasPrefix(h.Name, debDataPrefix+"tar") {
+			continue
+		}
+
+		return (&tar{}).Open(a, strings.TrimPrefix(h.Name, debDataPrefix))
+	}
+}
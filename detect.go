@@ -0,0 +1,118 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// detectPeekSize is the number of bytes inspected by [Detect], enough
+// to contain the primary volume descriptor of ISO 9660 images.
+const detectPeekSize = 0x8006
+
+// magic is the signature of a format at a fixed offset.
+type magic struct {
+	ext    string
+	offset int
+	sig    []byte
+}
+
+// containerMagics contains the signatures of compression containers.
+// brotli and lzma streams have no signature and can't be detected.
+var containerMagics = []magic{
+	{"gz", 0, []byte{0x1f, 0x8b}},
+	{"xz", 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{"bz2", 0, []byte("BZh")},
+	{"zst", 0, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"lz4", 0, []byte{0x04, 0x22, 0x4d, 0x18}},
+	{"lz", 0, []byte("LZIP")},
+	{"Z", 0, []byte{0x1f, 0x9d}},
+}
+
+// archiveMagics contains the signatures of archive formats.
+var archiveMagics = []magic{
+	{"zip", 0, []byte("PK\x03\x04")},
+	{"zip", 0, []byte("PK\x05\x06")}, // empty archive
+	{"zip", 0, []byte("PK\x07\x08")}, // spanned archive
+	{"7z", 0, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}},
+	{"deb", 0, []byte("!<arch>\ndebian-binary")},
+	{"ar", 0, []byte("!<arch>\n")},
+	{"rpm", 0, []byte{0xed, 0xab, 0xee, 0xdb}},
+	{"cpio", 0, []byte("070701")},
+	{"cpio", 0, []byte("070702")},
+	{"cpio", 0, []byte("070707")},
+	{"tar", 257, []byte("ustar")},
+	{"iso", 0x8001, []byte("CD001")},
+}
+
+// Detect detects the format of the archive read from r using the
+// signatures of the supported formats, for when the name of the archive
+// isn't known (e.g., reading from stdin). It returns the extension of
+// the format, including the leading period, and a reader returning the
+// entire archive, including the bytes read to detect it. The extension
+// can be passed to [Open] as [OpenOptions.Extension].
+//
+// Compressed tar archives are detected by decompressing the start of
+// the archive. Formats without a signature (brotli and lzma
+// containers, and tar archives predating POSIX) can't be detected.
+func Detect(r io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, detectPeekSize)
+	b, err := br.Peek(detectPeekSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	if ext, ok := matchMagic(b, archiveMagics); ok {
+		return "." + ext, br, nil
+	}
+
+	codec, ok := matchMagic(b, containerMagics)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: no known signature found", ErrUnsupportedFormat)
+	}
+
+	// Decompress the start of the container to check for tar and cpio
+	// archives, which are commonly compressed.
+	ext := codec
+	if cr, err := newContainerReader(bytes.NewReader(b), codec); err == nil {
+		inner := make([]byte, 512)
+		n, _ := io.ReadFull(cr, inner) //nolint:errcheck // Why: Truncated input is expected.
+		if inner, ok := matchMagic(inner[:n], archiveMagics); ok && (inner == "tar" || inner == "cpio") {
+			ext = inner + "." + codec
+		}
+		cr.Close() //nolint:errcheck,gosec // Why: Best effort.
+	}
+
+	return "." + ext, br, nil
+}
+
+// matchMagic returns the extension of the first signature matching b.
+func matchMagic(b []byte, magics []magic) (string, bool) {
+	for _, m := range magics {
+		if len(b) >= m.offset+len(m.sig) && bytes.Equal(b[m.offset:m.offset+len(m.sig)], m.sig) {
+			return m.ext, true
+		}
+	}
+
+	return "", false
+}
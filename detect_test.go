@@ -0,0 +1,65 @@
+package archives_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"gotest.tools/v3/assert"
+)
+
+func TestDetect(t *testing.T) {
+	zipBytes, err := ziptest.Create([]ziptest.File{{Name: "a.txt", Contents: []byte("hello")}})
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name      string
+		container tartest.Container
+		want      string
+	}{
+		{"tar", tartest.ContainerNone, ".tar"},
+		{"tar.gz", tartest.ContainerGz, ".tar.gz"},
+		{"tar.xz", tartest.ContainerXz, ".tar.xz"},
+		{"tar.zst", tartest.ContainerZstd, ".tar.zst"},
+		{"tar.lz4", tartest.ContainerLz4, ".tar.lz4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tartest.Create(tartest.WithContainer(tt.container))
+			assert.NilError(t, err)
+			b, err := io.ReadAll(r)
+			assert.NilError(t, err)
+
+			ext, dr, err := archives.Detect(bytes.NewReader(b))
+			assert.NilError(t, err)
+			assert.Equal(t, ext, tt.want)
+
+			// The returned reader must return the entire archive.
+			got, err := io.ReadAll(dr)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, b)
+		})
+	}
+
+	t.Run("zip", func(t *testing.T) {
+		ext, r, err := archives.Detect(bytes.NewReader(zipBytes))
+		assert.NilError(t, err)
+		assert.Equal(t, ext, ".zip")
+
+		a, err := archives.Open(r, archives.OpenOptions{Extension: ext})
+		assert.NilError(t, err)
+		defer a.Close()
+
+		h, err := a.Next()
+		assert.NilError(t, err)
+		assert.Equal(t, h.Name, "a.txt")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, _, err := archives.Detect(bytes.NewReader([]byte("hello world")))
+		assert.ErrorIs(t, err, archives.ErrUnsupportedFormat)
+	})
+}
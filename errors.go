@@ -0,0 +1,167 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdtar "archive/tar"
+	stdzip "archive/zip"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"go.rgst.io/jaredallard/archives/v2/internal/deflate64"
+	"go.rgst.io/jaredallard/archives/v2/internal/lzip"
+	"go.rgst.io/jaredallard/archives/v2/internal/unixcompress"
+)
+
+// Contains errors returned when opening, reading and extracting
+// archives. Errors wrap these, use [errors.Is] to check for them.
+var (
+	// ErrUnsupportedFormat is returned when an archive uses a format,
+	// compression method or feature that isn't supported.
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+	// ErrNotFound is returned when a file isn't found in an archive.
+	ErrNotFound = errors.New("file not found in archive")
+
+	// ErrPathTraversal is returned when extracting an entry would write
+	// outside of the destination, e.g. using "../" or symbolic links.
+	ErrPathTraversal = errors.New("path escapes the destination")
+
+	// ErrUnsupportedEntryType is returned when extracting or writing an
+	// entry of a type that isn't supported, such as devices.
+	ErrUnsupportedEntryType = errors.New("unsupported entry type")
+
+	// ErrCorrupt is returned when an archive is malformed, including
+	// checksum mismatches.
+	ErrCorrupt = errors.New("corrupt archive")
+
+	// ErrTruncated is returned when an archive ends unexpectedly.
+	ErrTruncated = errors.New("truncated archive")
+
+	// ErrPasswordRequired is returned when reading an encrypted file
+	// without a password.
+	ErrPasswordRequired = errors.New("password required to read encrypted file")
+
+	// ErrIncorrectPassword is returned when reading an encrypted file
+	// with an incorrect password.
+	ErrIncorrectPassword = errors.New("incorrect password")
+)
+
+// EntryError is returned when an entry of an archive fails to be
+// extracted. Use [errors.As] to get the header of the entry.
+type EntryError struct {
+	// Header is the header of the entry.
+	Header *Header
+
+	// Err is the error that occurred.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Header.Name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// corruptErrors contains the errors returned by the readers used by this
+// package for malformed input.
+var corruptErrors = []error{
+	stdtar.ErrHeader,
+	stdzip.ErrFormat,
+	stdzip.ErrChecksum,
+	gzip.ErrHeader,
+	gzip.ErrChecksum,
+	zstd.ErrMagicMismatch,
+	zstd.ErrReservedBlockType,
+	zstd.ErrCompressedSizeTooBig,
+	zstd.ErrBlockTooSmall,
+	zstd.ErrUnexpectedBlockSize,
+	zstd.ErrWindowSizeTooSmall,
+	zstd.ErrFrameSizeMismatch,
+	zstd.ErrCRCMismatch,
+	lz4.ErrInvalidFrame,
+	lz4.ErrInvalidHeaderChecksum,
+	lz4.ErrInvalidBlockChecksum,
+	lz4.ErrInvalidFrameChecksum,
+	deflate64.ErrCorrupt,
+	lzip.ErrCorrupt,
+	unixcompress.ErrCorrupt,
+}
+
+// classifiedError is an error classified as one of the errors of this
+// package, keeping the message of the original error.
+type classifiedError struct {
+	err  error
+	kind error
+}
+
+// Error implements the error interface.
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the original error and its classification.
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
+
+// classifyError returns err wrapped so that it matches [ErrCorrupt],
+// [ErrTruncated] or [ErrUnsupportedFormat] using [errors.Is] if it is
+// one of the errors returned by the readers used by this package for
+// such input. Other errors are returned as is.
+func classifyError(err error) error {
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, ErrCorrupt) ||
+		errors.Is(err, ErrTruncated) || errors.Is(err, ErrUnsupportedFormat) {
+		return err
+	}
+
+	var kind error
+	var flateErr flate.CorruptInputError
+	var bzip2Err bzip2.StructuralError
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		kind = ErrTruncated
+	case errors.Is(err, stdzip.ErrAlgorithm):
+		kind = ErrUnsupportedFormat
+	case errors.As(err, &flateErr), errors.As(err, &bzip2Err):
+		kind = ErrCorrupt
+	default:
+		for _, corruptErr := range corruptErrors {
+			if errors.Is(err, corruptErr) {
+				kind = ErrCorrupt
+				break
+			}
+		}
+	}
+	if kind == nil {
+		return err
+	}
+
+	return &classifiedError{err: err, kind: kind}
+}
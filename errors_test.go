@@ -0,0 +1,155 @@
+package archives_test
+
+import (
+	stdtar "archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"gotest.tools/v3/assert"
+)
+
+func TestErrUnsupportedFormat(t *testing.T) {
+	_, err := archives.Open(bytes.NewReader(nil), archives.OpenOptions{Extension: ".rar"})
+	assert.ErrorIs(t, err, archives.ErrUnsupportedFormat)
+
+	_, err = archives.OpenReaderAt(bytes.NewReader(nil), 0, archives.OpenOptions{Extension: ".rar"})
+	assert.ErrorIs(t, err, archives.ErrUnsupportedFormat)
+}
+
+func TestErrNotFound(t *testing.T) {
+	r, err := tartest.Create()
+	assert.NilError(t, err)
+
+	a, err := archives.Open(r, archives.OpenOptions{Extension: ".tar"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = archives.Pick(a, archives.PickFilterByName("missing.txt"))
+	assert.ErrorIs(t, err, archives.ErrNotFound)
+}
+
+func TestErrPathTraversal(t *testing.T) {
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "../evil.txt", Typeflag: stdtar.TypeReg},
+	), t.TempDir(), archives.ExtractOptions{Extension: ".tar"})
+	assert.ErrorIs(t, err, archives.ErrPathTraversal)
+
+	var entryErr *archives.EntryError
+	assert.Assert(t, errors.As(err, &entryErr))
+	assert.Equal(t, entryErr.Header.Name, "../evil.txt")
+}
+
+func TestErrPathTraversalSiblingPrefix(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out")
+
+	_, err := archives.ExtractWithResult(createTar(t,
+		&stdtar.Header{Name: "../out2/evil.txt", Typeflag: stdtar.TypeReg},
+	), dest, archives.ExtractOptions{Extension: ".tar"})
+	assert.ErrorIs(t, err, archives.ErrPathTraversal)
+
+	_, err = os.Lstat(filepath.Join(dir, "out2"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestErrPathTraversalSymlink(t *testing.T) {
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "link", Typeflag: stdtar.TypeSymlink, Linkname: "/tmp"},
+		&stdtar.Header{Name: "link/evil.txt", Typeflag: stdtar.TypeReg},
+	), t.TempDir(), archives.ExtractOptions{Extension: ".tar"})
+	assert.ErrorIs(t, err, archives.ErrPathTraversal)
+
+	var entryErr *archives.EntryError
+	assert.Assert(t, errors.As(err, &entryErr))
+	assert.Equal(t, entryErr.Header.Name, "link/evil.txt")
+}
+
+func TestErrUnsupportedEntryType(t *testing.T) {
+	tarArchive := createTar(t,
+		&stdtar.Header{Typeflag: stdtar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": "global"}},
+		&stdtar.Header{Name: "fifo", Typeflag: stdtar.TypeFifo, Mode: 0o644},
+		&stdtar.Header{Name: "null", Typeflag: stdtar.TypeChar, Mode: 0o666, Devmajor: 1, Devminor: 3},
+		&stdtar.Header{Name: "a.txt", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	).Bytes()
+
+	a, err := archives.Open(bytes.NewReader(tarArchive), archives.OpenOptions{Extension: ".tar"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	// Global PAX headers aren't returned as entries.
+	var types []string
+	for {
+		h, err := a.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NilError(t, err)
+		types = append(types, h.Name+" "+h.Type.String())
+	}
+	assert.DeepEqual(t, types, []string{"fifo special", "null special", "a.txt file"})
+
+	dest := t.TempDir()
+	err = archives.Extract(bytes.NewReader(tarArchive), dest, archives.ExtractOptions{Extension: ".tar"})
+	assert.ErrorIs(t, err, archives.ErrUnsupportedEntryType)
+
+	var entryErr *archives.EntryError
+	assert.Assert(t, errors.As(err, &entryErr))
+	assert.Equal(t, entryErr.Header.Name, "fifo")
+
+	// Special entries can be skipped like any other failing entry.
+	dest = t.TempDir()
+	res, err := archives.ExtractWithResult(bytes.NewReader(tarArchive), dest, archives.ExtractOptions{
+		Extension:    ".tar",
+		OnEntryError: func(*archives.Header, error) error { return nil },
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(res.Skipped), 2)
+
+	entries, err := os.ReadDir(dest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Name(), "a.txt")
+}
+
+func TestErrCorrupt(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{{Name: "a.txt", Contents: []byte("hello")}})
+	assert.NilError(t, err)
+
+	// Corrupt the contents of the entry, which is stored, so that its
+	// checksum doesn't match.
+	b = bytes.Replace(b, []byte("hello"), []byte("jello"), 1)
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = a.Next()
+	assert.NilError(t, err)
+
+	_, err = io.ReadAll(a)
+	assert.ErrorIs(t, err, archives.ErrCorrupt)
+
+	_, err = archives.Open(bytes.NewReader([]byte("not a gzip stream")), archives.OpenOptions{Extension: ".tar.gz"})
+	assert.ErrorIs(t, err, archives.ErrCorrupt)
+}
+
+func TestErrTruncated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	_, err := io.Copy(gw, createTar(t, &stdtar.Header{Name: "a.txt", Typeflag: stdtar.TypeReg}))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	b := buf.Bytes()[:buf.Len()/2]
+	err = archives.Extract(bytes.NewReader(b), t.TempDir(), archives.ExtractOptions{Extension: ".tar.gz"})
+	assert.ErrorIs(t, err, archives.ErrTruncated)
+}
@@ -21,7 +21,9 @@ package archives
 import (
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -33,15 +35,44 @@ import (
 // See: https://github.com/securego/gosec/issues/324
 func sanitizeArchivePath(d, t string) (v string, err error) {
 	v = filepath.Join(d, t)
-	if strings.HasPrefix(v, filepath.Clean(d)) {
+	if rel, err := filepath.Rel(d, v); err == nil && !escapesDir(rel) {
 		return v, nil
 	}
 
-	return "", fmt.Errorf("%s: %s", "content filepath is tainted", t)
+	return "", fmt.Errorf("%w: content filepath is tainted: %s", ErrPathTraversal, t)
 }
 
-// extract contains low level logic for extracting archives.
-func extract(a Archive, dest string, opts *ExtractOptions) error {
+// escapesDir returns true if rel, a path relative to a directory as
+// returned by [filepath.Rel], points outside of that directory.
+func escapesDir(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// extract contains low level logic for extracting archives, recording
+// the extracted entries in res. Errors extracting an entry are returned
+// as an [*EntryError], unless skipped by opts.OnEntryError.
+func extract(a Archive, dest string, opts *ExtractOptions, res *ExtractResult) error {
+	dirs, err := extractEntries(a, dest, opts, res)
+	if err != nil {
+		return err
+	}
+
+	return setDirsMetadata(dest, dirs, opts, res)
+}
+
+// extractEntries extracts the entries of a into dest like extract, but
+// returns the extracted directories instead of applying their metadata.
+// The metadata of directories is applied once all entries have been
+// extracted, like GNU tar does: creating their children would change
+// their modification time, and fail if they're read-only.
+func extractEntries(a Archive, dest string, opts *ExtractOptions, res *ExtractResult) ([]ExtractedEntry, error) {
+	var dirs []ExtractedEntry
+
+	var l *layer
+	if opts.Layer != LayerNone {
+		l = newLayer(dest, opts.Layer)
+	}
+
 	for {
 		h, err := a.Next()
 		if err != nil {
@@ -49,63 +80,450 @@ func extract(a Archive, dest string, opts *ExtractOptions) error {
 				break
 			}
 
-			return fmt.Errorf("failed to read archive header: %w", err)
-		}
+			// Nested archives that fail to open (see Recurse) can be
+			// skipped like any other entry.
+			var entryErr *EntryError
+			if errors.As(err, &entryErr) {
+				if err := handleEntryError(entryErr.Header, entryErr.Err, opts, res); err != nil {
+					return nil, err
+				}
+				continue
+			}
 
-		path, err := sanitizeArchivePath(dest, h.Name)
-		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to read archive header: %w", err)
 		}
 
-		switch h.Type {
-		case HeaderDir:
-			//nolint:gosec // Why: acceptable, we're a tar extractor.
-			if err := os.MkdirAll(path, h.Mode); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
+		if l != nil {
+			whiteout, err := l.prepare(h, res)
+			if err != nil {
+				if err := handleEntryError(h, err, opts, res); err != nil {
+					return nil, err
+				}
+				continue
 			}
-		case HeaderFile:
-			// Sometimes the directory entry is missing, so we need to create
-			// it.
-			//
-			//nolint:gosec // Why: acceptable, we're a tar extractor.
-			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
+			if whiteout {
+				continue
 			}
+		}
 
-			//nolint:gosec // Why: acceptable, we're a tar extractor.
-			f, err := os.Create(path)
-			if err != nil {
-				return fmt.Errorf("failed to create file: %w", err)
+		e, err := extractEntry(a, h, dest, opts, res)
+		if err != nil {
+			if err := handleEntryError(h, err, opts, res); err != nil {
+				return nil, err
 			}
+			continue
+		}
 
-			if _, err := io.Copy(f, a); err != nil {
-				_ = f.Close() //nolint:errcheck // Why: Best effort to close the file.
-				return fmt.Errorf("failed to copy file contents: %w", err)
-			}
+		if err := res.record(dest, e); err != nil {
+			return nil, err
+		}
 
-			if err := f.Close(); err != nil {
-				return fmt.Errorf("failed to close file: %w", err)
-			}
-		default:
-			return fmt.Errorf("unsupported file type in package (%s: %v)", h.Name, h.Type)
+		if h.Type == HeaderDir {
+			dirs = append(dirs, e)
+		}
+		if l != nil {
+			l.add(e.Path)
 		}
+	}
+
+	return dirs, nil
+}
 
-		if opts.PreservePermissions != nil && *opts.PreservePermissions {
-			if err := os.Chmod(path, h.Mode); err != nil {
-				return fmt.Errorf("failed to set file permissions: %w", err)
+// setDirsMetadata applies the metadata of the directories dirs,
+// extracted into dest. Errors are handled like errors extracting an
+// entry.
+func setDirsMetadata(dest string, dirs []ExtractedEntry, opts *ExtractOptions, res *ExtractResult) error {
+	// Parents are usually listed before their children, handle children
+	// first anyway.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := setDirMetadata(dest, dirs[i].Path, &dirs[i].Header, opts); err != nil {
+			if err := handleEntryError(&dirs[i].Header, err, opts, res); err != nil {
+				return err
 			}
 		}
+	}
+
+	return nil
+}
+
+// handleEntryError handles err, returned when extracting the entry h,
+// returning it as an [*EntryError] unless skipped by opts.OnEntryError.
+func handleEntryError(h *Header, err error, opts *ExtractOptions, res *ExtractResult) error {
+	entryErr := &EntryError{Header: h, Err: err}
+	if opts.OnEntryError == nil {
+		res.Failed = entryErr
+		return entryErr
+	}
+
+	if err := opts.OnEntryError(h, err); err != nil {
+		res.Failed = entryErr
+		return err
+	}
 
+	res.Skipped = append(res.Skipped, *entryErr)
+	return nil
+}
+
+// extractEntry extracts the entry h, whose contents are read from a,
+// into dest, returning where and what was written. Missing parent
+// directories are created and recorded in res. Files that fail to be
+// written are removed.
+func extractEntry(a Archive, h *Header, dest string, opts *ExtractOptions, res *ExtractResult) (ExtractedEntry, error) {
+	path, err := sanitizeArchivePath(dest, h.Name)
+	if err != nil {
+		return ExtractedEntry{}, err
+	}
+
+	// Symbolic links created by earlier entries must not be used to
+	// write outside of dest.
+	if err := checkNoSymlinks(dest, filepath.Dir(path)); err != nil {
+		return ExtractedEntry{}, err
+	}
+
+	if err := removeSymlink(path); err != nil {
+		return ExtractedEntry{}, err
+	}
+
+	e := ExtractedEntry{Header: *h, Path: path}
+
+	switch h.Type {
+	case HeaderDir:
+		if err := mkdirAll(dest, filepath.Dir(path), res); err != nil {
+			return ExtractedEntry{}, err
+		}
+
+		// Directories are writable until their metadata is applied by
+		// setDirMetadata, so that their children can be created.
+		//
+		//nolint:gosec // Why: acceptable, we're a tar extractor.
+		if err := os.MkdirAll(path, h.Mode|0o700); err != nil {
+			return ExtractedEntry{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		return e, nil
+	case HeaderFile:
+		// Sometimes the directory entry is missing, so we need to create
+		// it.
+		if err := mkdirAll(dest, filepath.Dir(path), res); err != nil {
+			return ExtractedEntry{}, err
+		}
+
+		//nolint:gosec // Why: acceptable, we're a tar extractor.
+		f, err := os.Create(path)
+		if err != nil {
+			return ExtractedEntry{}, fmt.Errorf("failed to create file: %w", err)
+		}
+
+		var digest hash.Hash
+		if opts.Digest != nil {
+			digest = opts.Digest()
+		}
+
+		e.Size, err = copyFile(f, digest, a, h.SparseHoles)
+		if err != nil {
+			_ = f.Close()       //nolint:errcheck // Why: Best effort to close the file.
+			_ = os.Remove(path) //nolint:errcheck // Why: Best effort, don't leave partial files behind.
+			return ExtractedEntry{}, fmt.Errorf("failed to copy file contents: %w", err)
+		}
+
+		if err := f.Close(); err != nil {
+			return ExtractedEntry{}, fmt.Errorf("failed to close file: %w", err)
+		}
+
+		if digest != nil {
+			e.Digest = digest.Sum(nil)
+		}
+	case HeaderSymlink:
+		if err := mkdirAll(dest, filepath.Dir(path), res); err != nil {
+			return ExtractedEntry{}, err
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ExtractedEntry{}, fmt.Errorf("failed to remove existing file: %w", err)
+		}
+
+		if err := os.Symlink(h.Linkname, path); err != nil {
+			return ExtractedEntry{}, fmt.Errorf("failed to create symlink: %w", err)
+		}
+
+		// Permissions and times of symlinks can't be set portably, and
+		// os.Chmod and os.Chtimes would follow the link.
 		if opts.PreserveOwnership {
-			if err := os.Chown(path, h.UID, h.GID); err != nil {
-				return fmt.Errorf("failed to set file ownership: %w", err)
+			uid, gid, err := entryOwner(h, opts)
+			if err != nil {
+				return ExtractedEntry{}, err
+			}
+
+			if err := os.Lchown(path, uid, gid); err != nil {
+				return ExtractedEntry{}, fmt.Errorf("failed to set symlink ownership: %w", err)
 			}
 		}
 
-		if err := os.Chtimes(path, h.AccessTime, h.ModTime); err != nil {
-			return fmt.Errorf("failed to set file times: %w", err)
+		return e, nil
+	case HeaderHardlink:
+		target, err := sanitizeArchivePath(dest, h.Linkname)
+		if err != nil {
+			return ExtractedEntry{}, err
+		}
+
+		if err := checkNoSymlinks(dest, filepath.Dir(target)); err != nil {
+			return ExtractedEntry{}, err
+		}
+
+		if target == path {
+			return ExtractedEntry{}, fmt.Errorf("%w: hardlink refers to itself", ErrCorrupt)
+		}
+
+		if err := mkdirAll(dest, filepath.Dir(path), res); err != nil {
+			return ExtractedEntry{}, err
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ExtractedEntry{}, fmt.Errorf("failed to remove existing file: %w", err)
+		}
+
+		if err := os.Link(target, path); err != nil {
+			return ExtractedEntry{}, fmt.Errorf("failed to create hardlink: %w", err)
+		}
+
+		// The metadata is shared with the target.
+		return e, nil
+	default:
+		return ExtractedEntry{}, fmt.Errorf("%w: %v", ErrUnsupportedEntryType, h.Type)
+	}
+
+	if err := setMetadata(path, h, opts); err != nil {
+		return ExtractedEntry{}, err
+	}
+
+	return e, nil
+}
+
+// setDirMetadata applies the metadata of the directory entry h, which
+// was extracted to path, once all entries have been extracted. It is
+// skipped if path has since been replaced by another entry.
+func setDirMetadata(dest, path string, h *Header, opts *ExtractOptions) error {
+	if err := checkNoSymlinks(dest, filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat directory: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil
+	}
+
+	return setMetadata(path, h, opts)
+}
+
+// setMetadata applies the permissions, ownership and times of h to
+// path, as configured by opts.
+func setMetadata(path string, h *Header, opts *ExtractOptions) error {
+	// Changing the owner clears the setuid and setgid bits, so it has to
+	// happen first.
+	if opts.PreserveOwnership {
+		uid, gid, err := entryOwner(h, opts)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to set file ownership: %w", err)
+		}
+	}
+
+	if mode, ok := extractMode(h, opts); ok {
+		if err := os.Chmod(path, mode); err != nil {
+			return fmt.Errorf("failed to set file permissions: %w", err)
 		}
 	}
 
+	if err := os.Chtimes(path, h.AccessTime, h.ModTime); err != nil {
+		return fmt.Errorf("failed to set file times: %w", err)
+	}
+
 	return nil
 }
+
+// entryOwner returns the user and group ID that the extracted entry h
+// is owned by, mapped by opts.IDMap.
+func entryOwner(h *Header, opts *ExtractOptions) (uid, gid int, err error) {
+	if opts.IDMap == nil {
+		return h.UID, h.GID, nil
+	}
+
+	return opts.IDMap.Map(h)
+}
+
+// extractMode returns the mode to set on the extracted entry h, a file
+// or directory, according to opts. If the mode shouldn't be set, false
+// is returned.
+func extractMode(h *Header, opts *ExtractOptions) (os.FileMode, bool) {
+	force := opts.ForceFileMode
+	if h.Type == HeaderDir {
+		force = opts.ForceDirMode
+	}
+	if force != 0 {
+		return force, true
+	}
+
+	if opts.PreservePermissions == nil || !*opts.PreservePermissions {
+		return 0, false
+	}
+
+	mode := h.Mode & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if opts.ModeMask != 0 {
+		mode &^= os.ModePerm &^ opts.ModeMask
+	}
+	if !opts.AllowSetuid {
+		mode &^= os.ModeSetuid | os.ModeSetgid
+	}
+	if opts.ApplyUmask {
+		mode &^= processUmask()
+	}
+
+	return mode, true
+}
+
+// mkdirAll creates dir and any missing parents, as done by
+// [os.MkdirAll], recording the directories it created below dest in res.
+func mkdirAll(dest, dir string, res *ExtractResult) error {
+	var missing []string
+	for cur := dir; ; cur = filepath.Dir(cur) {
+		rel, err := filepath.Rel(dest, cur)
+		if err != nil || rel == "." || escapesDir(rel) {
+			break
+		}
+
+		if _, err := os.Lstat(cur); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, rel)
+	}
+
+	//nolint:gosec // Why: acceptable, we're a tar extractor.
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		err := res.record(dest, ExtractedEntry{
+			Header:   Header{Name: filepath.ToSlash(missing[i]) + "/", Type: HeaderDir, Mode: os.ModeDir | 0o755},
+			Path:     filepath.Join(dest, missing[i]),
+			Implicit: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNoSymlinks returns an error if any existing component of dir
+// below dest is a symbolic link.
+func checkNoSymlinks(dest, dir string) error {
+	rel, err := filepath.Rel(filepath.Clean(dest), dir)
+	if err != nil || rel == "." {
+		return nil //nolint:nilerr // Why: dir is not below dest.
+	}
+
+	cur := filepath.Clean(dest)
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+
+		fi, err := os.Lstat(cur)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", cur, err)
+		}
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: refusing to write through symlink %s", ErrPathTraversal, cur)
+		}
+	}
+
+	return nil
+}
+
+// removeSymlink removes path if it is a symbolic link, so that creating
+// a file at path does not follow it.
+func removeSymlink(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return nil //nolint:nilerr // Why: Nothing to remove.
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove existing symlink: %w", err)
+	}
+
+	return nil
+}
+
+// copyFile copies the contents of a file from r to f, and to digest if
+// it isn't nil. The holes of sparse files (see [Header.SparseHoles]) are
+// skipped using Seek instead of being written, so that they don't take
+// up space on file systems supporting sparse files.
+func copyFile(f *os.File, digest hash.Hash, r io.Reader, holes []SparseEntry) (int64, error) {
+	var w io.Writer = f
+	if digest != nil {
+		w = io.MultiWriter(f, digest)
+	}
+	if len(holes) == 0 {
+		return io.Copy(w, r)
+	}
+
+	// Holes are skipped without reading them if supported, otherwise
+	// they're read as zeros.
+	skipHole := func(n int64) error {
+		_, err := io.CopyN(io.Discard, r, n)
+		return err
+	}
+	if s, ok := r.(holeSkipper); ok {
+		skipHole = s.skipHole
+	}
+
+	var off int64
+	for _, hole := range holes {
+		n, err := io.CopyN(w, r, hole.Offset-off)
+		off += n
+		if err != nil {
+			return off, err
+		}
+
+		if err := skipHole(hole.Length); err != nil {
+			return off, err
+		}
+		if digest != nil {
+			if _, err := io.CopyN(digest, zeroReader{}, hole.Length); err != nil {
+				return off, err
+			}
+		}
+		if _, err := f.Seek(hole.Length, io.SeekCurrent); err != nil {
+			return off, err
+		}
+		off += hole.Length
+	}
+
+	n, err := io.Copy(w, r)
+	off += n
+	if err != nil {
+		return off, err
+	}
+
+	// Seeking doesn't extend the file if it ends with a hole.
+	return off, f.Truncate(off)
+}
+
+// zeroReader is an [io.Reader] returning an infinite stream of zeros.
+type zeroReader struct{}
+
+// Read implements [io.Reader].
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
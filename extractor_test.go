@@ -0,0 +1,80 @@
+package archives
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExtractMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		h      Header
+		opts   ExtractOptions
+		want   os.FileMode
+		wantOK bool
+	}{
+		{
+			name:   "preserves permissions",
+			h:      Header{Type: HeaderFile, Mode: 0o640},
+			opts:   ExtractOptions{PreservePermissions: ptr(true)},
+			want:   0o640,
+			wantOK: true,
+		},
+		{
+			name: "doesn't preserve permissions",
+			h:    Header{Type: HeaderFile, Mode: 0o640},
+			opts: ExtractOptions{PreservePermissions: ptr(false)},
+		},
+		{
+			name:   "clears setuid and setgid",
+			h:      Header{Type: HeaderFile, Mode: 0o755 | os.ModeSetuid | os.ModeSetgid | os.ModeSticky},
+			opts:   ExtractOptions{PreservePermissions: ptr(true)},
+			want:   0o755 | os.ModeSticky,
+			wantOK: true,
+		},
+		{
+			name:   "allows setuid",
+			h:      Header{Type: HeaderFile, Mode: 0o755 | os.ModeSetuid | os.ModeSetgid},
+			opts:   ExtractOptions{PreservePermissions: ptr(true), AllowSetuid: true},
+			want:   0o755 | os.ModeSetuid | os.ModeSetgid,
+			wantOK: true,
+		},
+		{
+			name:   "masks permissions",
+			h:      Header{Type: HeaderFile, Mode: 0o777 | os.ModeSetuid | os.ModeSticky},
+			opts:   ExtractOptions{PreservePermissions: ptr(true), AllowSetuid: true, ModeMask: 0o755},
+			want:   0o755 | os.ModeSetuid | os.ModeSticky,
+			wantOK: true,
+		},
+		{
+			name:   "applies umask",
+			h:      Header{Type: HeaderFile, Mode: 0o777},
+			opts:   ExtractOptions{PreservePermissions: ptr(true), ApplyUmask: true},
+			want:   0o777 &^ processUmask(),
+			wantOK: true,
+		},
+		{
+			name:   "forces file mode",
+			h:      Header{Type: HeaderFile, Mode: 0o777},
+			opts:   ExtractOptions{PreservePermissions: ptr(false), ForceFileMode: 0o600, ForceDirMode: 0o700},
+			want:   0o600,
+			wantOK: true,
+		},
+		{
+			name:   "forces directory mode",
+			h:      Header{Type: HeaderDir, Mode: os.ModeDir | 0o777},
+			opts:   ExtractOptions{PreservePermissions: ptr(true), ForceFileMode: 0o600, ForceDirMode: 0o700},
+			want:   0o700,
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := extractMode(&tt.h, &tt.opts)
+			assert.Equal(t, ok, tt.wantOK)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
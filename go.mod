@@ -1,14 +1,19 @@
 module go.rgst.io/jaredallard/archives/v2
 
-go 1.23
+go 1.23.0
 
 toolchain go1.26.0
 
 require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/google/go-cmp v0.5.9
 	github.com/jamespfennell/xz v0.1.2
 	github.com/klauspost/compress v1.18.4
+	github.com/pierrec/lz4/v4 v4.1.22
 	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/crypto v0.40.0
+	golang.org/x/text v0.28.0
 	gotest.tools/v3 v3.5.2
 )
 
-require github.com/google/go-cmp v0.5.9 // indirect
+require golang.org/x/sys v0.34.0 // indirect
@@ -0,0 +1,168 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// IDMap maps the user and group IDs of entries to the IDs they're
+// extracted as, see [ExtractOptions.IDMap].
+type IDMap struct {
+	// UIDs contains the ranges user IDs are mapped with, like the ranges
+	// of /etc/subuid or the uid_map of a user namespace.
+	UIDs []IDRange
+
+	// GIDs contains the ranges group IDs are mapped with, like the ranges
+	// of /etc/subgid or the gid_map of a user namespace.
+	GIDs []IDRange
+
+	// ByName, if set, maps entries to the IDs of the user and group of
+	// the system named [Header.Uname] and [Header.Gname], looked up using
+	// [os/user]. Entries without names, or whose names don't exist, are
+	// mapped using the ranges.
+	ByName bool
+
+	// Fallback denotes how IDs that can't be mapped are handled: IDs
+	// that aren't in any of the ranges, and whose names aren't mapped by
+	// ByName. It doesn't apply to mapped IDs that don't exist on the
+	// system, which is common for the IDs of subordinate ranges, since
+	// IDs don't need to exist to own files.
+	//
+	// Defaults to [IDFallbackError].
+	Fallback IDFallback
+
+	// FallbackUID and FallbackGID are the IDs that IDs that can't be
+	// mapped are mapped to if Fallback is [IDFallbackFixed], e.g. 65534
+	// (nobody).
+	FallbackUID, FallbackGID int
+}
+
+// IDRange maps a contiguous range of IDs.
+type IDRange struct {
+	// ArchiveID is the first ID of the range in archives.
+	ArchiveID int
+
+	// HostID is the first ID of the range on the system, that ArchiveID
+	// is mapped to.
+	HostID int
+
+	// Size is the number of IDs in the range.
+	Size int
+}
+
+// IDFallback denotes how IDs that can't be mapped by an [IDMap] are
+// handled, see [IDMap.Fallback].
+type IDFallback int
+
+// Contains the supported fallback policies.
+const (
+	// IDFallbackError fails to extract entries whose IDs can't be mapped.
+	IDFallbackError IDFallback = iota
+
+	// IDFallbackKeep keeps the IDs from the archive.
+	IDFallbackKeep
+
+	// IDFallbackFixed maps the IDs to [IDMap.FallbackUID] and
+	// [IDMap.FallbackGID].
+	IDFallbackFixed
+)
+
+// Map returns the user and group ID that the entry h is mapped to.
+func (m *IDMap) Map(h *Header) (uid, gid int, err error) {
+	uid, err = m.mapID("user", h.UID, h.Uname, m.UIDs, lookupUID, m.FallbackUID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	gid, err = m.mapID("group", h.GID, h.Gname, m.GIDs, lookupGID, m.FallbackGID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return uid, gid, nil
+}
+
+// mapID maps the ID id of a user or group (kind) with the provided
+// name using ranges, or lookup if mapping by name.
+func (m *IDMap) mapID(kind string, id int, name string, ranges []IDRange, lookup func(string) (int, bool, error), fallback int) (int, error) {
+	if m.ByName && name != "" {
+		hostID, ok, err := lookup(name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up %s %q: %w", kind, name, err)
+		}
+		if ok {
+			return hostID, nil
+		}
+	}
+
+	for _, r := range ranges {
+		if id >= r.ArchiveID && id-r.ArchiveID < r.Size {
+			return r.HostID + id - r.ArchiveID, nil
+		}
+	}
+
+	switch m.Fallback {
+	case IDFallbackKeep:
+		return id, nil
+	case IDFallbackFixed:
+		return fallback, nil
+	default:
+		return 0, fmt.Errorf("%s ID %d (%q) is not mapped", kind, id, name)
+	}
+}
+
+// lookupUID returns the ID of the user with the provided name, or false
+// if it doesn't exist.
+func lookupUID(name string) (int, bool, error) {
+	u, err := user.Lookup(name)
+	if errors.As(err, new(user.UnknownUserError)) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		// Not a Unix system (e.g., Windows SIDs).
+		return 0, false, nil //nolint:nilerr // Why: Treated as unknown.
+	}
+	return uid, true, nil
+}
+
+// lookupGID returns the ID of the group with the provided name, or
+// false if it doesn't exist.
+func lookupGID(name string) (int, bool, error) {
+	g, err := user.LookupGroup(name)
+	if errors.As(err, new(user.UnknownGroupError)) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		// Not a Unix system (e.g., Windows SIDs).
+		return 0, false, nil //nolint:nilerr // Why: Treated as unknown.
+	}
+	return gid, true, nil
+}
@@ -0,0 +1,112 @@
+//go:build unix
+
+package archives_test
+
+import (
+	stdtar "archive/tar"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestIDMapRanges(t *testing.T) {
+	m := &archives.IDMap{
+		UIDs: []archives.IDRange{{ArchiveID: 0, HostID: 100000, Size: 65536}},
+		GIDs: []archives.IDRange{{ArchiveID: 0, HostID: 200000, Size: 10}, {ArchiveID: 100, HostID: 300000, Size: 1}},
+	}
+
+	uid, gid, err := m.Map(&archives.Header{UID: 1000, GID: 100})
+	assert.NilError(t, err)
+	assert.Equal(t, uid, 101000)
+	assert.Equal(t, gid, 300000)
+
+	_, _, err = m.Map(&archives.Header{UID: 1000, GID: 10})
+	assert.ErrorContains(t, err, "group ID 10")
+}
+
+func TestIDMapFallback(t *testing.T) {
+	h := &archives.Header{UID: 70000, GID: 70000}
+
+	m := &archives.IDMap{Fallback: archives.IDFallbackKeep}
+	uid, gid, err := m.Map(h)
+	assert.NilError(t, err)
+	assert.Equal(t, uid, 70000)
+	assert.Equal(t, gid, 70000)
+
+	m = &archives.IDMap{Fallback: archives.IDFallbackFixed, FallbackUID: 65534, FallbackGID: 65533}
+	uid, gid, err = m.Map(h)
+	assert.NilError(t, err)
+	assert.Equal(t, uid, 65534)
+	assert.Equal(t, gid, 65533)
+
+	// Mapped IDs don't need to exist on the system.
+	m.UIDs = []archives.IDRange{{ArchiveID: 70000, HostID: 4000000000, Size: 1}}
+	uid, gid, err = m.Map(h)
+	assert.NilError(t, err)
+	assert.Equal(t, uid, 4000000000)
+	assert.Equal(t, gid, 65533)
+}
+
+func TestIDMapByName(t *testing.T) {
+	u, err := user.Current()
+	assert.NilError(t, err)
+	g, err := user.LookupGroupId(u.Gid)
+	assert.NilError(t, err)
+
+	m := &archives.IDMap{ByName: true}
+	uid, gid, err := m.Map(&archives.Header{UID: 12345, GID: 12345, Uname: u.Username, Gname: g.Name})
+	assert.NilError(t, err)
+	assert.Equal(t, strconv.Itoa(uid), u.Uid)
+	assert.Equal(t, strconv.Itoa(gid), u.Gid)
+
+	// Unknown names are mapped by ID.
+	m.UIDs = []archives.IDRange{{ArchiveID: 12345, HostID: 1, Size: 1}}
+	m.Fallback = archives.IDFallbackKeep
+	uid, gid, err = m.Map(&archives.Header{UID: 12345, GID: 12345, Uname: "does-not-exist", Gname: "does-not-exist"})
+	assert.NilError(t, err)
+	assert.Equal(t, uid, 1)
+	assert.Equal(t, gid, 12345)
+}
+
+func TestExtractIDMap(t *testing.T) {
+	dest := t.TempDir()
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "file", Typeflag: stdtar.TypeReg, Mode: 0o644, Uid: 1000, Gid: 1000},
+		&stdtar.Header{Name: "link", Typeflag: stdtar.TypeSymlink, Linkname: "file", Uid: 1000, Gid: 1000},
+	), dest, archives.ExtractOptions{
+		Extension:         ".tar",
+		PreserveOwnership: true,
+		IDMap: &archives.IDMap{
+			UIDs: []archives.IDRange{{ArchiveID: 1000, HostID: os.Getuid(), Size: 1}},
+			GIDs: []archives.IDRange{{ArchiveID: 1000, HostID: os.Getgid(), Size: 1}},
+		},
+	})
+	assert.NilError(t, err)
+
+	for _, name := range []string{"file", "link"} {
+		fi, err := os.Lstat(filepath.Join(dest, name))
+		assert.NilError(t, err)
+
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		assert.Assert(t, ok)
+		assert.Equal(t, int(st.Uid), os.Getuid())
+		assert.Equal(t, int(st.Gid), os.Getgid())
+	}
+}
+
+func TestExtractIDMapUnmapped(t *testing.T) {
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "file", Typeflag: stdtar.TypeReg, Mode: 0o644, Uid: 1000, Gid: 1000},
+	), t.TempDir(), archives.ExtractOptions{
+		Extension:         ".tar",
+		PreserveOwnership: true,
+		IDMap:             &archives.IDMap{},
+	})
+	assert.ErrorContains(t, err, "user ID 1000")
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package artest contains a minimal ar writer for creating archives for
+// usage in tests in the archives package.
+package artest
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Variant is the ar variant to write, which determines how long member
+// names are stored.
+type Variant int
+
+const (
+	// VariantGNU stores long names in a "//" name table. This is the
+	// default.
+	VariantGNU Variant = iota
+	// VariantBSD stores long names after the member header.
+	VariantBSD
+)
+
+// File is a member to add to an archive.
+type File struct {
+	Name     string
+	Contents []byte
+	Mode     int64
+	UID      int
+	GID      int
+	ModTime  time.Time
+}
+
+// Options is a struct for configuring the created archive.
+type Options struct {
+	Variant     Variant
+	SymbolTable bool
+}
+
+// OptionFn modifies a [Options] struct.
+type OptionFn func(*Options)
+
+// WithVariant sets the ar variant to write.
+func WithVariant(v Variant) OptionFn {
+	return func(o *Options) {
+		o.Variant = v
+	}
+}
+
+// WithSymbolTable adds an (empty) symbol table member at the start of
+// the archive, like ar does for static libraries.
+func WithSymbolTable() OptionFn {
+	return func(o *Options) {
+		o.SymbolTable = true
+	}
+}
+
+// Create creates a new ar archive containing the provided files.
+func Create(files []File, options ...OptionFn) ([]byte, error) {
+	opts := &Options{}
+	for _, o := range options {
+		o(opts)
+	}
+
+	buf := bytes.NewBufferString("!<arch>\n")
+
+	if opts.SymbolTable {
+		name := "/"
+		if opts.Variant == VariantBSD {
+			name = "__.SYMDEF"
+		}
+		writeMember(buf, name, File{}, make([]byte, 4))
+	}
+
+	// GNU stores names that don't fit in the header in a name table,
+	// which must precede the members using it.
+	offsets := make(map[string]int)
+	if opts.Variant == VariantGNU {
+		names := new(bytes.Buffer)
+		for _, f := range files {
+			if len(f.Name) > 15 {
+				offsets[f.Name] = names.Len()
+				names.WriteString(f.Name + "/\n")
+			}
+		}
+		if names.Len() > 0 {
+			writeMember(buf, "//", File{}, names.Bytes())
+		}
+	}
+
+	for _, f := range files {
+		switch {
+		case opts.Variant == VariantBSD && (len(f.Name) > 16 || bytes.ContainsAny([]byte(f.Name), " ")):
+			data := append([]byte(f.Name), f.Contents...)
+			writeMember(buf, fmt.Sprintf("#1/%d", len(f.Name)), f, data)
+		case opts.Variant == VariantBSD:
+			writeMember(buf, f.Name, f, f.Contents)
+		case len(f.Name) > 15:
+			writeMember(buf, fmt.Sprintf("/%d", offsets[f.Name]), f, f.Contents)
+		default:
+			writeMember(buf, f.Name+"/", f, f.Contents)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeMember writes a member header followed by data.
+func writeMember(buf *bytes.Buffer, name string, f File, data []byte) {
+	var mtime int64
+	if !f.ModTime.IsZero() {
+		mtime = f.ModTime.Unix()
+	}
+
+	fmt.Fprintf(buf, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, mtime, f.UID, f.GID, f.Mode, len(data))
+	buf.Write(data)
+	if len(data)%2 != 0 {
+		buf.WriteByte('\n')
+	}
+}
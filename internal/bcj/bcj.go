@@ -0,0 +1,267 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package bcj implements the branch/call/jump (BCJ) filters used by
+// 7-Zip and xz to improve the compression of executable code. The
+// filters convert relative branch targets into absolute ones (when
+// encoding) and back (when decoding).
+package bcj
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Filter is a BCJ filter for a specific architecture.
+type Filter interface {
+	// Convert converts buf in place. pos is the position of buf in the
+	// uncompressed stream. It returns the number of bytes that were
+	// processed, any remaining bytes must be passed to the next call to
+	// Convert (or, at the end of the stream, are left as-is).
+	Convert(buf []byte, pos uint32, encode bool) int
+}
+
+// x86 implements [Filter] for x86 (and x86-64) code.
+type x86 struct {
+	prevMask uint32
+	prevPos  uint32
+}
+
+// NewX86 returns a new [Filter] for x86 code.
+func NewX86() Filter {
+	return &x86{prevPos: ^uint32(4)} // -5
+}
+
+// test86MSByte reports if b is a plausible most significant byte of a
+// relative x86 call or jump target.
+func test86MSByte(b byte) bool {
+	return b == 0 || b == 0xff
+}
+
+// Convert implements [Filter].
+func (f *x86) Convert(buf []byte, pos uint32, encode bool) int {
+	maskToAllowed := [8]bool{true, true, true, false, true, false, false, false}
+	maskToBitNumber := [8]uint32{0, 1, 2, 2, 3, 3, 3, 3}
+
+	if len(buf) < 5 {
+		return 0
+	}
+
+	if pos-f.prevPos > 5 {
+		f.prevPos = pos - 5
+	}
+
+	limit := len(buf) - 5
+	i := 0
+	for i <= limit {
+		b := buf[i]
+		if b != 0xe8 && b != 0xe9 {
+			i++
+			continue
+		}
+
+		offset := pos + uint32(i) - f.prevPos //nolint:gosec // Why: Positions wrap by design.
+		f.prevPos = pos + uint32(i)           //nolint:gosec // Why: Positions wrap by design.
+		if offset > 5 {
+			f.prevMask = 0
+		} else {
+			for j := uint32(0); j < offset; j++ {
+				f.prevMask &= 0x77
+				f.prevMask <<= 1
+			}
+		}
+
+		b = buf[i+4]
+		if test86MSByte(b) && maskToAllowed[(f.prevMask>>1)&0x7] && (f.prevMask>>1) < 0x10 {
+			src := binary.LittleEndian.Uint32(buf[i+1:])
+
+			var dest uint32
+			for {
+				if encode {
+					dest = src + (pos + uint32(i) + 5) //nolint:gosec // Why: Positions wrap by design.
+				} else {
+					dest = src - (pos + uint32(i) + 5) //nolint:gosec // Why: Positions wrap by design.
+				}
+
+				if f.prevMask == 0 {
+					break
+				}
+
+				n := maskToBitNumber[f.prevMask>>1]
+				b = byte(dest >> (24 - n*8))
+				if !test86MSByte(b) {
+					break
+				}
+
+				src = dest ^ (1<<(32-n*8) - 1)
+			}
+
+			dest &= 0x01ffffff
+			if dest&0x01000000 != 0 {
+				dest |= 0xff000000
+			}
+			binary.LittleEndian.PutUint32(buf[i+1:], dest)
+			i += 5
+			f.prevMask = 0
+		} else {
+			i++
+			f.prevMask |= 1
+			if test86MSByte(b) {
+				f.prevMask |= 0x10
+			}
+		}
+	}
+
+	return i
+}
+
+// arm implements [Filter] for 32-bit little endian ARM code.
+type arm struct{}
+
+// NewARM returns a new [Filter] for 32-bit little endian ARM code.
+func NewARM() Filter {
+	return arm{}
+}
+
+// Convert implements [Filter].
+func (arm) Convert(buf []byte, pos uint32, encode bool) int {
+	i := 0
+	for ; i+4 <= len(buf); i += 4 {
+		// BL instruction.
+		if buf[i+3] != 0xeb {
+			continue
+		}
+
+		src := (uint32(buf[i+2])<<16 | uint32(buf[i+1])<<8 | uint32(buf[i])) << 2
+		var dest uint32
+		if encode {
+			dest = pos + uint32(i) + 8 + src //nolint:gosec // Why: Positions wrap by design.
+		} else {
+			dest = src - (pos + uint32(i) + 8) //nolint:gosec // Why: Positions wrap by design.
+		}
+		dest >>= 2
+
+		buf[i+2] = byte(dest >> 16)
+		buf[i+1] = byte(dest >> 8)
+		buf[i] = byte(dest)
+	}
+
+	return i
+}
+
+// arm64 implements [Filter] for ARM64 code.
+type arm64 struct{}
+
+// NewARM64 returns a new [Filter] for ARM64 code.
+func NewARM64() Filter {
+	return arm64{}
+}
+
+// Convert implements [Filter].
+func (arm64) Convert(buf []byte, pos uint32, encode bool) int {
+	i := 0
+	for ; i+4 <= len(buf); i += 4 {
+		pc := pos + uint32(i) //nolint:gosec // Why: Positions wrap by design.
+		instr := binary.LittleEndian.Uint32(buf[i:])
+
+		switch {
+		case instr>>26 == 0x25: // BL
+			pc >>= 2
+			if !encode {
+				pc = -pc
+			}
+
+			instr = 0x94000000 | (instr+pc)&0x03ffffff
+			binary.LittleEndian.PutUint32(buf[i:], instr)
+		case instr&0x9f000000 == 0x90000000: // ADRP
+			src := (instr>>29)&3 | (instr>>3)&0x001ffffc
+
+			// Only convert values in the range +/-512 MiB.
+			if (src+0x00020000)&0x001c0000 != 0 {
+				continue
+			}
+
+			pc >>= 12
+			if !encode {
+				pc = -pc
+			}
+
+			dest := src + pc
+			instr &= 0x9000001f
+			instr |= (dest & 3) << 29
+			instr |= (dest & 0x0003fffc) << 3
+			instr |= (-(dest & 0x00020000)) & 0x00e00000
+			binary.LittleEndian.PutUint32(buf[i:], instr)
+		}
+	}
+
+	return i
+}
+
+// reader decodes data read from r using a [Filter].
+type reader struct {
+	r io.Reader
+	f Filter
+
+	// buf contains buffered data. buf[:converted] has been decoded and
+	// is ready to be returned, the rest still needs to be converted.
+	buf       []byte
+	converted int
+	pos       uint32
+	eof       bool
+}
+
+// NewReader returns a new [io.Reader] that decodes the data read from r
+// using f.
+func NewReader(r io.Reader, f Filter) io.Reader {
+	return &reader{r: r, f: f, buf: make([]byte, 0, 1<<16)}
+}
+
+// Read implements [io.Reader].
+func (r *reader) Read(p []byte) (int, error) {
+	for r.converted == 0 {
+		if r.eof {
+			if len(r.buf) == 0 {
+				return 0, io.EOF
+			}
+
+			// Anything left at the end of the stream is not converted.
+			r.converted = len(r.buf)
+			break
+		}
+
+		n, err := r.r.Read(r.buf[len(r.buf):cap(r.buf)])
+		r.buf = r.buf[:len(r.buf)+n]
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+
+		r.converted = r.f.Convert(r.buf, r.pos, false)
+		r.pos += uint32(r.converted) //nolint:gosec // Why: Positions wrap by design.
+	}
+
+	n := copy(p, r.buf[:r.converted])
+	r.converted -= n
+
+	// Shift the remaining data to the front of the buffer.
+	m := copy(r.buf, r.buf[n:])
+	r.buf = r.buf[:m]
+	return n, nil
+}
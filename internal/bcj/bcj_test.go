@@ -0,0 +1,56 @@
+package bcj_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"go.rgst.io/jaredallard/archives/v2/internal/bcj"
+	"gotest.tools/v3/assert"
+)
+
+// code returns pseudo machine code containing plenty of branch
+// instructions for every supported filter.
+func code() []byte {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec // Why: Deterministic test data.
+	b := make([]byte, 1<<18+3)
+	for i := 0; i+5 <= len(b); i += 5 {
+		switch r.Intn(5) {
+		case 0:
+			b[i], b[i+4] = 0xe8, 0x00
+			b[i+1], b[i+2], b[i+3] = byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256))
+		case 1:
+			b[i+3] = 0xeb // ARM BL
+		case 2:
+			b[i] = 0x94 // ARM64 BL (after shifting)
+			b[i+3] = 0x94
+		default:
+			r.Read(b[i : i+5])
+		}
+	}
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	filters := map[string]func() bcj.Filter{
+		"x86":   bcj.NewX86,
+		"arm":   bcj.NewARM,
+		"arm64": bcj.NewARM64,
+	}
+	for name, newFilter := range filters {
+		t.Run(name, func(t *testing.T) {
+			data := code()
+
+			encoded := bytes.Clone(data)
+			newFilter().Convert(encoded, 0, true)
+			assert.Assert(t, !bytes.Equal(encoded, data), "expected data to be converted")
+
+			r := bcj.NewReader(iotest.HalfReader(bytes.NewReader(encoded)), newFilter())
+			got, err := io.ReadAll(r)
+			assert.NilError(t, err)
+			assert.Assert(t, bytes.Equal(got, data), "round trip mismatch")
+		})
+	}
+}
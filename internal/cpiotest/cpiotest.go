@@ -0,0 +1,179 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package cpiotest contains a minimal cpio writer for creating archives
+// for usage in tests in the archives package.
+package cpiotest
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// File is an entry to add to an archive.
+type File struct {
+	// Name is the name of the entry.
+	Name string
+
+	// Contents are the contents of the entry. For symlinks, this is the
+	// target of the link.
+	Contents []byte
+
+	// Mode is the Unix mode of the entry, including the file type bits
+	// (e.g., 0o100644).
+	Mode uint32
+
+	UID     int
+	GID     int
+	ModTime time.Time
+
+	// Ino and Nlink are the inode number and link count of the entry. If
+	// Ino is zero, a unique inode number is assigned.
+	Ino   uint32
+	Nlink uint32
+}
+
+// Format is the cpio format to write.
+type Format int
+
+const (
+	// FormatNewc is the "new" portable format without checksums. This
+	// is the default.
+	FormatNewc Format = iota
+	// FormatCRC is the "new" portable format with checksums.
+	FormatCRC
+	// FormatOdc is the "old" portable format.
+	FormatOdc
+)
+
+// Options is a struct for configuring the created archive.
+type Options struct {
+	Format Format
+}
+
+// OptionFn modifies a [Options] struct.
+type OptionFn func(*Options)
+
+// WithFormat sets the cpio format to write.
+func WithFormat(f Format) OptionFn {
+	return func(o *Options) {
+		o.Format = f
+	}
+}
+
+// Create creates a new cpio archive containing the provided files.
+func Create(files []File, options ...OptionFn) ([]byte, error) {
+	opts := &Options{}
+	for _, o := range options {
+		o(opts)
+	}
+
+	write := func(buf *bytes.Buffer, f File) {
+		switch opts.Format {
+		case FormatNewc:
+			writeNewc(buf, "070701", f)
+		case FormatCRC:
+			writeNewc(buf, "070702", f)
+		case FormatOdc:
+			writeOdc(buf, f)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for i, f := range files {
+		if f.Ino == 0 {
+			f.Ino = uint32(i + 1) //nolint:gosec // Why: Test helper.
+		}
+		if f.Nlink == 0 {
+			f.Nlink = 1
+		}
+
+		write(buf, f)
+	}
+
+	write(buf, File{Name: "TRAILER!!!", Nlink: 1})
+	return buf.Bytes(), nil
+}
+
+// Stripped is an entry in the stripped format used by RPM, which only
+// references the file by its index in the RPM header.
+type Stripped struct {
+	Index    int
+	Contents []byte
+}
+
+// CreateStripped creates a new cpio archive in the stripped format used
+// by RPM for packages containing large files.
+func CreateStripped(files []Stripped) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, f := range files {
+		fmt.Fprintf(buf, "07070X%08x", f.Index)
+		pad(buf)
+		buf.Write(f.Contents)
+		pad(buf)
+	}
+
+	writeNewc(buf, "070701", File{Name: "TRAILER!!!", Nlink: 1})
+	return buf.Bytes(), nil
+}
+
+// writeNewc writes a newc or crc header followed by the contents of f.
+func writeNewc(buf *bytes.Buffer, magic string, f File) {
+	var check uint32
+	if magic == "070702" {
+		for _, b := range f.Contents {
+			check += uint32(b)
+		}
+	}
+
+	fmt.Fprintf(buf, "%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+		magic, f.Ino, f.Mode, f.UID, f.GID, f.Nlink, mtime(f), len(f.Contents),
+		0, 0, 0, 0, len(f.Name)+1, check)
+	buf.WriteString(f.Name)
+	buf.WriteByte(0)
+	pad(buf)
+
+	buf.Write(f.Contents)
+	pad(buf)
+}
+
+// writeOdc writes an odc header followed by the contents of f.
+func writeOdc(buf *bytes.Buffer, f File) {
+	fmt.Fprintf(buf, "070707%06o%06o%06o%06o%06o%06o%06o%011o%06o%011o",
+		0, f.Ino, f.Mode, f.UID, f.GID, f.Nlink, 0, mtime(f), len(f.Name)+1, len(f.Contents))
+	buf.WriteString(f.Name)
+	buf.WriteByte(0)
+	buf.Write(f.Contents)
+}
+
+// mtime returns the modification time of f as a Unix timestamp.
+func mtime(f File) int64 {
+	if f.ModTime.IsZero() {
+		return 0
+	}
+
+	return f.ModTime.Unix()
+}
+
+// pad pads buf to a multiple of four bytes.
+func pad(buf *bytes.Buffer) {
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+}
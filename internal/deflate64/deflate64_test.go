@@ -0,0 +1,96 @@
+package deflate64_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2/internal/deflate64"
+	"gotest.tools/v3/assert"
+)
+
+// testData returns data made of random words, which contains no
+// repetitions longer than 258 bytes.
+func testData() []byte {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec // Why: Deterministic test data.
+	words := []string{"hello", "world", "archive", "deflate", " ", "\n", "64"}
+
+	buf := new(bytes.Buffer)
+	for buf.Len() < 1<<18 {
+		if r.Intn(10) == 0 {
+			buf.WriteByte(byte(r.Intn(256)))
+			continue
+		}
+		buf.WriteString(words[r.Intn(len(words))])
+	}
+	return buf.Bytes()
+}
+
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	w := deflate64.NewWriter(buf)
+	_, err := w.Write(data)
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decompress(t *testing.T, b []byte) ([]byte, error) {
+	t.Helper()
+
+	r := deflate64.NewReader(bytes.NewReader(b))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	// Long runs and repetitions more than 32 KiB apart use the length
+	// and distance codes specific to Deflate64.
+	far := append(bytes.Repeat([]byte{'x'}, 100000), testData()[:40000]...)
+	far = append(far, far[100000:]...)
+
+	for _, data := range [][]byte{{}, []byte("a"), bytes.Repeat([]byte("ab"), 1000), testData(), far} {
+		got, err := decompress(t, compress(t, data))
+		assert.NilError(t, err)
+		assert.Assert(t, bytes.Equal(got, data), "round trip mismatch (len %d != %d)", len(got), len(data))
+	}
+}
+
+// TestDeflateCompatible ensures that deflate streams without matches
+// of length 258, whose encoding differs, are decoded.
+func TestDeflateCompatible(t *testing.T) {
+	data := testData()
+	for _, level := range []int{flate.NoCompression, flate.BestSpeed, flate.BestCompression, flate.HuffmanOnly} {
+		buf := new(bytes.Buffer)
+		w, err := flate.NewWriter(buf, level)
+		assert.NilError(t, err)
+		_, err = w.Write(data)
+		assert.NilError(t, err)
+		assert.NilError(t, w.Close())
+
+		got, err := decompress(t, buf.Bytes())
+		assert.NilError(t, err)
+		assert.Assert(t, bytes.Equal(got, data), "level %d: mismatch", level)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	b := compress(t, testData())
+	_, err := decompress(t, b[:len(b)/2])
+	assert.Assert(t, errors.Is(err, io.ErrUnexpectedEOF), "unexpected error: %v", err)
+}
+
+func TestCorrupt(t *testing.T) {
+	// A block with the reserved block type.
+	_, err := decompress(t, []byte{0x07})
+	assert.Assert(t, errors.Is(err, deflate64.ErrCorrupt), "unexpected error: %v", err)
+
+	// A stored block with a mismatching length.
+	_, err = decompress(t, []byte{0x01, 0x05, 0x00, 0x00, 0x00})
+	assert.Assert(t, errors.Is(err, deflate64.ErrCorrupt), "unexpected error: %v", err)
+}
@@ -0,0 +1,498 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package deflate64 implements the Deflate64 ("enhanced deflate")
+// format used by zip archives created by Windows and 7-Zip for large
+// files.
+//
+// Deflate64 is identical to deflate (RFC 1951) except for a 64 KiB
+// window, two additional distance codes and length code 285, which
+// stores a 16 bit length instead of the fixed length 258. The standard
+// library's compress/flate package does not support these differences.
+package deflate64
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Contains constants for the Deflate64 format.
+const (
+	// windowSize is the size of the sliding window.
+	windowSize = 1 << 16
+
+	// maxCodeBits is the maximum length of a Huffman code.
+	maxCodeBits = 15
+
+	// endOfBlock is the literal/length symbol ending a block.
+	endOfBlock = 256
+
+	// chunkSize is the amount of output decoded at once.
+	chunkSize = 1 << 15
+)
+
+// ErrCorrupt is returned when the stream is not valid.
+var ErrCorrupt = errors.New("deflate64: corrupt stream")
+
+// Contains the base values and number of extra bits of the length and
+// distance codes.
+var (
+	lengthBase = [29]int{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31,
+		35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 3,
+	}
+	lengthExtra = [29]uint{
+		0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2,
+		3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 16,
+	}
+	distBase = [32]int{
+		1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193,
+		257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577, 32769, 49153,
+	}
+	distExtra = [32]uint{
+		0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6,
+		7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13, 14, 14,
+	}
+
+	// codeLengthOrder is the order in which the lengths of the code
+	// length code are stored.
+	codeLengthOrder = [19]int{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+// huffman is a Huffman code, decoded using a lookup table indexed by
+// the next maxCodeBits bits of the stream.
+type huffman struct {
+	// table contains the symbol shifted left by four and the length of
+	// its code for every possible bit sequence. A length of zero denotes
+	// an unused code.
+	table [1 << maxCodeBits]uint32
+}
+
+// init initializes h from the code lengths of its symbols.
+func (h *huffman) init(lengths []uint8) error {
+	var count [maxCodeBits + 1]int
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+
+	var next [maxCodeBits + 1]int
+	code := 0
+	for l := 1; l <= maxCodeBits; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+		if code+count[l] > 1<<l {
+			// The code is over-subscribed.
+			return ErrCorrupt
+		}
+	}
+
+	h.table = [1 << maxCodeBits]uint32{}
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+
+		// Codes are stored most significant bit first, but the stream is
+		// read least significant bit first.
+		c := next[l]
+		next[l]++
+		var rev int
+		for i := uint8(0); i < l; i++ {
+			rev = rev<<1 | c>>i&1
+		}
+
+		for i := rev; i < len(h.table); i += 1 << l {
+			h.table[i] = uint32(sym)<<4 | uint32(l) //nolint:gosec // Why: Bounded by the alphabet size.
+		}
+	}
+
+	return nil
+}
+
+// fixedLit and fixedDist are the fixed Huffman codes.
+var fixedLit, fixedDist = func() (*huffman, *huffman) {
+	var lengths [288]uint8
+	for i := range lengths {
+		switch {
+		case i < 144:
+			lengths[i] = 8
+		case i < 256:
+			lengths[i] = 9
+		case i < 280:
+			lengths[i] = 7
+		default:
+			lengths[i] = 8
+		}
+	}
+	lit := &huffman{}
+	lit.init(lengths[:]) //nolint:errcheck // Why: The fixed code is valid.
+
+	var distLengths [32]uint8
+	for i := range distLengths {
+		distLengths[i] = 5
+	}
+	dist := &huffman{}
+	dist.init(distLengths[:]) //nolint:errcheck // Why: The fixed code is valid.
+
+	return lit, dist
+}()
+
+// reader implements [io.Reader] for Deflate64 streams.
+type reader struct {
+	r io.ByteReader
+
+	// bits contains n bits read from r that have not been used yet.
+	bits uint64
+	n    uint
+
+	// win contains the history followed by the output that has not been
+	// read yet, which starts at rd.
+	win []byte
+	rd  int
+
+	// Contains the state of the current block.
+	inBlock bool
+	final   bool
+	stored  int
+	lit     *huffman
+	dist    *huffman
+
+	// dynLit and dynDist are reused for dynamic Huffman codes.
+	dynLit, dynDist huffman
+
+	err error
+}
+
+// NewReader returns a new [io.ReadCloser] that decompresses the
+// Deflate64 stream read from r.
+func NewReader(r io.Reader) io.ReadCloser {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &reader{r: br, win: make([]byte, 0, 3*windowSize)}
+}
+
+// Close implements [io.Closer]. It does not close the underlying
+// reader.
+func (z *reader) Close() error {
+	return nil
+}
+
+// Read implements [io.Reader].
+func (z *reader) Read(p []byte) (int, error) {
+	for z.rd == len(z.win) {
+		if z.err != nil {
+			return 0, z.err
+		}
+
+		// Keep the window from growing unbounded, all output has been
+		// read at this point.
+		if len(z.win) > 2*windowSize {
+			n := copy(z.win, z.win[len(z.win)-windowSize:])
+			z.win = z.win[:n]
+			z.rd = n
+		}
+
+		z.err = z.decode()
+	}
+
+	n := copy(p, z.win[z.rd:])
+	z.rd += n
+	return n, nil
+}
+
+// need ensures that at least n bits are available.
+func (z *reader) need(n uint) error {
+	for z.n < n {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		z.bits |= uint64(b) << z.n
+		z.n += 8
+	}
+
+	return nil
+}
+
+// readBits reads n bits.
+func (z *reader) readBits(n uint) (int, error) {
+	if err := z.need(n); err != nil {
+		return 0, err
+	}
+
+	v := int(z.bits & (1<<n - 1)) //nolint:gosec // Why: At most 16 bits.
+	z.bits >>= n
+	z.n -= n
+	return v, nil
+}
+
+// readSymbol reads a symbol encoded using h.
+func (z *reader) readSymbol(h *huffman) (int, error) {
+	// The last code of a stream may be shorter than maxCodeBits, so
+	// running out of input is only an error if the code is incomplete.
+	if err := z.need(maxCodeBits); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+
+	e := h.table[z.bits&(1<<maxCodeBits-1)]
+	l := uint(e & 0xf)
+	if l == 0 {
+		return 0, ErrCorrupt
+	}
+	if l > z.n {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	z.bits >>= l
+	z.n -= l
+	return int(e >> 4), nil
+}
+
+// decode decodes the next chunk of output into the window. It returns
+// [io.EOF] after the final block.
+func (z *reader) decode() error {
+	start := len(z.win)
+	for len(z.win)-start < chunkSize {
+		if !z.inBlock {
+			if z.final {
+				return io.EOF
+			}
+			if err := z.readBlockHeader(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if z.lit == nil {
+			if err := z.decodeStored(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sym, err := z.readSymbol(z.lit)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case sym < endOfBlock:
+			z.win = append(z.win, byte(sym))
+		case sym == endOfBlock:
+			z.inBlock = false
+		default:
+			if err := z.decodeMatch(sym - endOfBlock - 1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readBlockHeader reads the header of the next block.
+func (z *reader) readBlockHeader() error {
+	hdr, err := z.readBits(3)
+	if err != nil {
+		return err
+	}
+	z.final = hdr&1 != 0
+	z.inBlock = true
+
+	switch hdr >> 1 {
+	case 0:
+		// Stored blocks start at a byte boundary.
+		z.bits >>= z.n % 8
+		z.n -= z.n % 8
+
+		length, err := z.readBits(16)
+		if err != nil {
+			return err
+		}
+		nlength, err := z.readBits(16)
+		if err != nil {
+			return err
+		}
+		if length != ^nlength&0xffff {
+			return ErrCorrupt
+		}
+
+		z.lit, z.dist = nil, nil
+		z.stored = length
+	case 1:
+		z.lit, z.dist = fixedLit, fixedDist
+	case 2:
+		if err := z.readDynamicCodes(); err != nil {
+			return err
+		}
+		z.lit, z.dist = &z.dynLit, &z.dynDist
+	default:
+		return ErrCorrupt
+	}
+
+	return nil
+}
+
+// readDynamicCodes reads the Huffman codes of a dynamic block.
+func (z *reader) readDynamicCodes() error {
+	nlit, err := z.readBits(5)
+	if err != nil {
+		return err
+	}
+	ndist, err := z.readBits(5)
+	if err != nil {
+		return err
+	}
+	nclen, err := z.readBits(4)
+	if err != nil {
+		return err
+	}
+	nlit += 257
+	ndist++
+	nclen += 4
+
+	if nlit > 286 {
+		return ErrCorrupt
+	}
+
+	var clenLengths [19]uint8
+	for i := 0; i < nclen; i++ {
+		l, err := z.readBits(3)
+		if err != nil {
+			return err
+		}
+		clenLengths[codeLengthOrder[i]] = uint8(l) //nolint:gosec // Why: At most 3 bits.
+	}
+
+	var clen huffman
+	if err := clen.init(clenLengths[:]); err != nil {
+		return err
+	}
+
+	lengths := make([]uint8, nlit+ndist)
+	for i := 0; i < len(lengths); {
+		sym, err := z.readSymbol(&clen)
+		if err != nil {
+			return err
+		}
+
+		if sym < 16 {
+			lengths[i] = uint8(sym) //nolint:gosec // Why: Checked above.
+			i++
+			continue
+		}
+
+		var repeat int
+		var value uint8
+		switch sym {
+		case 16:
+			if i == 0 {
+				return ErrCorrupt
+			}
+			value = lengths[i-1]
+			repeat, err = z.readBits(2)
+			repeat += 3
+		case 17:
+			repeat, err = z.readBits(3)
+			repeat += 3
+		default:
+			repeat, err = z.readBits(7)
+			repeat += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+repeat > len(lengths) {
+			return ErrCorrupt
+		}
+
+		for ; repeat > 0; repeat-- {
+			lengths[i] = value
+			i++
+		}
+	}
+
+	if lengths[endOfBlock] == 0 {
+		return ErrCorrupt
+	}
+	if err := z.dynLit.init(lengths[:nlit]); err != nil {
+		return err
+	}
+	return z.dynDist.init(lengths[nlit:])
+}
+
+// decodeStored copies the contents of a stored block.
+func (z *reader) decodeStored() error {
+	for ; z.stored > 0; z.stored-- {
+		b, err := z.readBits(8)
+		if err != nil {
+			return err
+		}
+		z.win = append(z.win, byte(b))
+	}
+
+	z.inBlock = false
+	return nil
+}
+
+// decodeMatch decodes the match for the length code code.
+func (z *reader) decodeMatch(code int) error {
+	if code >= len(lengthBase) {
+		return ErrCorrupt
+	}
+	extra, err := z.readBits(lengthExtra[code])
+	if err != nil {
+		return err
+	}
+	length := lengthBase[code] + extra
+
+	dcode, err := z.readSymbol(z.dist)
+	if err != nil {
+		return err
+	}
+	if dcode >= len(distBase) {
+		return ErrCorrupt
+	}
+	extra, err = z.readBits(distExtra[dcode])
+	if err != nil {
+		return err
+	}
+	dist := distBase[dcode] + extra
+
+	if dist > len(z.win) {
+		return ErrCorrupt
+	}
+
+	// Matches may overlap their own output, so they are copied one byte
+	// at a time.
+	pos := len(z.win) - dist
+	for i := 0; i < length; i++ {
+		z.win = append(z.win, z.win[pos+i])
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package deflate64
+
+import (
+	"io"
+)
+
+// Contains constants for the writer.
+const (
+	// minMatch and maxMatch are the bounds of the length of a match.
+	minMatch = 3
+	maxMatch = 3 + 0xffff
+
+	// hashBits is the size of the hash table used to find matches.
+	hashBits = 15
+)
+
+// Writer compresses data using the Deflate64 format. It is a minimal
+// implementation, writing a single block using the fixed Huffman codes,
+// intended to create test data. Unlike deflate, it makes use of matches
+// longer than 258 bytes and distances larger than 32 KiB.
+type Writer struct {
+	w   io.Writer
+	buf []byte
+
+	// bits contains n bits that have not been written yet.
+	bits uint64
+	n    uint
+	out  []byte
+}
+
+// NewWriter returns a new [Writer] writing the compressed data to w.
+// The data is only written once the writer is closed.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write implements [io.Writer].
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// Close compresses the written data and writes it to the underlying
+// writer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	// A single final block using the fixed Huffman codes.
+	w.writeBits(0b011, 3)
+
+	var head [1 << hashBits]int
+	for i := range head {
+		head[i] = -1
+	}
+	hash := func(i int) int {
+		return int((uint32(w.buf[i])<<16 | uint32(w.buf[i+1])<<8 | uint32(w.buf[i+2])) * 2654435761 >> (32 - hashBits))
+	}
+
+	for i := 0; i < len(w.buf); {
+		if i+minMatch > len(w.buf) {
+			w.writeLiteral(int(w.buf[i]))
+			i++
+			continue
+		}
+
+		h := hash(i)
+		cand := head[h]
+		head[h] = i
+
+		length := 0
+		if cand >= 0 && i-cand <= windowSize {
+			for length < maxMatch && i+length < len(w.buf) && w.buf[cand+length] == w.buf[i+length] {
+				length++
+			}
+		}
+
+		if length < minMatch {
+			w.writeLiteral(int(w.buf[i]))
+			i++
+			continue
+		}
+
+		w.writeMatch(length, i-cand)
+		for j := i + 1; j < i+length && j+minMatch <= len(w.buf); j++ {
+			head[hash(j)] = j
+		}
+		i += length
+	}
+
+	w.writeLiteral(endOfBlock)
+	if w.n > 0 {
+		w.writeBits(0, 8-w.n%8)
+	}
+
+	_, err := w.w.Write(w.out)
+	return err
+}
+
+// writeBits writes the n least significant bits of v.
+func (w *Writer) writeBits(v int, n uint) {
+	w.bits |= uint64(v) << w.n //nolint:gosec // Why: v is non-negative.
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.bits))
+		w.bits >>= 8
+		w.n -= 8
+	}
+}
+
+// writeCode writes a Huffman code of n bits, which are stored most
+// significant bit first.
+func (w *Writer) writeCode(code int, n uint) {
+	var rev int
+	for i := uint(0); i < n; i++ {
+		rev = rev<<1 | code>>i&1
+	}
+	w.writeBits(rev, n)
+}
+
+// writeLiteral writes a literal/length symbol using the fixed code.
+func (w *Writer) writeLiteral(sym int) {
+	switch {
+	case sym < 144:
+		w.writeCode(0x30+sym, 8)
+	case sym < 256:
+		w.writeCode(0x190+sym-144, 9)
+	case sym < 280:
+		w.writeCode(sym-256, 7)
+	default:
+		w.writeCode(0xc0+sym-280, 8)
+	}
+}
+
+// writeMatch writes a match of length bytes at distance dist.
+func (w *Writer) writeMatch(length, dist int) {
+	code := len(lengthBase) - 1
+	if length <= 258 {
+		for code = len(lengthBase) - 2; lengthBase[code] > length; code-- {
+		}
+	}
+	w.writeLiteral(endOfBlock + 1 + code)
+	w.writeBits(length-lengthBase[code], lengthExtra[code])
+
+	dcode := len(distBase) - 1
+	for distBase[dcode] > dist {
+		dcode--
+	}
+	w.writeCode(dcode, 5)
+	w.writeBits(dist-distBase[dcode], distExtra[dcode])
+}
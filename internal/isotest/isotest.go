@@ -0,0 +1,531 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package isotest contains a minimal ISO 9660 writer for creating
+// images for usage in tests in the archives package.
+package isotest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// sectorSize is the size of a sector and logical block.
+const sectorSize = 2048
+
+// File is an entry to add to an image.
+type File struct {
+	// Name is the path of the entry, e.g. boot/vmlinuz.
+	Name string
+
+	// Contents are the contents of the entry.
+	Contents []byte
+
+	// Mode is the Unix mode of the entry, including the file type bits
+	// (e.g., 0o100644). Directories are created for parents that are not
+	// in the list of files.
+	Mode uint32
+
+	// Linkname is the target of the entry if it is a symlink. It is only
+	// written with Rock Ridge extensions.
+	Linkname string
+
+	UID     int
+	GID     int
+	ModTime time.Time
+}
+
+// Options is a struct for configuring the created image.
+type Options struct {
+	RockRidge  bool
+	Joliet     bool
+	ExtentSize int
+}
+
+// OptionFn modifies a [Options] struct.
+type OptionFn func(*Options)
+
+// WithRockRidge adds Rock Ridge extensions to the primary volume.
+func WithRockRidge() OptionFn {
+	return func(o *Options) {
+		o.RockRidge = true
+	}
+}
+
+// WithJoliet adds a Joliet supplementary volume.
+func WithJoliet() OptionFn {
+	return func(o *Options) {
+		o.Joliet = true
+	}
+}
+
+// WithExtentSize splits the contents of files into extents of at most
+// size bytes, which must be a multiple of 2048. This is used to create
+// multi-extent files without writing 4 GiB of data.
+func WithExtentSize(size int) OptionFn {
+	return func(o *Options) {
+		o.ExtentSize = size
+	}
+}
+
+// node is a file or directory of the image.
+type node struct {
+	name     string
+	file     File
+	dir      bool
+	children []*node
+	parent   *node
+
+	// number is the number of a directory in the path table.
+	number int
+
+	// lba and size are the location of the contents of the node, jlba
+	// and jsize the location of a directory in the Joliet hierarchy.
+	lba, size   int
+	jlba, jsize int
+}
+
+// Create creates a new ISO 9660 image containing the provided files.
+func Create(files []File, options ...OptionFn) ([]byte, error) {
+	opts := &Options{ExtentSize: 0xfffff800}
+	for _, o := range options {
+		o(opts)
+	}
+	if opts.ExtentSize%sectorSize != 0 {
+		return nil, fmt.Errorf("extent size %d is not a multiple of %d", opts.ExtentSize, sectorSize)
+	}
+
+	root := &node{dir: true, file: File{Mode: 0o40755}}
+	root.parent = root
+	for _, f := range files {
+		if err := root.add(f); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &writer{opts: opts, root: root}
+	return w.write()
+}
+
+// add adds f to the tree below n, creating missing parents.
+func (n *node) add(f File) error {
+	parts := strings.Split(strings.Trim(f.Name, "/"), "/")
+	cur := n
+	for i, part := range parts {
+		var child *node
+		for _, c := range cur.children {
+			if c.name == part {
+				child = c
+			}
+		}
+
+		last := i == len(parts)-1
+		if child == nil {
+			child = &node{name: part, parent: cur, dir: true, file: File{Mode: 0o40755}}
+			cur.children = append(cur.children, child)
+		} else if last || !child.dir {
+			return fmt.Errorf("duplicate entry %q", f.Name)
+		}
+
+		if last {
+			child.file = f
+			child.dir = f.Mode&0o170000 == 0o040000
+		}
+		cur = child
+	}
+
+	return nil
+}
+
+// writer lays out and writes an image.
+type writer struct {
+	opts *Options
+	root *node
+
+	// dirs contains all directories in path table order.
+	dirs []*node
+	// files contains all files with contents in the order they are
+	// written.
+	files []*node
+}
+
+// isoName returns the identifier of n in the primary volume.
+func isoName(n *node) []byte {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, n.name)
+
+	if n.dir {
+		return []byte(strings.ReplaceAll(name, ".", "_"))
+	}
+	if !strings.Contains(name, ".") {
+		name += "."
+	}
+	return []byte(name + ";1")
+}
+
+// jolietName returns the identifier of n in the Joliet volume.
+func jolietName(n *node) []byte {
+	name := n.name
+	if !n.dir {
+		name += ";1"
+	}
+
+	u := utf16.Encode([]rune(name))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.BigEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+// write lays out the image and returns it.
+func (w *writer) write() ([]byte, error) {
+	// Sort children and collect directories in breadth first order, as
+	// required by the path table.
+	queue := []*node{w.root}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		d.number = len(w.dirs) + 1
+		w.dirs = append(w.dirs, d)
+
+		sort.Slice(d.children, func(i, j int) bool {
+			return bytes.Compare(isoName(d.children[i]), isoName(d.children[j])) < 0
+		})
+		for _, c := range d.children {
+			switch {
+			case c.dir:
+				queue = append(queue, c)
+			case c.file.Linkname == "":
+				w.files = append(w.files, c)
+			}
+		}
+	}
+
+	// Volume descriptors are followed by the path tables, directories
+	// and file contents.
+	sector := 16 + 2
+	if w.opts.Joliet {
+		sector++
+	}
+	pathTable := sector
+	sector += 2
+	jolietPathTable := sector
+	if w.opts.Joliet {
+		sector += 2
+	}
+
+	for _, d := range w.dirs {
+		d.size = len(w.dirRecords(d, false))
+	}
+	if w.opts.Joliet {
+		for _, d := range w.dirs {
+			d.jsize = len(w.dirRecords(d, true))
+		}
+	}
+	for _, d := range w.dirs {
+		d.lba = sector
+		sector += d.size / sectorSize
+	}
+	if w.opts.Joliet {
+		for _, d := range w.dirs {
+			d.jlba = sector
+			sector += d.jsize / sectorSize
+		}
+	}
+	for _, f := range w.files {
+		f.lba = sector
+		f.size = len(f.file.Contents)
+		sector += (f.size + sectorSize - 1) / sectorSize
+	}
+
+	img := make([]byte, sector*sectorSize)
+	pt, mpt := w.pathTable(false)
+	copy(img[16*sectorSize:], w.volumeDescriptor(1, sector, len(pt), pathTable, w.root.lba, w.root.size))
+	copy(img[pathTable*sectorSize:], pt)
+	copy(img[(pathTable+1)*sectorSize:], mpt)
+	next := 17
+	if w.opts.Joliet {
+		jpt, jmpt := w.pathTable(true)
+		svd := w.volumeDescriptor(2, sector, len(jpt), jolietPathTable, w.root.jlba, w.root.jsize)
+		copy(svd[88:], "%/E")
+		copy(img[17*sectorSize:], svd)
+		copy(img[jolietPathTable*sectorSize:], jpt)
+		copy(img[(jolietPathTable+1)*sectorSize:], jmpt)
+		next++
+	}
+	copy(img[next*sectorSize:], "\xffCD001\x01")
+
+	for _, d := range w.dirs {
+		copy(img[d.lba*sectorSize:], w.dirRecords(d, false))
+		if w.opts.Joliet {
+			copy(img[d.jlba*sectorSize:], w.dirRecords(d, true))
+		}
+	}
+	for _, f := range w.files {
+		copy(img[f.lba*sectorSize:], f.file.Contents)
+	}
+
+	return img, nil
+}
+
+// volumeDescriptor returns a primary (typ 1) or supplementary (typ 2)
+// volume descriptor.
+func (w *writer) volumeDescriptor(typ byte, sectors, ptSize, ptLBA, rootLBA, rootSize int) []byte {
+	vd := make([]byte, sectorSize)
+	vd[0] = typ
+	copy(vd[1:], "CD001\x01")
+	for _, r := range [][2]int{{8, 72}, {190, 813}} {
+		for i := r[0]; i < r[1]; i++ {
+			vd[i] = ' '
+		}
+	}
+	copy(vd[40:], "ARCHIVES")
+	both32(vd[80:], sectors)
+	both16(vd[120:], 1)
+	both16(vd[124:], 1)
+	both16(vd[128:], sectorSize)
+	both32(vd[132:], ptSize)
+	binary.LittleEndian.PutUint32(vd[140:], uint32(ptLBA)) //nolint:gosec // Why: Test helper.
+	binary.BigEndian.PutUint32(vd[148:], uint32(ptLBA+1))  //nolint:gosec // Why: Test helper.
+	copy(vd[156:], record(rootLBA, rootSize, w.root.file.ModTime, 2, []byte{0}, nil))
+	for _, off := range []int{813, 830, 847, 864} {
+		copy(vd[off:], "0000000000000000")
+	}
+	vd[881] = 1
+	return vd
+}
+
+// pathTable returns the little and big endian path tables.
+func (w *writer) pathTable(joliet bool) ([]byte, []byte) {
+	var l, m bytes.Buffer
+	for _, d := range w.dirs {
+		name, lba := []byte{0}, d.lba
+		if d != w.root {
+			name = isoName(d)
+			if joliet {
+				name = jolietName(d)
+			}
+		}
+		if joliet {
+			lba = d.jlba
+		}
+
+		for _, buf := range []*bytes.Buffer{&l, &m} {
+			e := make([]byte, 8+len(name)+len(name)%2)
+			e[0] = byte(len(name))
+			if buf == &l {
+				binary.LittleEndian.PutUint32(e[2:], uint32(lba))             //nolint:gosec // Why: Test helper.
+				binary.LittleEndian.PutUint16(e[6:], uint16(d.parent.number)) //nolint:gosec // Why: Test helper.
+			} else {
+				binary.BigEndian.PutUint32(e[2:], uint32(lba))             //nolint:gosec // Why: Test helper.
+				binary.BigEndian.PutUint16(e[6:], uint16(d.parent.number)) //nolint:gosec // Why: Test helper.
+			}
+			copy(e[8:], name)
+			buf.Write(e)
+		}
+	}
+
+	return l.Bytes(), m.Bytes()
+}
+
+// dirRecords returns the records of directory d, padded to a multiple
+// of the sector size.
+func (w *writer) dirRecords(d *node, joliet bool) []byte {
+	loc := func(n *node) (int, int) {
+		if joliet {
+			return n.jlba, n.jsize
+		}
+		return n.lba, n.size
+	}
+
+	var recs [][]byte
+	lba, size := loc(d)
+	var su []byte
+	if w.opts.RockRidge && !joliet {
+		su = w.rockRidge(d, "")
+		if d == w.root {
+			su = append(append([]byte("SP\x07\x01\xbe\xef\x00"), su...), er()...)
+		}
+	}
+	recs = append(recs, record(lba, size, d.file.ModTime, 2, []byte{0}, su))
+	lba, size = loc(d.parent)
+	recs = append(recs, record(lba, size, d.parent.file.ModTime, 2, []byte{1}, nil))
+
+	for _, c := range d.children {
+		name := isoName(c)
+		if joliet {
+			name = jolietName(c)
+		}
+		var su []byte
+		if w.opts.RockRidge && !joliet {
+			su = w.rockRidge(c, c.name)
+		}
+
+		switch {
+		case c.dir:
+			lba, size := loc(c)
+			recs = append(recs, record(lba, size, c.file.ModTime, 2, name, su))
+		case c.file.Linkname != "":
+			recs = append(recs, record(0, 0, c.file.ModTime, 0, name, su))
+		default:
+			// Files larger than the extent size are split into multiple
+			// records.
+			lba, remaining := c.lba, c.size
+			for {
+				n, flags := min(remaining, w.opts.ExtentSize), byte(0)
+				if n < remaining {
+					flags = 0x80
+				}
+				recs = append(recs, record(lba, n, c.file.ModTime, flags, name, su))
+
+				lba += n / sectorSize
+				remaining -= n
+				if remaining == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, r := range recs {
+		if used := buf.Len() % sectorSize; used+len(r) > sectorSize {
+			buf.Write(make([]byte, sectorSize-used))
+		}
+		buf.Write(r)
+	}
+	if rem := buf.Len() % sectorSize; rem != 0 {
+		buf.Write(make([]byte, sectorSize-rem))
+	}
+	return buf.Bytes()
+}
+
+// rockRidge returns the Rock Ridge entries of n. name is omitted if
+// empty.
+func (w *writer) rockRidge(n *node, name string) []byte {
+	var b bytes.Buffer
+
+	px := make([]byte, 36)
+	copy(px, "PX\x24\x01")
+	both32(px[4:], int(n.file.Mode))
+	both32(px[12:], 1)
+	both32(px[20:], n.file.UID)
+	both32(px[28:], n.file.GID)
+	b.Write(px)
+
+	if name != "" {
+		b.Write([]byte{'N', 'M', byte(5 + len(name)), 1, 0})
+		b.WriteString(name)
+	}
+
+	if n.file.Linkname != "" {
+		var comps []byte
+		for i, part := range strings.Split(n.file.Linkname, "/") {
+			switch {
+			case part == "" && i == 0:
+				comps = append(comps, 0x08, 0)
+			case part == "":
+			case part == ".":
+				comps = append(comps, 0x02, 0)
+			case part == "..":
+				comps = append(comps, 0x04, 0)
+			default:
+				comps = append(comps, 0, byte(len(part)))
+				comps = append(comps, part...)
+			}
+		}
+		b.Write([]byte{'S', 'L', byte(5 + len(comps)), 1, 0})
+		b.Write(comps)
+	}
+
+	if !n.file.ModTime.IsZero() {
+		b.Write([]byte{'T', 'F', 12, 1, 0x02})
+		b.Write(recordTime(n.file.ModTime))
+	}
+
+	return b.Bytes()
+}
+
+// er returns the extensions reference entry for Rock Ridge.
+func er() []byte {
+	id := "RRIP_1991A"
+	e := []byte{'E', 'R', byte(8 + len(id)), 1, byte(len(id)), 0, 0, 1}
+	return append(e, id...)
+}
+
+// record returns a directory record.
+func record(lba, size int, modTime time.Time, flags byte, name, su []byte) []byte {
+	n := 33 + len(name)
+	if len(name)%2 == 0 {
+		n++
+	}
+
+	r := make([]byte, n, n+len(su)+1)
+	both32(r[2:], lba)
+	both32(r[10:], size)
+	copy(r[18:], recordTime(modTime))
+	r[25] = flags
+	both16(r[28:], 1)
+	r[32] = byte(len(name))
+	copy(r[33:], name)
+	r = append(r, su...)
+	if len(r)%2 != 0 {
+		r = append(r, 0)
+	}
+	r[0] = byte(len(r))
+	return r
+}
+
+// recordTime returns t in the 7 byte format used by directory records.
+func recordTime(t time.Time) []byte {
+	if t.IsZero() {
+		return make([]byte, 7)
+	}
+
+	t = t.UTC()
+	return []byte{
+		byte(t.Year() - 1900), byte(t.Month()), byte(t.Day()),
+		byte(t.Hour()), byte(t.Minute()), byte(t.Second()), 0,
+	}
+}
+
+// both16 writes v in both byte orders.
+func both16(b []byte, v int) {
+	binary.LittleEndian.PutUint16(b, uint16(v))  //nolint:gosec // Why: Test helper.
+	binary.BigEndian.PutUint16(b[2:], uint16(v)) //nolint:gosec // Why: Test helper.
+}
+
+// both32 writes v in both byte orders.
+func both32(b []byte, v int) {
+	binary.LittleEndian.PutUint32(b, uint32(v))  //nolint:gosec // Why: Test helper.
+	binary.BigEndian.PutUint32(b[4:], uint32(v)) //nolint:gosec // Why: Test helper.
+}
@@ -0,0 +1,56 @@
+package lzip_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2/internal/lzip"
+	"gotest.tools/v3/assert"
+)
+
+func compress(t *testing.T, data string) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	w, err := lzip.NewWriter(buf)
+	assert.NilError(t, err)
+	_, err = io.WriteString(w, data)
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := strings.Repeat("hello world\n", 1000)
+
+	r, err := lzip.NewReader(bytes.NewReader(compress(t, data)))
+	assert.NilError(t, err)
+
+	got, err := io.ReadAll(r)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), data)
+}
+
+func TestMultiMember(t *testing.T) {
+	b := append(compress(t, "hello "), compress(t, "world")...)
+
+	r, err := lzip.NewReader(bytes.NewReader(b))
+	assert.NilError(t, err)
+
+	got, err := io.ReadAll(r)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello world")
+}
+
+func TestDetectsCorruptTrailer(t *testing.T) {
+	b := compress(t, "hello world")
+	b[len(b)-20] ^= 0xff // CRC32
+
+	r, err := lzip.NewReader(bytes.NewReader(b))
+	assert.NilError(t, err)
+
+	_, err = io.ReadAll(r)
+	assert.ErrorContains(t, err, "crc mismatch")
+}
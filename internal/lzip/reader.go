@@ -0,0 +1,237 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package lzip implements the lzip (.lz) file format on top of the
+// LZMA implementation provided by github.com/ulikunitz/xz/lzma.
+//
+// An lzip file is one or more members, each of which consists of a six
+// byte header, a raw LZMA stream terminated by an end of stream marker
+// and a twenty byte trailer.
+package lzip
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Contains constants for the lzip format.
+const (
+	magic      = "LZIP"
+	version    = 1
+	headerLen  = 6
+	trailerLen = 20
+
+	// lzmaProperties are the fixed LZMA properties used by lzip (lc=3,
+	// lp=0, pb=2).
+	lzmaProperties = 0x5d
+
+	minDictSize = 1 << 12
+	maxDictSize = 1 << 29
+)
+
+// ErrCorrupt is returned when the stream is not valid.
+var ErrCorrupt = errors.New("lzip: corrupt stream")
+
+// reader implements [io.Reader] for lzip streams.
+type reader struct {
+	r *countingReader
+
+	// lz is the reader for the current member.
+	lz io.Reader
+
+	crc      hash.Hash32
+	dataSize uint64
+}
+
+// NewReader returns a new [io.Reader] that decompresses the lzip stream
+// read from r. Multi-member files are supported.
+func NewReader(r io.Reader) (io.Reader, error) {
+	z := &reader{r: &countingReader{r: bufio.NewReader(r)}, crc: crc32.NewIEEE()}
+	if err := z.nextMember(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("lzip: %w", io.ErrUnexpectedEOF)
+		}
+		return nil, err
+	}
+
+	return z, nil
+}
+
+// nextMember reads the header of the next member and prepares a reader
+// for it. io.EOF is returned if there are no more members.
+func (z *reader) nextMember() error {
+	if _, err := z.r.r.Peek(1); err != nil {
+		return err
+	}
+
+	z.r.n = 0
+	var hdr [headerLen]byte
+	if _, err := io.ReadFull(z.r, hdr[:]); err != nil {
+		return fmt.Errorf("lzip: failed to read header: %w", err)
+	}
+
+	if string(hdr[:4]) != magic {
+		return fmt.Errorf("%w: invalid magic", ErrCorrupt)
+	}
+
+	if hdr[4] != version {
+		return fmt.Errorf("lzip: unsupported version %d", hdr[4])
+	}
+
+	dictSize := decodeDictSize(hdr[5])
+	if dictSize < minDictSize || dictSize > maxDictSize {
+		return fmt.Errorf("%w: invalid dictionary size %d", ErrCorrupt, dictSize)
+	}
+
+	// Convert the member into a classic .lzma header with an unknown size
+	// so that it can be decoded by the lzma package.
+	var lzmaHdr [lzma.HeaderLen]byte
+	lzmaHdr[0] = lzmaProperties
+	binary.LittleEndian.PutUint32(lzmaHdr[1:5], dictSize)
+	binary.LittleEndian.PutUint64(lzmaHdr[5:], ^uint64(0))
+
+	lz, err := lzma.NewReader(&prefixedReader{lzmaHdr[:], z.r})
+	if err != nil {
+		return fmt.Errorf("lzip: failed to create lzma reader: %w", err)
+	}
+
+	z.lz = lz
+	z.crc.Reset()
+	z.dataSize = 0
+	return nil
+}
+
+// decodeDictSize decodes the coded dictionary size in an lzip header.
+// The low five bits are the base two logarithm of the base size and
+// the high three bits are the number of sixteenths of the base size to
+// subtract from it.
+func decodeDictSize(b byte) uint32 {
+	base := uint32(1) << (b & 0x1f)
+	return base - (base/16)*uint32(b>>5)
+}
+
+// verifyTrailer reads the trailer of the current member and compares
+// it with the data that was read.
+func (z *reader) verifyTrailer() error {
+	var trailer [trailerLen]byte
+	if _, err := io.ReadFull(z.r, trailer[:]); err != nil {
+		return fmt.Errorf("lzip: failed to read trailer: %w", err)
+	}
+
+	if got := binary.LittleEndian.Uint32(trailer[0:4]); got != z.crc.Sum32() {
+		return fmt.Errorf("%w: crc mismatch (%08x != %08x)", ErrCorrupt, got, z.crc.Sum32())
+	}
+
+	if got := binary.LittleEndian.Uint64(trailer[4:12]); got != z.dataSize {
+		return fmt.Errorf("%w: data size mismatch (%d != %d)", ErrCorrupt, got, z.dataSize)
+	}
+
+	if got := binary.LittleEndian.Uint64(trailer[12:20]); got != z.r.n {
+		return fmt.Errorf("%w: member size mismatch (%d != %d)", ErrCorrupt, got, z.r.n)
+	}
+
+	return nil
+}
+
+// Read implements [io.Reader].
+func (z *reader) Read(p []byte) (int, error) {
+	for {
+		if z.lz == nil {
+			return 0, io.EOF
+		}
+
+		n, err := z.lz.Read(p)
+		z.crc.Write(p[:n]) //nolint:errcheck // Why: hash.Hash never returns an error.
+		z.dataSize += uint64(n)
+		if !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		if err := z.verifyTrailer(); err != nil {
+			return n, err
+		}
+
+		z.lz = nil
+		if err := z.nextMember(); err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// countingReader counts the number of bytes read through it.
+type countingReader struct {
+	r *bufio.Reader
+	n uint64
+}
+
+// Read implements [io.Reader].
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += uint64(n) //nolint:gosec // Why: n is never negative.
+	return n, err
+}
+
+// ReadByte implements [io.ByteReader].
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// prefixedReader returns prefix before reading from r. It implements
+// [io.ByteReader] so that the lzma package reads from it directly
+// instead of wrapping it in a (slow) single byte reader.
+type prefixedReader struct {
+	prefix []byte
+	r      *countingReader
+}
+
+// Read implements [io.Reader].
+func (p *prefixedReader) Read(b []byte) (int, error) {
+	if len(p.prefix) > 0 {
+		n := copy(b, p.prefix)
+		p.prefix = p.prefix[n:]
+		return n, nil
+	}
+
+	return p.r.Read(b)
+}
+
+// ReadByte implements [io.ByteReader].
+func (p *prefixedReader) ReadByte() (byte, error) {
+	if len(p.prefix) > 0 {
+		b := p.prefix[0]
+		p.prefix = p.prefix[1:]
+		return b, nil
+	}
+
+	return p.r.ReadByte()
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package lzip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// writerDictSizeCode is the coded dictionary size used by the writer
+// (8 MiB).
+const writerDictSizeCode = 23
+
+// writer implements [io.WriteCloser] for single member lzip streams.
+type writer struct {
+	w  *countingWriter
+	lz *lzma.Writer
+
+	crc      hash.Hash32
+	dataSize uint64
+}
+
+// NewWriter returns a new [io.WriteCloser] that compresses data written
+// to it into a single lzip member. Close must be called to write the
+// trailer.
+func NewWriter(w io.Writer) (io.WriteCloser, error) {
+	cw := &countingWriter{w: w}
+	if _, err := cw.Write([]byte{'L', 'Z', 'I', 'P', version, writerDictSizeCode}); err != nil {
+		return nil, err
+	}
+
+	lz, err := lzma.WriterConfig{
+		DictCap:   1 << writerDictSizeCode,
+		EOSMarker: true,
+	}.NewWriter(&skipWriter{w: cw, skip: lzma.HeaderLen})
+	if err != nil {
+		return nil, fmt.Errorf("lzip: failed to create lzma writer: %w", err)
+	}
+
+	return &writer{w: cw, lz: lz, crc: crc32.NewIEEE()}, nil
+}
+
+// Write implements [io.Writer].
+func (z *writer) Write(p []byte) (int, error) {
+	n, err := z.lz.Write(p)
+	z.crc.Write(p[:n])      //nolint:errcheck // Why: hash.Hash never returns an error.
+	z.dataSize += uint64(n) //nolint:gosec // Why: n is never negative.
+	return n, err
+}
+
+// Close implements [io.Closer].
+func (z *writer) Close() error {
+	if err := z.lz.Close(); err != nil {
+		return err
+	}
+
+	var trailer [trailerLen]byte
+	binary.LittleEndian.PutUint32(trailer[0:4], z.crc.Sum32())
+	binary.LittleEndian.PutUint64(trailer[4:12], z.dataSize)
+	binary.LittleEndian.PutUint64(trailer[12:20], z.w.n+trailerLen)
+	_, err := z.w.Write(trailer[:])
+	return err
+}
+
+// countingWriter counts the number of bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+// Write implements [io.Writer].
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n) //nolint:gosec // Why: n is never negative.
+	return n, err
+}
+
+// skipWriter discards the first skip bytes written to it. It is used to
+// strip the classic .lzma header written by the lzma package.
+type skipWriter struct {
+	w    io.Writer
+	skip int
+}
+
+// Write implements [io.Writer].
+func (s *skipWriter) Write(p []byte) (int, error) {
+	n := min(s.skip, len(p))
+	s.skip -= n
+
+	if _, err := s.w.Write(p[n:]); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,377 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package rpmtest contains a minimal RPM package writer for creating
+// packages for usage in tests in the archives package.
+package rpmtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"go.rgst.io/jaredallard/archives/v2/internal/cpiotest"
+)
+
+// Package contains the metadata of the package to create.
+type Package struct {
+	Name     string
+	Version  string
+	Release  string
+	Epoch    int
+	Arch     string
+	Summary  string
+	License  string
+	Provides []string
+	Requires []string
+}
+
+// File is a file in the package.
+type File struct {
+	// Name is the absolute path of the file, e.g. /usr/bin/tool.
+	Name string
+
+	// Contents are the contents of the file.
+	Contents []byte
+
+	// Mode is the Unix mode of the file, including the file type bits
+	// (e.g., 0o100755).
+	Mode uint32
+
+	// Linkname is the target of the file if it is a symlink.
+	Linkname string
+
+	User    string
+	Group   string
+	ModTime time.Time
+}
+
+// Options is a struct for configuring the created package.
+type Options struct {
+	Compressor string
+	Stripped   bool
+}
+
+// OptionFn modifies a [Options] struct.
+type OptionFn func(*Options)
+
+// WithCompressor sets the payload compressor, one of "gzip" (the
+// default), "xz", "zstd" or "identity". An empty compressor omits the
+// tag, like packages predating it, and compresses the payload with
+// gzip.
+func WithCompressor(c string) OptionFn {
+	return func(o *Options) {
+		o.Compressor = c
+	}
+}
+
+// WithStripped writes the payload in the stripped cpio format used for
+// packages containing large files.
+func WithStripped() OptionFn {
+	return func(o *Options) {
+		o.Stripped = true
+	}
+}
+
+// Contains the tags written by Create.
+const (
+	tagName              = 1000
+	tagVersion           = 1001
+	tagRelease           = 1002
+	tagEpoch             = 1003
+	tagSummary           = 1004
+	tagBuildTime         = 1006
+	tagSize              = 1009
+	tagLicense           = 1014
+	tagOS                = 1021
+	tagArch              = 1022
+	tagFileSizes         = 1028
+	tagFileModes         = 1030
+	tagFileMTimes        = 1034
+	tagFileLinkTos       = 1036
+	tagFileUserName      = 1039
+	tagFileGroupName     = 1040
+	tagProvideName       = 1047
+	tagRequireName       = 1049
+	tagDirIndexes        = 1116
+	tagBasenames         = 1117
+	tagDirNames          = 1118
+	tagPayloadFormat     = 1124
+	tagPayloadCompressor = 1125
+
+	sigTagSize = 1000
+)
+
+// Create creates a new RPM package containing the provided files.
+func Create(pkg Package, files []File, options ...OptionFn) ([]byte, error) {
+	opts := &Options{Compressor: "gzip"}
+	for _, o := range options {
+		o(opts)
+	}
+
+	payload, err := createPayload(files, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &header{}
+	h.addString(tagName, pkg.Name)
+	h.addString(tagVersion, pkg.Version)
+	h.addString(tagRelease, pkg.Release)
+	if pkg.Epoch != 0 {
+		h.addInt32s(tagEpoch, []int32{int32(pkg.Epoch)}) //nolint:gosec // Why: Test helper.
+	}
+	h.addI18NString(tagSummary, pkg.Summary)
+	h.addInt32s(tagBuildTime, []int32{1700000000})
+	h.addString(tagLicense, pkg.License)
+	h.addString(tagOS, "linux")
+	h.addString(tagArch, pkg.Arch)
+	h.addStringArray(tagProvideName, pkg.Provides)
+	h.addStringArray(tagRequireName, pkg.Requires)
+	h.addString(tagPayloadFormat, "cpio")
+	if opts.Compressor != "" {
+		h.addString(tagPayloadCompressor, opts.Compressor)
+	}
+
+	var size int32
+	var sizes, mtimes, dirIndexes []int32
+	var modes []int16
+	var links, users, groups, basenames, dirnames []string
+	dirs := make(map[string]int)
+	for _, f := range files {
+		size += int32(len(f.Contents)) //nolint:gosec // Why: Test helper.
+		sizes = append(sizes, int32(len(f.Contents)))
+		modes = append(modes, int16(f.Mode)) //nolint:gosec // Why: Modes are 16 bits.
+		var mtime int32
+		if !f.ModTime.IsZero() {
+			mtime = int32(f.ModTime.Unix()) //nolint:gosec // Why: Test helper.
+		}
+		mtimes = append(mtimes, mtime)
+		links = append(links, f.Linkname)
+		users = append(users, f.User)
+		groups = append(groups, f.Group)
+
+		dir, base := path.Split(f.Name)
+		if _, ok := dirs[dir]; !ok {
+			dirs[dir] = len(dirnames)
+			dirnames = append(dirnames, dir)
+		}
+		dirIndexes = append(dirIndexes, int32(dirs[dir])) //nolint:gosec // Why: Test helper.
+		basenames = append(basenames, base)
+	}
+
+	h.addInt32s(tagSize, []int32{size})
+	if len(files) > 0 {
+		h.addInt32s(tagFileSizes, sizes)
+		h.addInt16s(tagFileModes, modes)
+		h.addInt32s(tagFileMTimes, mtimes)
+		h.addStringArray(tagFileLinkTos, links)
+		h.addStringArray(tagFileUserName, users)
+		h.addStringArray(tagFileGroupName, groups)
+		h.addInt32s(tagDirIndexes, dirIndexes)
+		h.addStringArray(tagBasenames, basenames)
+		h.addStringArray(tagDirNames, dirnames)
+	}
+	hdr := h.bytes()
+
+	sig := &header{}
+	sig.addInt32s(sigTagSize, []int32{int32(len(hdr) + len(payload))}) //nolint:gosec // Why: Test helper.
+	sigBytes := sig.bytes()
+
+	buf := new(bytes.Buffer)
+	buf.Write(lead(pkg))
+	buf.Write(sigBytes)
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(hdr)
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// lead returns the lead of a binary package.
+func lead(pkg Package) []byte {
+	b := make([]byte, 96)
+	copy(b, "\xed\xab\xee\xdb")
+	b[4] = 3                             // major
+	binary.BigEndian.PutUint16(b[8:], 1) // archnum
+	copy(b[10:75], pkg.Name+"-"+pkg.Version+"-"+pkg.Release)
+	binary.BigEndian.PutUint16(b[76:], 1) // osnum
+	binary.BigEndian.PutUint16(b[78:], 5) // signature type
+	return b
+}
+
+// createPayload creates the compressed cpio payload.
+func createPayload(files []File, opts *Options) ([]byte, error) {
+	var archive []byte
+	var err error
+	if opts.Stripped {
+		entries := make([]cpiotest.Stripped, 0, len(files))
+		for i, f := range files {
+			contents := f.Contents
+			if f.Linkname != "" {
+				contents = []byte(f.Linkname)
+			}
+			entries = append(entries, cpiotest.Stripped{Index: i, Contents: contents})
+		}
+		archive, err = cpiotest.CreateStripped(entries)
+	} else {
+		entries := make([]cpiotest.File, 0, len(files))
+		for _, f := range files {
+			contents := f.Contents
+			if f.Linkname != "" {
+				contents = []byte(f.Linkname)
+			}
+			entries = append(entries, cpiotest.File{
+				Name:     "." + f.Name,
+				Contents: contents,
+				Mode:     f.Mode,
+				ModTime:  f.ModTime,
+			})
+		}
+		archive, err = cpiotest.Create(entries)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	var w io.WriteCloser
+	switch opts.Compressor {
+	case "gzip", "":
+		w = gzip.NewWriter(buf)
+	case "xz":
+		w, err = xz.NewWriter(buf)
+	case "zstd":
+		w, err = zstd.NewWriter(buf)
+	case "identity":
+		return archive, nil
+	default:
+		return nil, fmt.Errorf("unsupported compressor %q", opts.Compressor)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(archive); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Contains the tag types written by header.
+const (
+	typeInt16       = 3
+	typeInt32       = 4
+	typeString      = 6
+	typeStringArray = 8
+	typeI18NString  = 9
+)
+
+// header builds an RPM header structure.
+type header struct {
+	index []byte
+	store bytes.Buffer
+	n     int
+}
+
+// add adds a tag whose data is aligned to align bytes in the store.
+func (h *header) add(tag, typ uint32, count int, data []byte, align int) {
+	for h.store.Len()%align != 0 {
+		h.store.WriteByte(0)
+	}
+
+	e := make([]byte, 16)
+	binary.BigEndian.PutUint32(e[0:], tag)
+	binary.BigEndian.PutUint32(e[4:], typ)
+	binary.BigEndian.PutUint32(e[8:], uint32(h.store.Len())) //nolint:gosec // Why: Test helper.
+	binary.BigEndian.PutUint32(e[12:], uint32(count))        //nolint:gosec // Why: Test helper.
+	h.index = append(h.index, e...)
+	h.store.Write(data)
+	h.n++
+}
+
+func (h *header) addString(tag uint32, s string) {
+	h.add(tag, typeString, 1, append([]byte(s), 0), 1)
+}
+
+func (h *header) addI18NString(tag uint32, s string) {
+	h.add(tag, typeI18NString, 1, append([]byte(s), 0), 1)
+}
+
+func (h *header) addStringArray(tag uint32, ss []string) {
+	if len(ss) == 0 {
+		return
+	}
+
+	var data []byte
+	for _, s := range ss {
+		data = append(data, s...)
+		data = append(data, 0)
+	}
+	h.add(tag, typeStringArray, len(ss), data, 1)
+}
+
+func (h *header) addInt16s(tag uint32, v []int16) {
+	data := make([]byte, 2*len(v))
+	for i, x := range v {
+		binary.BigEndian.PutUint16(data[2*i:], uint16(x)) //nolint:gosec // Why: Bit pattern is kept.
+	}
+	h.add(tag, typeInt16, len(v), data, 2)
+}
+
+func (h *header) addInt32s(tag uint32, v []int32) {
+	data := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.BigEndian.PutUint32(data[4*i:], uint32(x)) //nolint:gosec // Why: Bit pattern is kept.
+	}
+	h.add(tag, typeInt32, len(v), data, 4)
+}
+
+// bytes returns the encoded header structure.
+func (h *header) bytes() []byte {
+	// Index entries are sorted by tag.
+	entries := make([][]byte, 0, h.n)
+	for i := 0; i < h.n; i++ {
+		entries = append(entries, h.index[i*16:(i+1)*16])
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return binary.BigEndian.Uint32(entries[i]) < binary.BigEndian.Uint32(entries[j])
+	})
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("\x8e\xad\xe8\x01\x00\x00\x00\x00")
+	binary.Write(buf, binary.BigEndian, uint32(h.n))           //nolint:errcheck,gosec // Why: Test helper.
+	binary.Write(buf, binary.BigEndian, uint32(h.store.Len())) //nolint:errcheck,gosec // Why: Test helper.
+	for _, e := range entries {
+		buf.Write(e)
+	}
+	buf.Write(h.store.Bytes())
+	return buf.Bytes()
+}
@@ -0,0 +1,465 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package sevenziptest contains a minimal 7z writer for creating
+// archives for usage in tests in the archives package.
+package sevenziptest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+	"go.rgst.io/jaredallard/archives/v2/internal/bcj"
+)
+
+// Method is the compression method used for file contents.
+type Method int
+
+const (
+	// MethodLZMA2 compresses files with LZMA2. This is the default.
+	MethodLZMA2 Method = iota
+	// MethodLZMA compresses files with LZMA.
+	MethodLZMA
+	// MethodCopy stores files without compression.
+	MethodCopy
+)
+
+// dictSize is the dictionary size used by the LZMA and LZMA2 coders,
+// with lzma2DictProp being its encoded form for LZMA2.
+const (
+	dictSize      = 1 << 20
+	lzma2DictProp = 16
+)
+
+// File is a file or directory to add to an archive.
+type File struct {
+	// Name is the name of the file. Directories should not have a
+	// trailing slash.
+	Name string
+
+	// Contents are the contents of the file.
+	Contents []byte
+
+	// Dir denotes that this entry is a directory.
+	Dir bool
+
+	// Attributes are the Windows attributes of the file. If zero, no
+	// attributes are written.
+	Attributes uint32
+
+	// ModTime is the modification time of the file. If zero, no
+	// modification time is written.
+	ModTime time.Time
+}
+
+// Options is a struct for configuring the created archive.
+type Options struct {
+	Method        Method
+	BCJ           bool
+	Solid         bool
+	EncodedHeader bool
+}
+
+// OptionFn modifies a [Options] struct.
+type OptionFn func(*Options)
+
+// WithMethod sets the compression method used for file contents.
+func WithMethod(m Method) OptionFn {
+	return func(o *Options) {
+		o.Method = m
+	}
+}
+
+// WithBCJ applies the x86 BCJ filter before compressing file contents.
+func WithBCJ() OptionFn {
+	return func(o *Options) {
+		o.BCJ = true
+	}
+}
+
+// WithSolid stores all files in a single solid block.
+func WithSolid() OptionFn {
+	return func(o *Options) {
+		o.Solid = true
+	}
+}
+
+// WithEncodedHeader compresses the archive header with LZMA, like
+// 7-Zip does by default.
+func WithEncodedHeader() OptionFn {
+	return func(o *Options) {
+		o.EncodedHeader = true
+	}
+}
+
+// folder is a folder (compressed block) that has been encoded.
+type folder struct {
+	coders      []byte
+	unpackSizes []uint64
+	crc         uint32
+	packed      []byte
+
+	// sizes and crcs of the files in the folder.
+	sizes []uint64
+	crcs  []uint32
+}
+
+// Create creates a new 7z archive containing the provided files.
+func Create(files []File, options ...OptionFn) ([]byte, error) {
+	opts := &Options{}
+	for _, o := range options {
+		o(opts)
+	}
+
+	// Group files with contents into folders.
+	var groups [][]File
+	for _, f := range files {
+		if f.Dir || len(f.Contents) == 0 {
+			continue
+		}
+
+		if opts.Solid && len(groups) > 0 {
+			groups[0] = append(groups[0], f)
+		} else {
+			groups = append(groups, []File{f})
+		}
+	}
+
+	var folders []*folder
+	for _, g := range groups {
+		var data []byte
+		fo := &folder{}
+		for _, f := range g {
+			data = append(data, f.Contents...)
+			fo.sizes = append(fo.sizes, uint64(len(f.Contents)))
+			fo.crcs = append(fo.crcs, crc32.ChecksumIEEE(f.Contents))
+		}
+
+		if err := encodeFolder(fo, data, opts.Method, opts.BCJ); err != nil {
+			return nil, err
+		}
+		folders = append(folders, fo)
+	}
+
+	var packed []byte
+	for _, fo := range folders {
+		packed = append(packed, fo.packed...)
+	}
+
+	hdr := new(bytes.Buffer)
+	hdr.WriteByte(0x01) // Header
+	if len(folders) > 0 {
+		hdr.WriteByte(0x04) // MainStreamsInfo
+		writeStreamsInfo(hdr, 0, folders, true)
+	}
+	writeFilesInfo(hdr, files)
+	hdr.WriteByte(0x00) // End
+
+	next := hdr.Bytes()
+	if opts.EncodedHeader {
+		fo := &folder{}
+		if err := encodeFolder(fo, next, MethodLZMA, false); err != nil {
+			return nil, err
+		}
+
+		enc := new(bytes.Buffer)
+		enc.WriteByte(0x17) // EncodedHeader
+		writeStreamsInfo(enc, uint64(len(packed)), []*folder{fo}, false)
+		packed = append(packed, fo.packed...)
+		next = enc.Bytes()
+	}
+
+	return Raw(packed, next), nil
+}
+
+// Raw creates a 7z archive from packed streams and an encoded next
+// header, e.g. to create malformed archives.
+func Raw(packed, next []byte) []byte {
+	var sig [32]byte
+	copy(sig[:], "7z\xbc\xaf\x27\x1c")
+	sig[7] = 4
+	binary.LittleEndian.PutUint64(sig[12:], uint64(len(packed)))
+	binary.LittleEndian.PutUint64(sig[20:], uint64(len(next)))
+	binary.LittleEndian.PutUint32(sig[28:], crc32.ChecksumIEEE(next))
+	binary.LittleEndian.PutUint32(sig[8:], crc32.ChecksumIEEE(sig[12:]))
+
+	out := append(sig[:], packed...)
+	return append(out, next...)
+}
+
+// encodeFolder compresses data into fo.
+func encodeFolder(fo *folder, data []byte, method Method, useBCJ bool) error {
+	fo.crc = crc32.ChecksumIEEE(data)
+
+	coders := new(bytes.Buffer)
+	numCoders := 1
+	if useBCJ {
+		numCoders = 2
+	}
+	writeNumber(coders, uint64(numCoders))
+
+	if useBCJ {
+		data = bytes.Clone(data)
+		bcj.NewX86().Convert(data, 0, true)
+	}
+
+	buf := new(bytes.Buffer)
+	switch method {
+	case MethodCopy:
+		coders.Write([]byte{0x01, 0x00})
+		buf.Write(data)
+	case MethodLZMA:
+		w, err := lzma.WriterConfig{DictCap: dictSize, Size: int64(len(data))}.NewWriter(buf)
+		if err != nil {
+			return fmt.Errorf("failed to create lzma writer: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		// The properties are the first five bytes of the classic header,
+		// the size is stored in the folder instead.
+		hdr := buf.Next(lzma.HeaderLen)
+		coders.Write([]byte{0x23, 0x03, 0x01, 0x01, 0x05})
+		coders.Write(hdr[:5])
+	case MethodLZMA2:
+		w, err := lzma.Writer2Config{DictCap: dictSize}.NewWriter2(buf)
+		if err != nil {
+			return fmt.Errorf("failed to create lzma2 writer: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		coders.Write([]byte{0x21, 0x21, 0x01, lzma2DictProp})
+	}
+	fo.unpackSizes = append(fo.unpackSizes, uint64(len(data)))
+
+	if useBCJ {
+		// Like 7-Zip, the filter is the last coder. Its input (1) is bound
+		// to the output of the compression coder (0).
+		coders.Write([]byte{0x04, 0x03, 0x03, 0x01, 0x03})
+		fo.unpackSizes = append(fo.unpackSizes, uint64(len(data)))
+		writeNumber(coders, 1)
+		writeNumber(coders, 0)
+	}
+
+	fo.coders = coders.Bytes()
+	fo.packed = bytes.Clone(buf.Bytes())
+	return nil
+}
+
+// writeStreamsInfo writes a StreamsInfo structure for folders.
+func writeStreamsInfo(w *bytes.Buffer, packPos uint64, folders []*folder, subStreams bool) {
+	w.WriteByte(0x06) // PackInfo
+	writeNumber(w, packPos)
+	writeNumber(w, uint64(len(folders)))
+	w.WriteByte(0x09) // Size
+	for _, fo := range folders {
+		writeNumber(w, uint64(len(fo.packed)))
+	}
+	w.WriteByte(0x00) // End
+
+	w.WriteByte(0x07) // UnpackInfo
+	w.WriteByte(0x0b) // Folder
+	writeNumber(w, uint64(len(folders)))
+	w.WriteByte(0x00) // External
+	for _, fo := range folders {
+		w.Write(fo.coders)
+	}
+	w.WriteByte(0x0c) // CodersUnpackSize
+	for _, fo := range folders {
+		for _, s := range fo.unpackSizes {
+			writeNumber(w, s)
+		}
+	}
+	w.WriteByte(0x0a) // CRC
+	w.WriteByte(0x01) // AllAreDefined
+	for _, fo := range folders {
+		writeUint32(w, fo.crc)
+	}
+	w.WriteByte(0x00) // End
+
+	if subStreams {
+		w.WriteByte(0x08) // SubStreamsInfo
+		w.WriteByte(0x0d) // NumUnpackStream
+		for _, fo := range folders {
+			writeNumber(w, uint64(len(fo.sizes)))
+		}
+
+		w.WriteByte(0x09) // Size
+		for _, fo := range folders {
+			for _, s := range fo.sizes[:len(fo.sizes)-1] {
+				writeNumber(w, s)
+			}
+		}
+
+		// Files in folders with a single file use the folder CRC.
+		var crcs []uint32
+		for _, fo := range folders {
+			if len(fo.sizes) != 1 {
+				crcs = append(crcs, fo.crcs...)
+			}
+		}
+		if len(crcs) > 0 {
+			w.WriteByte(0x0a) // CRC
+			w.WriteByte(0x01) // AllAreDefined
+			for _, crc := range crcs {
+				writeUint32(w, crc)
+			}
+		}
+		w.WriteByte(0x00) // End
+	}
+
+	w.WriteByte(0x00) // End
+}
+
+// writeFilesInfo writes a FilesInfo structure for files.
+func writeFilesInfo(w *bytes.Buffer, files []File) {
+	w.WriteByte(0x05) // FilesInfo
+	writeNumber(w, uint64(len(files)))
+
+	var emptyStream, emptyFile []bool
+	for _, f := range files {
+		empty := f.Dir || len(f.Contents) == 0
+		emptyStream = append(emptyStream, empty)
+		if empty {
+			emptyFile = append(emptyFile, !f.Dir)
+		}
+	}
+
+	if len(emptyFile) > 0 {
+		writeProperty(w, 0x0e, bitVector(emptyStream)) // EmptyStream
+		writeProperty(w, 0x0f, bitVector(emptyFile))   // EmptyFile
+	}
+
+	names := new(bytes.Buffer)
+	names.WriteByte(0x00) // External
+	for _, f := range files {
+		for _, c := range utf16.Encode([]rune(f.Name)) {
+			binary.Write(names, binary.LittleEndian, c) //nolint:errcheck // Why: bytes.Buffer never fails.
+		}
+		names.Write([]byte{0, 0})
+	}
+	writeProperty(w, 0x11, names.Bytes()) // Name
+
+	var mtimes, attributes []bool
+	times, attrs := new(bytes.Buffer), new(bytes.Buffer)
+	times.WriteByte(0x00) // External
+	attrs.WriteByte(0x00) // External
+	for _, f := range files {
+		mtimes = append(mtimes, !f.ModTime.IsZero())
+		if !f.ModTime.IsZero() {
+			// FILETIME is in 100ns intervals since 1601-01-01.
+			binary.Write(times, binary.LittleEndian, uint64(f.ModTime.UnixNano()/100+116444736000000000)) //nolint:errcheck,gosec // Why: Test helper.
+		}
+
+		attributes = append(attributes, f.Attributes != 0)
+		if f.Attributes != 0 {
+			writeUint32(attrs, f.Attributes)
+		}
+	}
+	writeProperty(w, 0x14, append(definedVector(mtimes), times.Bytes()...))     // MTime
+	writeProperty(w, 0x15, append(definedVector(attributes), attrs.Bytes()...)) // WinAttributes
+
+	w.WriteByte(0x00) // End
+}
+
+// writeProperty writes a property with its size.
+func writeProperty(w *bytes.Buffer, id byte, data []byte) {
+	w.WriteByte(id)
+	writeNumber(w, uint64(len(data)))
+	w.Write(data)
+}
+
+// bitVector encodes v as a bit vector, most significant bit first.
+func bitVector(v []bool) []byte {
+	b := make([]byte, (len(v)+7)/8)
+	for i, set := range v {
+		if set {
+			b[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return b
+}
+
+// definedVector encodes v as a bit vector prefixed with an "all
+// defined" byte.
+func definedVector(v []bool) []byte {
+	for _, set := range v {
+		if !set {
+			return append([]byte{0x00}, bitVector(v)...)
+		}
+	}
+	return []byte{0x01}
+}
+
+// writeNumber writes a 7z variable length number.
+func writeNumber(w io.ByteWriter, v uint64) {
+	// Find the number of additional bytes required.
+	n := 0
+	for n < 8 && v >= uint64(1)<<(7*(n+1)) {
+		n++
+	}
+
+	var first byte
+	for i := 0; i < n; i++ {
+		first |= 0x80 >> i
+	}
+
+	if n < 8 {
+		first |= byte(v >> (8 * n))
+	}
+	w.WriteByte(first) //nolint:errcheck // Why: bytes.Buffer never fails.
+
+	for i := 0; i < n; i++ {
+		w.WriteByte(byte(v >> (8 * i))) //nolint:errcheck // Why: bytes.Buffer never fails.
+	}
+}
+
+// writeUint32 writes a little endian uint32.
+func writeUint32(w io.Writer, v uint32) {
+	binary.Write(w, binary.LittleEndian, v) //nolint:errcheck // Why: bytes.Buffer never fails.
+}
+
+// UnixAttributes returns Windows attributes that carry the provided Unix
+// mode, like p7zip and 7-Zip on Unix systems write.
+func UnixAttributes(mode os.FileMode) uint32 {
+	attrib := uint32(0x8000) | uint32(mode.Perm())<<16
+	switch {
+	case mode.IsDir():
+		attrib |= 0x10 | 0o040000<<16
+	case mode&os.ModeSymlink != 0:
+		attrib |= 0o120000 << 16
+	default:
+		attrib |= 0o100000 << 16
+	}
+	return attrib
+}
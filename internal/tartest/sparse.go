@@ -0,0 +1,251 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package tartest
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SparseFormat is the format used to store a file in an archive created
+// by [CreateSparse]. archive/tar can read, but not write, sparse files.
+type SparseFormat int
+
+const (
+	// SparseNone stores the file as a regular file. This is the default.
+	SparseNone SparseFormat = iota
+	// SparseGNU stores the file using the old GNU sparse format, with the
+	// sparse map in the header and extension headers.
+	SparseGNU
+	// SparsePAX01 stores the file using the GNU PAX sparse format 0.1,
+	// with the sparse map in the PAX records.
+	SparsePAX01
+	// SparsePAX10 stores the file using the GNU PAX sparse format 1.0,
+	// with the sparse map before the contents.
+	SparsePAX10
+)
+
+// Region is a region of a sparse file containing data.
+type Region struct {
+	Offset int64
+	Data   []byte
+}
+
+// SparseFile is a file to add to an archive created by [CreateSparse].
+// The parts of the file not covered by Regions are holes.
+type SparseFile struct {
+	Name    string
+	Size    int64
+	Regions []Region
+	Format  SparseFormat
+}
+
+// Contents returns the contents of the file, including its holes.
+func (f *SparseFile) Contents() []byte {
+	b := make([]byte, f.Size)
+	for _, r := range f.Regions {
+		copy(b[r.Offset:], r.Data)
+	}
+	return b
+}
+
+// CreateSparse creates a new uncompressed tar archive containing the
+// provided files.
+func CreateSparse(files ...SparseFile) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for i := range files {
+		f := &files[i]
+
+		var data []byte
+		for _, r := range f.Regions {
+			data = append(data, r.Data...)
+		}
+
+		var err error
+		switch f.Format {
+		case SparseNone:
+			data = f.Contents()
+			err = writeTarHeader(buf, f.Name, tar.TypeReg, int64(len(data)), nil)
+		case SparseGNU:
+			err = writeGNUSparseHeader(buf, f, int64(len(data)))
+		case SparsePAX01:
+			sparseMap := make([]string, 0, 2*len(f.Regions))
+			for _, r := range f.Regions {
+				sparseMap = append(sparseMap, strconv.FormatInt(r.Offset, 10), strconv.Itoa(len(r.Data)))
+			}
+			err = writePAXHeader(buf, f.Name, [][2]string{
+				{"GNU.sparse.major", "0"},
+				{"GNU.sparse.minor", "1"},
+				{"GNU.sparse.map", strings.Join(sparseMap, ",")},
+				{"GNU.sparse.numblocks", strconv.Itoa(len(f.Regions))},
+				{"GNU.sparse.size", strconv.FormatInt(f.Size, 10)},
+			})
+			if err == nil {
+				err = writeTarHeader(buf, f.Name, tar.TypeReg, int64(len(data)), nil)
+			}
+		case SparsePAX10:
+			sparseMap := fmt.Sprintf("%d\n", len(f.Regions))
+			for _, r := range f.Regions {
+				sparseMap += fmt.Sprintf("%d\n%d\n", r.Offset, len(r.Data))
+			}
+			data = append(pad([]byte(sparseMap)), data...)
+
+			err = writePAXHeader(buf, f.Name, [][2]string{
+				{"GNU.sparse.major", "1"},
+				{"GNU.sparse.minor", "0"},
+				{"GNU.sparse.name", f.Name},
+				{"GNU.sparse.realsize", strconv.FormatInt(f.Size, 10)},
+			})
+			if err == nil {
+				dir, file := path.Split(f.Name)
+				err = writeTarHeader(buf, path.Join(dir, "GNUSparseFile.0", file), tar.TypeReg, int64(len(data)), nil)
+			}
+		default:
+			return nil, fmt.Errorf("unknown sparse format %d", f.Format)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(pad(data))
+	}
+
+	// End of archive marker.
+	buf.Write(make([]byte, 2*blockSize))
+	return buf.Bytes(), nil
+}
+
+// blockSize is the size of the blocks of tar archives.
+const blockSize = 512
+
+// pad pads b with zeros to a multiple of [blockSize].
+func pad(b []byte) []byte {
+	if n := len(b) % blockSize; n != 0 {
+		b = append(b, make([]byte, blockSize-n)...)
+	}
+	return b
+}
+
+// writeTarHeader writes a header block for a file. If fn isn't nil, it
+// is called to fill in additional fields before the checksum is
+// computed.
+func writeTarHeader(buf *bytes.Buffer, name string, typeflag byte, size int64, fn func(blk []byte)) error {
+	if len(name) > 100 {
+		return fmt.Errorf("name %q is too long", name)
+	}
+
+	blk := make([]byte, blockSize)
+	copy(blk[0:100], name)
+	formatOctal(blk[100:108], 0o644)
+	formatOctal(blk[108:116], 0)
+	formatOctal(blk[116:124], 0)
+	formatOctal(blk[124:136], size)
+	formatOctal(blk[136:148], 0)
+	blk[156] = typeflag
+	copy(blk[257:265], "ustar\x0000")
+	if fn != nil {
+		fn(blk)
+	}
+
+	copy(blk[148:156], "        ")
+	var sum int64
+	for _, c := range blk {
+		sum += int64(c)
+	}
+	copy(blk[148:156], fmt.Sprintf("%06o\x00 ", sum))
+
+	buf.Write(blk)
+	return nil
+}
+
+// writePAXHeader writes a PAX extended header containing the provided
+// records for the file with the provided name.
+func writePAXHeader(buf *bytes.Buffer, name string, records [][2]string) error {
+	var data []byte
+	for _, r := range records {
+		record := " " + r[0] + "=" + r[1] + "\n"
+		// The length includes the length field itself.
+		n := len(record) + 1
+		for len(strconv.Itoa(n))+len(record) != n {
+			n++
+		}
+		data = append(data, strconv.Itoa(n)+record...)
+	}
+
+	dir, file := path.Split(name)
+	if err := writeTarHeader(buf, path.Join(dir, "PaxHeaders.0", file), tar.TypeXHeader, int64(len(data)), nil); err != nil {
+		return err
+	}
+	buf.Write(pad(data))
+	return nil
+}
+
+// writeGNUSparseHeader writes the header and extension headers of a
+// file in the old GNU sparse format, storing size bytes of data.
+func writeGNUSparseHeader(buf *bytes.Buffer, f *SparseFile, size int64) error {
+	// The header contains 4 entries, extension headers 21.
+	entries, rest := f.Regions, []Region(nil)
+	if len(entries) > 4 {
+		entries, rest = entries[:4], entries[4:]
+	}
+
+	if err := writeTarHeader(buf, f.Name, tar.TypeGNUSparse, size, func(blk []byte) {
+		copy(blk[257:265], "ustar  \x00")
+		formatSparseEntries(blk[386:482], entries)
+		if len(rest) > 0 {
+			blk[482] = 1
+		}
+		formatOctal(blk[483:495], f.Size)
+	}); err != nil {
+		return err
+	}
+
+	for len(rest) > 0 {
+		entries, rest = rest, nil
+		if len(entries) > 21 {
+			entries, rest = entries[:21], entries[21:]
+		}
+
+		blk := make([]byte, blockSize)
+		formatSparseEntries(blk[:504], entries)
+		if len(rest) > 0 {
+			blk[504] = 1
+		}
+		buf.Write(blk)
+	}
+	return nil
+}
+
+// formatSparseEntries formats the offsets and lengths of regions into
+// b.
+func formatSparseEntries(b []byte, regions []Region) {
+	for i, r := range regions {
+		formatOctal(b[i*24:i*24+12], r.Offset)
+		formatOctal(b[i*24+12:i*24+24], int64(len(r.Data)))
+	}
+}
+
+// formatOctal formats n as a NUL terminated octal number into b.
+func formatOctal(b []byte, n int64) {
+	copy(b, fmt.Sprintf("%0*o\x00", len(b)-1, n))
+}
@@ -27,8 +27,13 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/andybalholm/brotli"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 	xznocgo "github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+	"go.rgst.io/jaredallard/archives/v2/internal/lzip"
+	"go.rgst.io/jaredallard/archives/v2/internal/unixcompress"
 )
 
 // Container represents the container of a tar file.
@@ -45,6 +50,16 @@ const (
 	ContainerBz2
 	// ContainerZstd is a tar archive compressed with zstd.
 	ContainerZstd
+	// ContainerLz4 is a tar archive compressed with lz4.
+	ContainerLz4
+	// ContainerLzip is a tar archive compressed with lzip.
+	ContainerLzip
+	// ContainerLzma is a tar archive compressed with legacy lzma.
+	ContainerLzma
+	// ContainerBrotli is a tar archive compressed with brotli.
+	ContainerBrotli
+	// ContainerCompress is a tar archive compressed with compress(1).
+	ContainerCompress
 )
 
 // Options is a struct for interacting with containers.
@@ -95,6 +110,24 @@ func Create(options ...OptionFn) (io.Reader, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
 		}
+	case ContainerLz4:
+		container = lz4.NewWriter(buf)
+	case ContainerLzip:
+		var err error
+		container, err = lzip.NewWriter(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lzip writer: %w", err)
+		}
+	case ContainerLzma:
+		var err error
+		container, err = lzma.NewWriter(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lzma writer: %w", err)
+		}
+	case ContainerBrotli:
+		container = brotli.NewWriter(buf)
+	case ContainerCompress:
+		container = unixcompress.NewWriter(buf)
 	}
 
 	var tw *tar.Writer
@@ -0,0 +1,278 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package unixcompress implements the LZW based format used by the
+// Unix compress(1) utility (.Z files).
+//
+// The standard library's compress/lzw package cannot be used because
+// compress(1) discards the remainder of a code group whenever the code
+// width changes, and supports resetting the dictionary mid-stream.
+package unixcompress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Contains constants for the .Z format.
+const (
+	magic0 = 0x1f
+	magic1 = 0x9d
+
+	// flagBitsMask masks the maximum code width out of the flags byte.
+	flagBitsMask = 0x1f
+
+	// flagBlockMode denotes that the clear code is supported.
+	flagBlockMode = 0x80
+
+	// initBits is the initial code width.
+	initBits = 9
+
+	// minMaxBits and maxMaxBits are the supported bounds for the maximum
+	// code width.
+	minMaxBits = 9
+	maxMaxBits = 16
+
+	// clearCode resets the dictionary when block mode is enabled.
+	clearCode = 256
+)
+
+// ErrCorrupt is returned when the stream is not valid.
+var ErrCorrupt = errors.New("unixcompress: corrupt stream")
+
+// reader implements [io.Reader] for .Z streams.
+type reader struct {
+	r *bufio.Reader
+
+	blockMode  bool
+	maxBits    uint
+	maxMaxCode int
+
+	// bit reader state. segBits is the number of bits consumed since the
+	// code width last changed, which is used to skip to the end of the
+	// current code group.
+	bitBuf  uint32
+	bitLen  uint
+	segBits int
+
+	nBits   uint
+	maxCode int
+	freeEnt int
+	oldCode int
+	finChar byte
+
+	prefix []uint16
+	suffix []byte
+	stack  []byte
+
+	// out contains decoded bytes that have not been returned yet.
+	out []byte
+	err error
+}
+
+// NewReader returns a new [io.Reader] that decompresses the .Z stream
+// read from r.
+func NewReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	var hdr [3]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, fmt.Errorf("unixcompress: failed to read header: %w", err)
+	}
+
+	if hdr[0] != magic0 || hdr[1] != magic1 {
+		return nil, fmt.Errorf("%w: invalid magic", ErrCorrupt)
+	}
+
+	maxBits := uint(hdr[2] & flagBitsMask)
+	if maxBits < minMaxBits || maxBits > maxMaxBits {
+		return nil, fmt.Errorf("unixcompress: unsupported maximum code width %d", maxBits)
+	}
+
+	z := &reader{
+		r:          br,
+		blockMode:  hdr[2]&flagBlockMode != 0,
+		maxBits:    maxBits,
+		maxMaxCode: 1 << maxBits,
+		prefix:     make([]uint16, 1<<maxBits),
+		suffix:     make([]byte, 1<<maxBits),
+		stack:      make([]byte, 0, 1<<maxBits),
+	}
+	for i := 0; i < 256; i++ {
+		z.suffix[i] = byte(i)
+	}
+	z.reset()
+
+	return z, nil
+}
+
+// reset resets the dictionary and code width to their initial state.
+func (z *reader) reset() {
+	z.nBits = initBits
+	z.maxCode = 1<<initBits - 1
+	z.freeEnt = 256
+	if z.blockMode {
+		z.freeEnt = clearCode + 1
+	}
+	z.oldCode = -1
+}
+
+// skipGroup discards the remainder of the current code group. A code
+// group is nBits bytes long (8 codes).
+func (z *reader) skipGroup() error {
+	groupBits := int(z.nBits) * 8
+	skip := (groupBits - z.segBits%groupBits) % groupBits
+	z.segBits = 0
+
+	for skip > 0 {
+		if z.bitLen == 0 {
+			b, err := z.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			z.bitBuf, z.bitLen = uint32(b), 8
+		}
+
+		n := min(uint(skip), z.bitLen)
+		z.bitBuf >>= n
+		z.bitLen -= n
+		skip -= int(n)
+	}
+
+	return nil
+}
+
+// readCode reads a single code of the current width.
+func (z *reader) readCode() (int, error) {
+	for z.bitLen < z.nBits {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			// A partial code at the end of the stream is padding.
+			return 0, err
+		}
+		z.bitBuf |= uint32(b) << z.bitLen
+		z.bitLen += 8
+	}
+
+	code := int(z.bitBuf & (1<<z.nBits - 1))
+	z.bitBuf >>= z.nBits
+	z.bitLen -= z.nBits
+	z.segBits += int(z.nBits)
+	return code, nil
+}
+
+// decode decodes the next code into z.out.
+func (z *reader) decode() error {
+	if z.freeEnt > z.maxCode && z.nBits < z.maxBits {
+		if err := z.skipGroup(); err != nil {
+			return err
+		}
+
+		z.nBits++
+		if z.nBits == z.maxBits {
+			z.maxCode = z.maxMaxCode
+		} else {
+			z.maxCode = 1<<z.nBits - 1
+		}
+	}
+
+	code, err := z.readCode()
+	if err != nil {
+		return err
+	}
+
+	if z.oldCode == -1 {
+		if code >= 256 {
+			return ErrCorrupt
+		}
+
+		z.finChar = byte(code)
+		z.oldCode = code
+		z.out = append(z.out[:0], z.finChar)
+		return nil
+	}
+
+	if code == clearCode && z.blockMode {
+		if err := z.skipGroup(); err != nil {
+			return err
+		}
+
+		oldCode := z.oldCode
+		z.reset()
+
+		// Unlike the initial state, the first code after a clear still
+		// creates a (never referenced) dictionary entry in place of the
+		// clear code.
+		z.freeEnt = clearCode
+		z.oldCode = oldCode
+		return nil
+	}
+
+	inCode := code
+	z.stack = z.stack[:0]
+	if code >= z.freeEnt {
+		// KwKwK case, the code is the one that is about to be created.
+		if code > z.freeEnt {
+			return ErrCorrupt
+		}
+
+		z.stack = append(z.stack, z.finChar)
+		code = z.oldCode
+	}
+
+	for code >= 256 {
+		z.stack = append(z.stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.finChar = z.suffix[code]
+	z.stack = append(z.stack, z.finChar)
+
+	// The stack is in reverse order.
+	z.out = z.out[:0]
+	for i := len(z.stack) - 1; i >= 0; i-- {
+		z.out = append(z.out, z.stack[i])
+	}
+
+	if z.freeEnt < z.maxMaxCode {
+		z.prefix[z.freeEnt] = uint16(z.oldCode) //nolint:gosec // Why: Codes are at most 16 bits.
+		z.suffix[z.freeEnt] = z.finChar
+		z.freeEnt++
+	}
+	z.oldCode = inCode
+
+	return nil
+}
+
+// Read implements [io.Reader].
+func (z *reader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+
+		if err := z.decode(); err != nil {
+			z.err = err
+		}
+	}
+
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
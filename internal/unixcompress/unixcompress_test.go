@@ -0,0 +1,72 @@
+package unixcompress_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os/exec"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2/internal/unixcompress"
+	"gotest.tools/v3/assert"
+)
+
+// testData returns data that is large enough to grow the code width to
+// its maximum and to fill the dictionary.
+func testData() []byte {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec // Why: Deterministic test data.
+	words := []string{"hello", "world", "archive", "tar", "compress", " ", "\n", "aaaa"}
+
+	buf := new(bytes.Buffer)
+	for buf.Len() < 1<<20 {
+		if r.Intn(10) == 0 {
+			buf.WriteByte(byte(r.Intn(256)))
+			continue
+		}
+		buf.WriteString(words[r.Intn(len(words))])
+	}
+	return buf.Bytes()
+}
+
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	w := unixcompress.NewWriter(buf)
+	_, err := w.Write(data)
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, data := range [][]byte{{}, []byte("a"), []byte("aaaaaaaaaaaaaaaaaaaaaaaaaa"), testData()} {
+		r, err := unixcompress.NewReader(bytes.NewReader(compress(t, data)))
+		assert.NilError(t, err)
+
+		got, err := io.ReadAll(r)
+		assert.NilError(t, err)
+		assert.Assert(t, bytes.Equal(got, data), "round trip mismatch (len %d != %d)", len(got), len(data))
+	}
+}
+
+// TestGzipCompatible ensures that both the reader and the writer are
+// compatible with gzip's .Z implementation.
+func TestGzipCompatible(t *testing.T) {
+	if _, err := exec.LookPath("gzip"); err != nil {
+		t.Skip("gzip not found on host")
+	}
+
+	data := testData()
+
+	cmd := exec.Command("gzip", "-dc")
+	cmd.Stdin = bytes.NewReader(compress(t, data))
+	got, err := cmd.Output()
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.Equal(got, data), "gzip output mismatch (len %d != %d)", len(got), len(data))
+}
+
+func TestRejectsInvalidMagic(t *testing.T) {
+	_, err := unixcompress.NewReader(bytes.NewReader([]byte{0x1f, 0x8b, 0x08}))
+	assert.ErrorContains(t, err, "invalid magic")
+}
@@ -0,0 +1,177 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package unixcompress
+
+import (
+	"bufio"
+	"io"
+)
+
+// writer implements [io.WriteCloser] for .Z streams. Unlike
+// compress(1), which monitors the compression ratio, the dictionary is
+// cleared as soon as it is full.
+type writer struct {
+	w *bufio.Writer
+
+	bitBuf  uint32
+	bitLen  uint
+	segBits int
+
+	nBits   uint
+	maxCode int
+
+	// decFreeEnt tracks the dictionary size as seen by a reader, which
+	// determines when the code width increases.
+	decFreeEnt int
+	codes      int
+
+	// freeEnt is the next code to allocate in dict.
+	freeEnt int
+	dict    map[uint32]int
+
+	// prefix is the code for the current match, or -1 if no data has
+	// been written yet.
+	prefix int
+	err    error
+}
+
+// NewWriter returns a new [io.WriteCloser] that compresses data written
+// to it in the .Z format with 16 bit codes. Close must be called to
+// flush the stream.
+func NewWriter(w io.Writer) io.WriteCloser {
+	z := &writer{
+		w:          bufio.NewWriter(w),
+		nBits:      initBits,
+		maxCode:    1<<initBits - 1,
+		decFreeEnt: clearCode + 1,
+		freeEnt:    clearCode + 1,
+		dict:       make(map[uint32]int),
+		prefix:     -1,
+	}
+	_, z.err = z.w.Write([]byte{magic0, magic1, flagBlockMode | maxMaxBits})
+	return z
+}
+
+// flushBits writes all complete bytes in the bit buffer.
+func (z *writer) flushBits() {
+	for z.bitLen >= 8 && z.err == nil {
+		z.err = z.w.WriteByte(byte(z.bitBuf))
+		z.bitBuf >>= 8
+		z.bitLen -= 8
+	}
+}
+
+// padGroup pads the output to the end of the current code group.
+func (z *writer) padGroup() {
+	groupBits := int(z.nBits) * 8
+	for pad := (groupBits - z.segBits%groupBits) % groupBits; pad > 0; {
+		n := min(uint(pad), 8)
+		z.bitLen += n
+		pad -= int(n)
+		z.flushBits()
+	}
+	z.segBits = 0
+}
+
+// writeCode writes a code, increasing the code width first if a reader
+// would do so.
+func (z *writer) writeCode(code int) {
+	if z.decFreeEnt > z.maxCode && z.nBits < maxMaxBits {
+		z.padGroup()
+		z.nBits++
+		if z.nBits == maxMaxBits {
+			z.maxCode = 1 << maxMaxBits
+		} else {
+			z.maxCode = 1<<z.nBits - 1
+		}
+	}
+
+	z.bitBuf |= uint32(code) << z.bitLen //nolint:gosec // Why: Codes are at most 16 bits.
+	z.bitLen += z.nBits
+	z.segBits += int(z.nBits)
+	z.flushBits()
+
+	// Readers only create a dictionary entry from the second code
+	// onwards.
+	if z.codes > 0 && z.decFreeEnt < 1<<maxMaxBits {
+		z.decFreeEnt++
+	}
+	z.codes++
+}
+
+// clear emits a clear code and resets the dictionary.
+func (z *writer) clear() {
+	z.writeCode(clearCode)
+	z.padGroup()
+
+	z.nBits = initBits
+	z.maxCode = 1<<initBits - 1
+	z.decFreeEnt = clearCode
+	z.freeEnt = clearCode + 1
+	clear(z.dict)
+}
+
+// Write implements [io.Writer].
+func (z *writer) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if z.err != nil {
+			return 0, z.err
+		}
+
+		if z.prefix == -1 {
+			z.prefix = int(c)
+			continue
+		}
+
+		key := uint32(z.prefix)<<8 | uint32(c) //nolint:gosec // Why: Codes are at most 16 bits.
+		if code, ok := z.dict[key]; ok {
+			z.prefix = code
+			continue
+		}
+
+		z.writeCode(z.prefix)
+		if z.freeEnt < 1<<maxMaxBits {
+			z.dict[key] = z.freeEnt
+			z.freeEnt++
+		} else {
+			z.clear()
+		}
+		z.prefix = int(c)
+	}
+
+	return len(p), z.err
+}
+
+// Close implements [io.Closer].
+func (z *writer) Close() error {
+	if z.prefix != -1 {
+		z.writeCode(z.prefix)
+	}
+
+	if z.bitLen > 0 {
+		z.bitLen = 8
+		z.flushBits()
+	}
+
+	if z.err != nil {
+		return z.err
+	}
+
+	return z.w.Flush()
+}
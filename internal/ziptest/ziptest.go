@@ -0,0 +1,389 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package ziptest contains helpers for creating zip archives using
+// features not supported by [archive/zip], for usage in tests in the
+// archives package.
+package ziptest
+
+import (
+	stdzip "archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // Why: Required by the WinZip AES format.
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+	"go.rgst.io/jaredallard/archives/v2/internal/deflate64"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// File is a file to add to an archive.
+type File struct {
+	Name     string
+	Contents []byte
+
+	// Method is the compression method of the file, one of the Method
+	// constants. Defaults to [stdzip.Store].
+	Method uint16
+
+	// Compressed, if set, is written as the compressed contents instead
+	// of compressing Contents. This is used for methods that can't be
+	// written by this package.
+	Compressed []byte
+
+	// NonUTF8 stores Name without marking it as UTF-8, Name may then
+	// contain bytes of any encoding.
+	NonUTF8 bool
+
+	// Extra is the extra field of the file.
+	Extra []byte
+}
+
+// Contains the compression methods supported in addition to
+// [stdzip.Store] and [stdzip.Deflate].
+const (
+	MethodDeflate64 uint16 = 9
+	MethodBzip2     uint16 = 12
+	MethodLZMA      uint16 = 14
+	MethodZstd      uint16 = 93
+	MethodXZ        uint16 = 95
+)
+
+// Encryption is an encryption method of zip entries.
+type Encryption int
+
+// Contains the supported encryption methods.
+const (
+	// EncryptionNone doesn't encrypt entries. This is the default.
+	EncryptionNone Encryption = iota
+	// EncryptionZipCrypto is traditional PKWARE encryption.
+	EncryptionZipCrypto
+	// EncryptionAES128 is WinZip AES encryption with a 128-bit key.
+	EncryptionAES128
+	// EncryptionAES192 is WinZip AES encryption with a 192-bit key.
+	EncryptionAES192
+	// EncryptionAES256 is WinZip AES encryption with a 256-bit key.
+	EncryptionAES256
+)
+
+// Options is a struct for configuring the created archive.
+type Options struct {
+	Encryption Encryption
+	Password   string
+
+	// AESVersion is the AE-x version used for AES encryption. AE-1
+	// stores the CRC-32 of the contents, AE-2 doesn't.
+	AESVersion int
+
+	// LZMAEndMarker writes LZMA compressed entries with an end of stream
+	// marker.
+	LZMAEndMarker bool
+}
+
+// OptionFn modifies a [Options] struct.
+type OptionFn func(*Options)
+
+// WithEncryption encrypts all entries using e and password.
+func WithEncryption(e Encryption, password string) OptionFn {
+	return func(o *Options) {
+		o.Encryption = e
+		o.Password = password
+	}
+}
+
+// WithAESVersion sets the AE-x version used for AES encryption, 1 (the
+// default) or 2.
+func WithAESVersion(v int) OptionFn {
+	return func(o *Options) {
+		o.AESVersion = v
+	}
+}
+
+// WithLZMAEndMarker writes LZMA compressed entries with an end of stream
+// marker, which is optional in zip archives.
+func WithLZMAEndMarker() OptionFn {
+	return func(o *Options) {
+		o.LZMAEndMarker = true
+	}
+}
+
+// Create creates a new zip archive containing the provided files.
+func Create(files []File, options ...OptionFn) ([]byte, error) {
+	opts := &Options{AESVersion: 1}
+	for _, o := range options {
+		o(opts)
+	}
+
+	buf := new(bytes.Buffer)
+	zw := stdzip.NewWriter(buf)
+	for _, f := range files {
+		method := f.Method
+		data := f.Compressed
+		if data == nil {
+			var err error
+			if data, err = compress(f.Contents, method, opts); err != nil {
+				return nil, err
+			}
+		}
+
+		fh := &stdzip.FileHeader{
+			Name:               f.Name,
+			Method:             method,
+			CRC32:              crc32.ChecksumIEEE(f.Contents),
+			UncompressedSize64: uint64(len(f.Contents)),
+			Modified:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			NonUTF8:            f.NonUTF8,
+			Extra:              f.Extra,
+		}
+		if method == MethodLZMA && opts.LZMAEndMarker {
+			fh.Flags |= 0x2
+		}
+
+		var err error
+		switch opts.Encryption {
+		case EncryptionNone:
+		case EncryptionZipCrypto:
+			fh.Flags |= 0x1
+			data, err = encryptZipCrypto(data, opts.Password, byte(fh.CRC32>>24))
+		default:
+			fh.Flags |= 0x1
+			strength := byte(opts.Encryption - EncryptionAES128 + 1)
+			fh.Extra = binary.LittleEndian.AppendUint16(fh.Extra, 0x9901)
+			fh.Extra = binary.LittleEndian.AppendUint16(fh.Extra, 7)
+			fh.Extra = binary.LittleEndian.AppendUint16(fh.Extra, uint16(opts.AESVersion)) //nolint:gosec // Why: Test helper.
+			fh.Extra = append(fh.Extra, 'A', 'E', strength)
+			fh.Extra = binary.LittleEndian.AppendUint16(fh.Extra, method)
+			fh.Method = 99
+			if opts.AESVersion == 2 {
+				fh.CRC32 = 0
+			}
+			data, err = encryptAES(data, opts.Password, strength)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fh.CompressedSize64 = uint64(len(data))
+
+		w, err := zw.CreateRaw(fh)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compress compresses b using method.
+func compress(b []byte, method uint16, opts *Options) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	var w io.WriteCloser
+	var err error
+	switch method {
+	case stdzip.Store:
+		return b, nil
+	case stdzip.Deflate:
+		w, err = flate.NewWriter(buf, flate.DefaultCompression)
+	case MethodDeflate64:
+		w = deflate64.NewWriter(buf)
+	case MethodLZMA:
+		return compressLZMA(b, opts.LZMAEndMarker)
+	case MethodZstd:
+		w, err = zstd.NewWriter(buf)
+	case MethodXZ:
+		w, err = xz.NewWriter(buf)
+	default:
+		return nil, fmt.Errorf("unsupported compression method %d", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compressLZMA compresses b using the LZMA format of zip archives,
+// which stores the properties of the classic .lzma header without the
+// uncompressed size.
+func compressLZMA(b []byte, endMarker bool) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w, err := lzma.WriterConfig{Size: int64(len(b)), EOSMarker: endMarker}.NewWriter(buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	classic := buf.Bytes()
+	out := []byte{9, 20, 5, 0}
+	out = append(out, classic[:5]...)
+	return append(out, classic[lzma.HeaderLen:]...), nil
+}
+
+// encryptZipCrypto encrypts b using traditional PKWARE encryption.
+func encryptZipCrypto(b []byte, password string, check byte) ([]byte, error) {
+	keys := [3]uint32{0x12345678, 0x23456789, 0x34567890}
+	update := func(c byte) {
+		keys[0] = crc32.IEEETable[byte(keys[0])^c] ^ (keys[0] >> 8)
+		keys[1] = (keys[1]+(keys[0]&0xff))*134775813 + 1
+		keys[2] = crc32.IEEETable[byte(keys[2])^byte(keys[1]>>24)] ^ (keys[2] >> 8)
+	}
+	for i := 0; i < len(password); i++ {
+		update(password[i])
+	}
+
+	hdr := make([]byte, 12)
+	if _, err := rand.Read(hdr[:11]); err != nil {
+		return nil, err
+	}
+	hdr[11] = check
+
+	out := append(hdr, b...)
+	for i, c := range out {
+		t := keys[2] | 2
+		out[i] = c ^ byte((t*(t^1))>>8)
+		update(c)
+	}
+
+	return out, nil
+}
+
+// encryptAES encrypts b using WinZip AES encryption.
+func encryptAES(b []byte, password string, strength byte) ([]byte, error) {
+	keyLen := 8 + 8*int(strength)
+	salt := make([]byte, keyLen/2)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, 1000, 2*keyLen+2, sha1.New)
+	block, err := aes.NewCipher(key[:keyLen])
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, len(b))
+	var counter, stream [aes.BlockSize]byte
+	for i := range b {
+		if i%aes.BlockSize == 0 {
+			for j := range counter {
+				counter[j]++
+				if counter[j] != 0 {
+					break
+				}
+			}
+			block.Encrypt(stream[:], counter[:])
+		}
+		data[i] = b[i] ^ stream[i%aes.BlockSize]
+	}
+
+	mac := hmac.New(sha1.New, key[keyLen:2*keyLen])
+	mac.Write(data)
+
+	out := append(salt, key[2*keyLen:]...)
+	out = append(out, data...)
+	return append(out, mac.Sum(nil)[:10]...), nil
+}
+
+// Split converts the zip archive b, as created by [Create], into a
+// spanned archive of volumes of the provided size, as created by
+// zip -s. Offsets in the central directory are made relative to the
+// volume they point into. The last volume may be larger than size, as
+// the end of central directory record is never split.
+func Split(b []byte, size int) ([][]byte, error) {
+	if len(b) < 22 || binary.LittleEndian.Uint32(b[len(b)-22:]) != 0x06054b50 {
+		return nil, fmt.Errorf("archive must not have a comment")
+	}
+	end := bytes.Clone(b[len(b)-22:])
+	records := int(binary.LittleEndian.Uint16(end[10:]))
+	dirSize := int(binary.LittleEndian.Uint32(end[12:]))
+	dirOffset := int(binary.LittleEndian.Uint32(end[16:]))
+
+	// The first volume starts with the spanning signature, shifting
+	// every offset.
+	const sigLen = 4
+	disk := func(pos int) (uint16, uint32) {
+		return uint16(pos / size), uint32(pos % size) //nolint:gosec // Why: Test helper.
+	}
+
+	dir := bytes.Clone(b[dirOffset : dirOffset+dirSize])
+	for i, p := 0, 0; i < records; i++ {
+		d, off := disk(sigLen + int(binary.LittleEndian.Uint32(dir[p+42:])))
+		binary.LittleEndian.PutUint16(dir[p+34:], d)
+		binary.LittleEndian.PutUint32(dir[p+42:], off)
+		p += 46 + int(binary.LittleEndian.Uint16(dir[p+28:])) +
+			int(binary.LittleEndian.Uint16(dir[p+30:])) + int(binary.LittleEndian.Uint16(dir[p+32:]))
+	}
+
+	stream := binary.LittleEndian.AppendUint32(nil, 0x08074b50)
+	stream = append(stream, b[:dirOffset]...)
+	stream = append(stream, dir...)
+
+	var vols [][]byte
+	for len(stream) > size {
+		vols = append(vols, stream[:size])
+		stream = stream[size:]
+	}
+	vols = append(vols, stream)
+
+	// Count the records on the last disk.
+	last := len(vols) - 1
+	var lastRecords uint16
+	for i, p := 0, 0; i < records; i++ {
+		if d, _ := disk(sigLen + dirOffset + p); int(d) == last {
+			lastRecords++
+		}
+		p += 46 + int(binary.LittleEndian.Uint16(dir[p+28:])) +
+			int(binary.LittleEndian.Uint16(dir[p+30:])) + int(binary.LittleEndian.Uint16(dir[p+32:]))
+	}
+
+	dirDisk, dirOff := disk(sigLen + dirOffset)
+	binary.LittleEndian.PutUint16(end[4:], uint16(last)) //nolint:gosec // Why: Test helper.
+	binary.LittleEndian.PutUint16(end[6:], dirDisk)
+	binary.LittleEndian.PutUint16(end[8:], lastRecords)
+	binary.LittleEndian.PutUint32(end[16:], dirOff)
+	vols[last] = append(bytes.Clone(vols[last]), end...)
+
+	return vols, nil
+}
@@ -0,0 +1,788 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Contains constants of the ISO 9660 format.
+const (
+	// isoSectorSize is the size of a sector. Volume descriptors are
+	// stored one per sector and directory records never cross a sector
+	// boundary.
+	isoSectorSize = 2048
+
+	// isoSystemAreaSectors is the number of sectors before the first
+	// volume descriptor.
+	isoSystemAreaSectors = 16
+
+	// isoMaxVolumeDescriptors is the maximum number of volume
+	// descriptors read before giving up on finding the terminator.
+	isoMaxVolumeDescriptors = 64
+
+	// isoMaxContinuations is the maximum number of Rock Ridge
+	// continuation areas read for a single directory record.
+	isoMaxContinuations = 16
+)
+
+// Contains the volume descriptor types of an ISO 9660 image.
+const (
+	isoVolumePrimary       = 1
+	isoVolumeSupplementary = 2
+	isoVolumeTerminator    = 255
+)
+
+// Contains the flags of an ISO 9660 directory record.
+const (
+	isoFlagDir         = 1 << 1
+	isoFlagAssociated  = 1 << 2
+	isoFlagMultiExtent = 1 << 7
+)
+
+// _ ensures that iso implements the [Archiver] interface.
+var _ Archiver = (&iso{})
+
+// _ ensures that iso implements the [ReaderAtArchiver] interface.
+var _ ReaderAtArchiver = (&iso{})
+
+// iso implements the [Archiver] interface for ISO 9660 images,
+// including the Rock Ridge and Joliet extensions.
+type iso struct{}
+
+// Extensions returns the supported extensions for the iso extractor.
+func (i *iso) Extensions() []string {
+	return []string{"iso"}
+}
+
+// Open creates a new [Archive] from the provided reader using the ISO
+// 9660 format. Directories of an image may be stored anywhere in the
+// image, so the entire image is read into memory. Use [OpenReaderAt] to
+// avoid this.
+func (i *iso) Open(r io.Reader, ext string) (Archive, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	return i.OpenReaderAt(bytes.NewReader(b), int64(len(b)), ext, &OpenOptions{})
+}
+
+// OpenReaderAt implements [ReaderAtArchiver].
+func (i *iso) OpenReaderAt(r io.ReaderAt, size int64, _ string, _ *OpenOptions) (Archive, error) {
+	a, err := openISO(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// isoExtent is a contiguous range of blocks in an image.
+type isoExtent struct {
+	lba  int64
+	size int64
+}
+
+// isoEntry is a file or directory of an ISO 9660 image.
+type isoEntry struct {
+	name    string
+	dir     bool
+	extents []isoExtent
+	size    int64
+	modTime time.Time
+
+	// multiExtent is true if the contents of the entry continue in the
+	// next directory record.
+	multiExtent bool
+
+	// Contains the Rock Ridge attributes of the entry.
+	rrName      string
+	hasPX       bool
+	mode        uint32
+	uid, gid    int
+	linkname    string
+	symlink     bool
+	linkJoin    bool
+	accessTime  time.Time
+	relocated   bool
+	childLink   int64
+	hasChildRef bool
+}
+
+// header returns the [Header] for the entry.
+func (e *isoEntry) header() *Header {
+	h := &Header{
+		Name:       e.name,
+		Type:       HeaderFile,
+		Size:       e.size,
+		ModTime:    e.modTime,
+		AccessTime: e.accessTime,
+		UID:        e.uid,
+		GID:        e.gid,
+	}
+
+	switch {
+	case e.hasPX:
+		h.Mode = unixModeToFileMode(e.mode)
+	case e.dir:
+		h.Mode = os.ModeDir | 0o755
+	case e.symlink:
+		h.Mode = os.ModeSymlink | 0o777
+	default:
+		h.Mode = 0o644
+	}
+
+	switch {
+	case e.dir:
+		h.Type = HeaderDir
+		h.Name += "/"
+		h.Size = 0
+	case e.symlink:
+		h.Type = HeaderSymlink
+		h.Linkname = e.linkname
+		h.Size = 0
+	case h.Mode&specialModes != 0:
+		h.Type = HeaderSpecial
+	}
+
+	return h
+}
+
+// isoArchive is an [Archive] reading an ISO 9660 image. Entries are
+// returned depth first, with every directory returned before its
+// contents.
+type isoArchive struct {
+	r    io.ReaderAt
+	size int64
+
+	// blockSize is the logical block size of the image.
+	blockSize int64
+
+	// joliet is true if the Joliet directory hierarchy is read, names
+	// of which are encoded in UCS-2.
+	joliet bool
+
+	// rockRidge is true if the image contains Rock Ridge extensions,
+	// suspSkip is the number of bytes to skip at the start of every
+	// system use area.
+	rockRidge bool
+	suspSkip  int
+
+	// stack contains the entries to return, the next entry last.
+	stack []*isoEntry
+
+	// visited contains the extents of all directories read, to detect
+	// loops.
+	visited map[int64]bool
+
+	// cur is the contents of the current entry.
+	cur io.Reader
+}
+
+// openISO reads the volume descriptors and root directory of an ISO
+// 9660 image. The Rock Ridge extensions of the primary volume are
+// preferred over the Joliet extensions, if both are present.
+func openISO(r io.ReaderAt, size int64) (*isoArchive, error) {
+	var primary, joliet []byte
+	for i := int64(0); ; i++ {
+		if i == isoMaxVolumeDescriptors {
+			return nil, fmt.Errorf("%w: no volume descriptor set terminator found", ErrCorrupt)
+		}
+
+		vd := make([]byte, isoSectorSize)
+		if _, err := r.ReadAt(vd, (isoSystemAreaSectors+i)*isoSectorSize); err != nil {
+			return nil, fmt.Errorf("failed to read volume descriptor: %w", err)
+		}
+
+		if string(vd[1:6]) != "CD001" {
+			return nil, fmt.Errorf("%w: not an ISO 9660 image", ErrCorrupt)
+		}
+
+		switch vd[0] {
+		case isoVolumePrimary:
+			if primary == nil {
+				primary = vd
+			}
+		case isoVolumeSupplementary:
+			if joliet == nil && isJolietEscape(vd[88:120]) {
+				joliet = vd
+			}
+		}
+
+		if vd[0] == isoVolumeTerminator {
+			break
+		}
+	}
+
+	if primary == nil {
+		return nil, fmt.Errorf("%w: no primary volume descriptor found", ErrCorrupt)
+	}
+
+	a := &isoArchive{
+		r:         r,
+		size:      size,
+		blockSize: int64(binary.LittleEndian.Uint16(primary[128:])),
+		visited:   make(map[int64]bool),
+	}
+	if a.blockSize == 0 || a.blockSize > isoSectorSize || a.blockSize&(a.blockSize-1) != 0 {
+		return nil, fmt.Errorf("%w: invalid logical block size %d", ErrCorrupt, a.blockSize)
+	}
+
+	root, err := parseISORecord(primary[156:190])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root directory record: %w", err)
+	}
+
+	if err := a.detectRockRidge(root); err != nil {
+		return nil, err
+	}
+
+	if !a.rockRidge && joliet != nil {
+		a.joliet = true
+		if root, err = parseISORecord(joliet[156:190]); err != nil {
+			return nil, fmt.Errorf("failed to read Joliet root directory record: %w", err)
+		}
+	}
+
+	entries, err := a.readDir(root.lba, root.size, "")
+	if err != nil {
+		return nil, err
+	}
+	a.push(entries)
+
+	return a, nil
+}
+
+// isJolietEscape returns true if the escape sequences of a
+// supplementary volume descriptor denote a Joliet volume.
+func isJolietEscape(b []byte) bool {
+	for _, seq := range []string{"%/@", "%/C", "%/E"} {
+		if bytes.HasPrefix(b, []byte(seq)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectRockRidge checks whether the first record of the root directory
+// contains the SUSP indicator, which is used to mark images using Rock
+// Ridge extensions.
+func (a *isoArchive) detectRockRidge(root isoRecord) error {
+	data, err := a.readExtent(root.lba, min(root.size, isoSectorSize))
+	if err != nil {
+		return fmt.Errorf("failed to read root directory: %w", err)
+	}
+	if len(data) == 0 || int(data[0]) > len(data) {
+		return fmt.Errorf("%w: invalid root directory", ErrCorrupt)
+	}
+
+	self, err := parseISORecord(data[:data[0]])
+	if err != nil {
+		return fmt.Errorf("failed to read root directory: %w", err)
+	}
+
+	su := self.systemUse
+	if len(su) >= 7 && string(su[:2]) == "SP" && su[4] == 0xbe && su[5] == 0xef {
+		a.rockRidge = true
+		a.suspSkip = int(su[6])
+	}
+
+	return nil
+}
+
+// isoRecord is a directory record of an ISO 9660 image.
+type isoRecord struct {
+	lba       int64
+	size      int64
+	modTime   time.Time
+	flags     byte
+	name      []byte
+	systemUse []byte
+}
+
+// parseISORecord parses the directory record b.
+func parseISORecord(b []byte) (isoRecord, error) {
+	if len(b) < 34 || int(b[0]) > len(b) || b[0] < 34 {
+		return isoRecord{}, fmt.Errorf("%w: invalid directory record", ErrCorrupt)
+	}
+	b = b[:b[0]]
+
+	nameLen := int(b[32])
+	if 33+nameLen > len(b) {
+		return isoRecord{}, fmt.Errorf("%w: invalid directory record name length %d", ErrCorrupt, nameLen)
+	}
+
+	// The system use area starts after the name, which is padded to an
+	// even length.
+	su := 33 + nameLen
+	if nameLen%2 == 0 {
+		su++
+	}
+	su = min(su, len(b))
+
+	return isoRecord{
+		lba:       int64(binary.LittleEndian.Uint32(b[2:])),
+		size:      int64(binary.LittleEndian.Uint32(b[10:])),
+		modTime:   isoRecordTime(b[18:25]),
+		flags:     b[25],
+		name:      b[33 : 33+nameLen],
+		systemUse: b[su:],
+	}, nil
+}
+
+// isoRecordTime parses the 7 byte date and time format used in
+// directory records.
+func isoRecordTime(b []byte) time.Time {
+	if bytes.Equal(b[:6], make([]byte, 6)) {
+		return time.Time{}
+	}
+
+	zone := time.FixedZone("", int(int8(b[6]))*15*60)
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]),
+		int(b[3]), int(b[4]), int(b[5]), 0, zone)
+}
+
+// isoLongTime parses the 17 byte date and time format used in volume
+// descriptors and Rock Ridge time stamps.
+func isoLongTime(b []byte) time.Time {
+	fields := make([]int, 0, 7)
+	for _, w := range []int{4, 2, 2, 2, 2, 2, 2} {
+		v, err := strconv.Atoi(string(b[:w]))
+		if err != nil {
+			return time.Time{}
+		}
+		fields = append(fields, v)
+		b = b[w:]
+	}
+	if fields[0] == 0 {
+		return time.Time{}
+	}
+
+	zone := time.FixedZone("", int(int8(b[0]))*15*60)
+	return time.Date(fields[0], time.Month(fields[1]), fields[2],
+		fields[3], fields[4], fields[5], fields[6]*int(10*time.Millisecond), zone)
+}
+
+// readExtent reads size bytes starting at block lba.
+func (a *isoArchive) readExtent(lba, size int64) ([]byte, error) {
+	if err := a.checkExtent(lba, size); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, size)
+	if _, err := a.r.ReadAt(b, lba*a.blockSize); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// checkExtent returns an error if the extent is not within the image.
+func (a *isoArchive) checkExtent(lba, size int64) error {
+	if lba*a.blockSize+size > a.size {
+		return fmt.Errorf("%w: extent at block %d with size %d is out of bounds", ErrCorrupt, lba, size)
+	}
+
+	return nil
+}
+
+// readDir reads the entries of the directory stored at block lba,
+// prefixing their names with prefix.
+func (a *isoArchive) readDir(lba, size int64, prefix string) ([]*isoEntry, error) {
+	if a.visited[lba] {
+		return nil, fmt.Errorf("%w: directory loop detected at block %d", ErrCorrupt, lba)
+	}
+	a.visited[lba] = true
+
+	data, err := a.readExtent(lba, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %q: %w", prefix, err)
+	}
+
+	var entries []*isoEntry
+	var last *isoEntry
+	for off := 0; off < len(data); {
+		n := int(data[off])
+		if n == 0 {
+			// Records never cross a sector boundary, the rest of the sector
+			// is padding.
+			off = (off/isoSectorSize + 1) * isoSectorSize
+			continue
+		}
+		if off+n > len(data) {
+			return nil, fmt.Errorf("%w: directory record in %q exceeds directory", ErrCorrupt, prefix)
+		}
+
+		rec, err := parseISORecord(data[off : off+n])
+		if err != nil {
+			return nil, err
+		}
+		off += n
+
+		// Skip the records of the directory itself and its parent.
+		if len(rec.name) == 1 && rec.name[0] <= 1 {
+			continue
+		}
+
+		if last != nil && last.multiExtent {
+			if err := a.checkExtent(rec.lba, rec.size); err != nil {
+				return nil, err
+			}
+			last.extents = append(last.extents, isoExtent{rec.lba, rec.size})
+			last.size += rec.size
+			last.multiExtent = rec.flags&isoFlagMultiExtent != 0
+			continue
+		}
+
+		if rec.flags&isoFlagAssociated != 0 {
+			continue
+		}
+
+		e, err := a.newEntry(rec, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			continue
+		}
+
+		entries = append(entries, e)
+		last = e
+	}
+
+	return entries, nil
+}
+
+// newEntry creates an entry from a directory record. It returns nil if
+// the record should be skipped.
+func (a *isoArchive) newEntry(rec isoRecord, prefix string) (*isoEntry, error) {
+	e := &isoEntry{
+		dir:         rec.flags&isoFlagDir != 0,
+		extents:     []isoExtent{{rec.lba, rec.size}},
+		size:        rec.size,
+		modTime:     rec.modTime,
+		multiExtent: rec.flags&isoFlagMultiExtent != 0,
+	}
+
+	if a.rockRidge && len(rec.systemUse) > a.suspSkip {
+		if err := a.parseSUSP(e, rec.systemUse[a.suspSkip:], 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// Relocated directories are returned at the location of their child
+	// link.
+	if e.relocated {
+		return nil, nil
+	}
+	if e.hasChildRef {
+		size, err := a.dirSize(e.childLink)
+		if err != nil {
+			return nil, err
+		}
+		e.dir = true
+		e.extents = []isoExtent{{e.childLink, size}}
+		e.size = size
+	}
+
+	name := e.rrName
+	if name == "" {
+		name = a.decodeName(rec.name)
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return nil, fmt.Errorf("%w: invalid entry name %q in %q", ErrCorrupt, name, prefix)
+	}
+	// The directory containing relocated directories is hidden like in
+	// other implementations, its contents are returned at the location
+	// of their child links.
+	if a.rockRidge && prefix == "" && e.dir && (name == "rr_moved" || name == ".rr_moved") {
+		return nil, nil
+	}
+	e.name = prefix + name
+
+	if !e.dir && !e.symlink {
+		if err := a.checkExtent(rec.lba, rec.size); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// dirSize returns the size of the directory at block lba, as stored in
+// its first record.
+func (a *isoArchive) dirSize(lba int64) (int64, error) {
+	// A directory record is at most 255 bytes long.
+	data, err := a.readExtent(lba, min(255, a.size-lba*a.blockSize))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read relocated directory: %w", err)
+	}
+
+	self, err := parseISORecord(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read relocated directory: %w", err)
+	}
+
+	return self.size, nil
+}
+
+// decodeName decodes the identifier of a directory record, removing the
+// version number and the trailing separator of names without an
+// extension.
+func (a *isoArchive) decodeName(b []byte) string {
+	var name string
+	if a.joliet {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			u = append(u, binary.BigEndian.Uint16(b[i:]))
+		}
+		name = string(utf16.Decode(u))
+	} else {
+		name = string(b)
+	}
+
+	if i := strings.LastIndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	if !a.joliet {
+		name = strings.TrimSuffix(name, ".")
+	}
+
+	return name
+}
+
+// parseSUSP parses the System Use Sharing Protocol entries of a system
+// use area, storing the Rock Ridge attributes in e. depth is the number
+// of continuation areas read so far.
+func (a *isoArchive) parseSUSP(e *isoEntry, data []byte, depth int) error {
+	for len(data) >= 4 {
+		sig, n := string(data[:2]), int(data[2])
+		if n < 4 || n > len(data) {
+			break
+		}
+		d := data[:n]
+		data = data[n:]
+
+		switch sig {
+		case "PX":
+			if n < 36 {
+				continue
+			}
+			e.hasPX = true
+			e.mode = binary.LittleEndian.Uint32(d[4:])
+			e.uid = int(binary.LittleEndian.Uint32(d[20:]))
+			e.gid = int(binary.LittleEndian.Uint32(d[28:]))
+			if e.mode&0o170000 == 0o120000 {
+				e.symlink = true
+			}
+		case "NM":
+			if n < 5 || d[4]&0x06 != 0 {
+				// The current and parent directory flags are only used by
+				// the records of the directory itself.
+				continue
+			}
+			e.rrName += string(d[5:])
+		case "SL":
+			if n < 5 {
+				continue
+			}
+			e.symlink = true
+			e.parseSymlinkComponents(d[5:])
+		case "TF":
+			if n < 5 {
+				continue
+			}
+			e.parseTimestamps(d[4], d[5:])
+		case "CE":
+			if n < 28 {
+				continue
+			}
+			if depth == isoMaxContinuations {
+				return fmt.Errorf("%w: too many Rock Ridge continuation areas", ErrCorrupt)
+			}
+
+			lba := int64(binary.LittleEndian.Uint32(d[4:]))
+			off := int64(binary.LittleEndian.Uint32(d[12:]))
+			size := int64(binary.LittleEndian.Uint32(d[20:]))
+			if off+size > isoSectorSize {
+				return fmt.Errorf("%w: invalid Rock Ridge continuation area", ErrCorrupt)
+			}
+
+			ce := make([]byte, size)
+			if lba*a.blockSize+off+size > a.size {
+				return fmt.Errorf("%w: continuation area at block %d is out of bounds", ErrCorrupt, lba)
+			}
+			if _, err := a.r.ReadAt(ce, lba*a.blockSize+off); err != nil {
+				return fmt.Errorf("failed to read Rock Ridge continuation area: %w", err)
+			}
+			if err := a.parseSUSP(e, ce, depth+1); err != nil {
+				return err
+			}
+		case "RE":
+			e.relocated = true
+		case "CL":
+			if n < 12 {
+				continue
+			}
+			e.hasChildRef = true
+			e.childLink = int64(binary.LittleEndian.Uint32(d[4:]))
+		case "ST":
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// parseSymlinkComponents appends the components of a Rock Ridge SL
+// entry to the link target of e.
+func (e *isoEntry) parseSymlinkComponents(b []byte) {
+	for len(b) >= 2 {
+		flags, n := b[0], int(b[1])
+		if 2+n > len(b) {
+			return
+		}
+		content := string(b[2 : 2+n])
+		b = b[2+n:]
+
+		var part string
+		switch {
+		case flags&0x02 != 0:
+			part = "."
+		case flags&0x04 != 0:
+			part = ".."
+		case flags&0x08 != 0:
+			e.linkname = "/"
+			e.linkJoin = false
+			continue
+		default:
+			part = content
+		}
+
+		if e.linkname != "" && !e.linkJoin && !strings.HasSuffix(e.linkname, "/") {
+			e.linkname += "/"
+		}
+		e.linkname += part
+
+		// The continue flag marks components that continue in the next
+		// component without a separator.
+		e.linkJoin = flags&0x01 != 0
+	}
+}
+
+// parseTimestamps parses the time stamps of a Rock Ridge TF entry.
+func (e *isoEntry) parseTimestamps(flags byte, b []byte) {
+	size := 7
+	if flags&0x80 != 0 {
+		size = 17
+	}
+
+	// Time stamps are stored in the order of their flags: creation,
+	// modification, access, attributes, backup, expiration and
+	// effective.
+	for bit := 0; bit < 7; bit++ {
+		if flags&(1<<bit) == 0 {
+			continue
+		}
+		if len(b) < size {
+			return
+		}
+
+		var t time.Time
+		if size == 7 {
+			t = isoRecordTime(b[:size])
+		} else {
+			t = isoLongTime(b[:size])
+		}
+		b = b[size:]
+
+		switch bit {
+		case 1:
+			e.modTime = t
+		case 2:
+			e.accessTime = t
+		}
+	}
+}
+
+// push adds entries to the stack so that they are returned in order.
+func (a *isoArchive) push(entries []*isoEntry) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		a.stack = append(a.stack, entries[i])
+	}
+}
+
+// Close implements [Archive]. The underlying reader is not closed.
+func (a *isoArchive) Close() error {
+	return nil
+}
+
+// Read implements [io.Reader].
+func (a *isoArchive) Read(p []byte) (int, error) {
+	if a.cur == nil {
+		return 0, io.EOF
+	}
+
+	n, err := a.cur.Read(p)
+	return n, classifyError(err)
+}
+
+// Next implements [Archive].
+func (a *isoArchive) Next() (*Header, error) {
+	h, err := a.next()
+	return h, classifyError(err)
+}
+
+// next returns the header of the next entry.
+func (a *isoArchive) next() (*Header, error) {
+	a.cur = nil
+	if len(a.stack) == 0 {
+		return nil, io.EOF
+	}
+
+	e := a.stack[len(a.stack)-1]
+	a.stack = a.stack[:len(a.stack)-1]
+
+	switch {
+	case e.dir:
+		entries, err := a.readDir(e.extents[0].lba, e.extents[0].size, e.name+"/")
+		if err != nil {
+			return nil, err
+		}
+		a.push(entries)
+	case !e.symlink:
+		readers := make([]io.Reader, 0, len(e.extents))
+		for _, ext := range e.extents {
+			readers = append(readers, io.NewSectionReader(a.r, ext.lba*a.blockSize, ext.size))
+		}
+		a.cur = io.MultiReader(readers...)
+	}
+
+	return e.header(), nil
+}
@@ -0,0 +1,161 @@
+package archives_test
+
+import (
+	stdtar "archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/isotest"
+	"gotest.tools/v3/assert"
+)
+
+// isoEntry is a simplified header used to compare ISO images.
+type isoEntry struct {
+	Name     string
+	Type     archives.HeaderType
+	Mode     os.FileMode
+	Linkname string
+	Contents string
+}
+
+// readISO returns all entries of the provided image, opened through
+// [archives.OpenReaderAt].
+func readISO(t *testing.T, b []byte) []isoEntry {
+	t.Helper()
+
+	a, err := archives.OpenReaderAt(bytes.NewReader(b), int64(len(b)), archives.OpenOptions{Extension: ".iso"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	var entries []isoEntry
+	for {
+		h, err := a.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+
+		contents, err := io.ReadAll(a)
+		assert.NilError(t, err)
+		entries = append(entries, isoEntry{h.Name, h.Type, h.Mode, h.Linkname, string(contents)})
+	}
+
+	return entries
+}
+
+// isoFiles are the files used to create test images.
+var isoFiles = []isotest.File{
+	{Name: "boot/vmlinuz", Contents: []byte("kernel"), Mode: 0o100644},
+	{Name: "install.sh", Contents: []byte("#!/bin/sh"), Mode: 0o100755},
+	{Name: "Packages", Mode: 0o40700},
+	{Name: "vmlinuz", Linkname: "boot/vmlinuz", Mode: 0o120777},
+}
+
+func TestISORockRidge(t *testing.T) {
+	b, err := isotest.Create(isoFiles, isotest.WithRockRidge(), isotest.WithJoliet())
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, readISO(t, b), []isoEntry{
+		{"boot/", archives.HeaderDir, os.ModeDir | 0o755, "", ""},
+		{"boot/vmlinuz", archives.HeaderFile, 0o644, "", "kernel"},
+		{"install.sh", archives.HeaderFile, 0o755, "", "#!/bin/sh"},
+		{"Packages/", archives.HeaderDir, os.ModeDir | 0o700, "", ""},
+		{"vmlinuz", archives.HeaderSymlink, os.ModeSymlink | 0o777, "boot/vmlinuz", ""},
+	})
+}
+
+func TestISOJoliet(t *testing.T) {
+	b, err := isotest.Create([]isotest.File{
+		{Name: "Überlong file name with spaces.txt", Contents: []byte("joliet"), Mode: 0o100644},
+	}, isotest.WithJoliet())
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, readISO(t, b), []isoEntry{
+		{"Überlong file name with spaces.txt", archives.HeaderFile, 0o644, "", "joliet"},
+	})
+}
+
+func TestISOPlain(t *testing.T) {
+	b, err := isotest.Create([]isotest.File{
+		{Name: "BOOT/VMLINUZ", Contents: []byte("kernel"), Mode: 0o100644},
+		{Name: "README.TXT", Contents: []byte("readme"), Mode: 0o100644},
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, readISO(t, b), []isoEntry{
+		{"BOOT/", archives.HeaderDir, os.ModeDir | 0o755, "", ""},
+		{"BOOT/VMLINUZ", archives.HeaderFile, 0o644, "", "kernel"},
+		{"README.TXT", archives.HeaderFile, 0o644, "", "readme"},
+	})
+}
+
+func TestISOMultiExtent(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	b, err := isotest.Create([]isotest.File{
+		{Name: "large.bin", Contents: contents, Mode: 0o100644},
+		{Name: "small.bin", Contents: []byte("small"), Mode: 0o100644},
+	}, isotest.WithRockRidge(), isotest.WithExtentSize(4096))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, readISO(t, b), []isoEntry{
+		{"large.bin", archives.HeaderFile, 0o644, "", string(contents)},
+		{"small.bin", archives.HeaderFile, 0o644, "", "small"},
+	})
+}
+
+func TestISOMetadata(t *testing.T) {
+	mtime := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	b, err := isotest.Create([]isotest.File{
+		{Name: "file", Contents: []byte("x"), Mode: 0o104755, UID: 1000, GID: 100, ModTime: mtime},
+	}, isotest.WithRockRidge())
+	assert.NilError(t, err)
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: archives.Ext("image.iso")})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	h, err := a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Mode, os.ModeSetuid|0o755)
+	assert.Equal(t, h.UID, 1000)
+	assert.Equal(t, h.GID, 100)
+	assert.Equal(t, h.Size, int64(1))
+	assert.Assert(t, h.ModTime.Equal(mtime))
+}
+
+func TestISOExtract(t *testing.T) {
+	b, err := isotest.Create(isoFiles, isotest.WithRockRidge())
+	assert.NilError(t, err)
+
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(b), dest, archives.ExtractOptions{
+		Extension: ".iso",
+	}))
+
+	got, err := os.ReadFile(filepath.Join(dest, "vmlinuz"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "kernel")
+}
+
+func TestISONotAnImage(t *testing.T) {
+	b := make([]byte, 20*2048)
+	_, err := archives.OpenReaderAt(bytes.NewReader(b), int64(len(b)), archives.OpenOptions{Extension: ".iso"})
+	assert.ErrorContains(t, err, "not an ISO 9660 image")
+}
+
+func TestOpenReaderAtSequentialFormat(t *testing.T) {
+	b := createTar(t, &stdtar.Header{Name: "file.txt", Typeflag: stdtar.TypeReg, Mode: 0o644}).Bytes()
+
+	a, err := archives.OpenReaderAt(bytes.NewReader(b), int64(len(b)), archives.OpenOptions{Extension: ".tar"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	h, err := a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "file.txt")
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LayerMode denotes how the whiteout files of container image layers
+// are handled, see [ExtractOptions.Layer].
+type LayerMode int
+
+// Contains the supported layer modes.
+const (
+	// LayerNone extracts whiteout files like any other file. This is the
+	// default.
+	LayerNone LayerMode = iota
+
+	// LayerApply applies whiteout files to the destination, which
+	// contains the layers below: ".wh.<name>" removes <name>, and
+	// ".wh..wh..opq" removes the existing contents of its directory
+	// (making it opaque). Extracting layers one after another yields the
+	// root filesystem of the image.
+	LayerApply
+
+	// LayerOverlay converts whiteout files to the whiteouts of overlayfs,
+	// for destinations used as overlayfs lower directories: ".wh.<name>"
+	// becomes a character device named <name> with device number 0/0,
+	// and ".wh..wh..opq" sets the "trusted.overlay.opaque" extended
+	// attribute of its directory. Only supported on Linux.
+	LayerOverlay
+)
+
+// Contains the names of whiteout files, as defined by the OCI image
+// specification.
+const (
+	// whiteoutPrefix is the prefix of whiteout files.
+	whiteoutPrefix = ".wh."
+
+	// whiteoutMetaPrefix is the prefix of whiteout files reserved for
+	// special purposes.
+	whiteoutMetaPrefix = whiteoutPrefix + whiteoutPrefix
+
+	// whiteoutOpaque marks its directory as opaque.
+	whiteoutOpaque = whiteoutMetaPrefix + ".opq"
+)
+
+// layer keeps track of the paths extracted from a container image
+// layer. Whiteout files only apply to lower layers, so these are never
+// removed.
+type layer struct {
+	dest string
+	mode LayerMode
+
+	// extracted contains the paths extracted from the layer and their
+	// parent directories.
+	extracted map[string]struct{}
+}
+
+// newLayer returns a new layer extracted into dest.
+func newLayer(dest string, mode LayerMode) *layer {
+	return &layer{dest: filepath.Clean(dest), mode: mode, extracted: map[string]struct{}{}}
+}
+
+// prepare prepares extracting the entry h. If it is a whiteout file, it
+// is applied and true is returned. Otherwise, an existing directory it
+// replaces, or existing file if it is a directory, is removed.
+func (l *layer) prepare(h *Header, res *ExtractResult) (bool, error) {
+	dir, base := path.Split(h.Name)
+	if !strings.HasPrefix(base, whiteoutPrefix) {
+		return false, l.replace(h)
+	}
+
+	switch {
+	case base == whiteoutOpaque:
+		return true, l.opaque(dir, res)
+	case strings.HasPrefix(base, whiteoutMetaPrefix):
+		// Reserved for other purposes (e.g., AUFS hardlinks), ignore.
+		return true, nil
+	}
+
+	name := strings.TrimPrefix(base, whiteoutPrefix)
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return true, fmt.Errorf("%w: invalid whiteout %q", ErrCorrupt, h.Name)
+	}
+	return true, l.whiteout(dir+name, res)
+}
+
+// replace removes the existing file at the path of h if either it or h
+// is a directory, and the other isn't.
+func (l *layer) replace(h *Header) error {
+	p, err := sanitizeArchivePath(l.dest, h.Name)
+	if err != nil {
+		return err
+	}
+	if err := checkNoSymlinks(l.dest, filepath.Dir(p)); err != nil {
+		return err
+	}
+
+	fi, err := os.Lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to stat existing file: %w", err)
+	}
+
+	if fi.IsDir() != (h.Type == HeaderDir) && p != l.dest {
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("failed to remove existing file: %w", err)
+		}
+	}
+	return nil
+}
+
+// whiteout removes the file name of a lower layer.
+func (l *layer) whiteout(name string, res *ExtractResult) error {
+	p, err := sanitizeArchivePath(l.dest, name)
+	if err != nil {
+		return err
+	}
+	if err := checkNoSymlinks(l.dest, filepath.Dir(p)); err != nil {
+		return err
+	}
+	if _, ok := l.extracted[p]; ok || p == l.dest {
+		return nil
+	}
+
+	if l.mode == LayerOverlay {
+		if err := mkdirAll(l.dest, filepath.Dir(p), res); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("failed to remove existing file: %w", err)
+		}
+		return mkOverlayWhiteout(p)
+	}
+
+	// RemoveAll doesn't follow symbolic links.
+	if err := os.RemoveAll(p); err != nil {
+		return fmt.Errorf("failed to remove whited out file: %w", err)
+	}
+	return nil
+}
+
+// opaque removes the contents of lower layers from the directory dir.
+func (l *layer) opaque(dir string, res *ExtractResult) error {
+	p, err := sanitizeArchivePath(l.dest, dir)
+	if err != nil {
+		return err
+	}
+	if err := checkNoSymlinks(l.dest, p); err != nil {
+		return err
+	}
+
+	if l.mode == LayerOverlay {
+		if err := mkdirAll(l.dest, p, res); err != nil {
+			return err
+		}
+		return setOverlayOpaque(p)
+	}
+
+	return l.removeLower(p)
+}
+
+// removeLower removes the contents of the directory dir that weren't
+// extracted from the layer.
+func (l *layer) removeLower(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if _, ok := l.extracted[p]; !ok {
+			if err := os.RemoveAll(p); err != nil {
+				return fmt.Errorf("failed to remove opaque directory contents: %w", err)
+			}
+			continue
+		}
+
+		if e.IsDir() {
+			if err := l.removeLower(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// add records that path was extracted from the layer.
+func (l *layer) add(p string) {
+	for ; p != l.dest && strings.HasPrefix(p, l.dest); p = filepath.Dir(p) {
+		if _, ok := l.extracted[p]; ok {
+			return
+		}
+		l.extracted[p] = struct{}{}
+	}
+}
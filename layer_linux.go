@@ -0,0 +1,41 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// mkOverlayWhiteout creates an overlayfs whiteout at path, a character
+// device with device number 0/0.
+func mkOverlayWhiteout(path string) error {
+	if err := syscall.Mknod(path, syscall.S_IFCHR, 0); err != nil {
+		return fmt.Errorf("failed to create overlayfs whiteout: %w", err)
+	}
+	return nil
+}
+
+// setOverlayOpaque marks the directory dir as opaque for overlayfs.
+func setOverlayOpaque(dir string) error {
+	if err := syscall.Setxattr(dir, "trusted.overlay.opaque", []byte("y"), 0); err != nil {
+		return fmt.Errorf("failed to mark directory as opaque: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package archives_test
+
+import (
+	stdtar "archive/tar"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestExtractLayerOverlay(t *testing.T) {
+	dest := t.TempDir()
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "etc/.wh.passwd", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "opt/", Typeflag: stdtar.TypeDir, Mode: 0o755},
+		&stdtar.Header{Name: "opt/.wh..wh..opq", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), dest, archives.ExtractOptions{Extension: ".tar", Layer: archives.LayerOverlay})
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOTSUP) {
+		t.Skip("creating overlayfs whiteouts requires privileges")
+	}
+	assert.NilError(t, err)
+
+	fi, err := os.Lstat(filepath.Join(dest, "etc", "passwd"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Type(), os.ModeDevice|os.ModeCharDevice)
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	assert.Assert(t, ok)
+	assert.Assert(t, st.Rdev == 0)
+
+	buf := make([]byte, 1)
+	n, err := syscall.Getxattr(filepath.Join(dest, "opt"), "trusted.overlay.opaque", buf)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf[:n]), "y")
+
+	assert.DeepEqual(t, listTree(t, dest), []string{"etc/", "etc/passwd", "opt/"})
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+//go:build !linux
+
+package archives
+
+import "fmt"
+
+// mkOverlayWhiteout creates an overlayfs whiteout at path. overlayfs
+// is only supported on Linux.
+func mkOverlayWhiteout(_ string) error {
+	return fmt.Errorf("%w: overlayfs whiteouts are only supported on Linux", ErrUnsupportedEntryType)
+}
+
+// setOverlayOpaque marks the directory dir as opaque for overlayfs.
+// overlayfs is only supported on Linux.
+func setOverlayOpaque(_ string) error {
+	return fmt.Errorf("%w: overlayfs whiteouts are only supported on Linux", ErrUnsupportedEntryType)
+}
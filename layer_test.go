@@ -0,0 +1,88 @@
+package archives_test
+
+import (
+	stdtar "archive/tar"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"gotest.tools/v3/assert"
+)
+
+// listTree returns the paths of the files in dir, relative to it, with
+// a trailing slash for directories.
+func listTree(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			rel += "/"
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	assert.NilError(t, err)
+	return paths
+}
+
+func TestExtractLayerApply(t *testing.T) {
+	dest := t.TempDir()
+	opts := archives.ExtractOptions{Extension: ".tar", Layer: archives.LayerApply}
+
+	assert.NilError(t, archives.Extract(createTar(t,
+		&stdtar.Header{Name: "etc/", Typeflag: stdtar.TypeDir, Mode: 0o755},
+		&stdtar.Header{Name: "etc/a", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "etc/b", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "opt/keep", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "opt/x/old", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "var/replaced/file", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), dest, opts))
+
+	assert.NilError(t, archives.Extract(createTar(t,
+		// Whiteouts only apply to lower layers.
+		&stdtar.Header{Name: "etc/c", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "etc/.wh.c", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "etc/.wh.a", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "etc/.wh..wh.plnk", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "opt/x/new", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "opt/.wh..wh..opq", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "opt/new", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "var/replaced", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), dest, opts))
+
+	assert.DeepEqual(t, listTree(t, dest), []string{
+		"etc/", "etc/b", "etc/c", "opt/", "opt/new", "opt/x/", "opt/x/new", "var/", "var/replaced",
+	})
+}
+
+func TestExtractLayerInvalidWhiteout(t *testing.T) {
+	err := archives.Extract(createTar(t,
+		&stdtar.Header{Name: "etc/.wh..", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), t.TempDir(), archives.ExtractOptions{Extension: ".tar", Layer: archives.LayerApply})
+	assert.ErrorIs(t, err, archives.ErrCorrupt)
+
+	err = archives.Extract(createTar(t,
+		&stdtar.Header{Name: "../.wh.etc", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), filepath.Join(t.TempDir(), "dest"), archives.ExtractOptions{Extension: ".tar", Layer: archives.LayerApply})
+	assert.ErrorIs(t, err, archives.ErrPathTraversal)
+}
+
+func TestExtractLayerApplyNotAtomic(t *testing.T) {
+	err := archives.Extract(createTar(t), t.TempDir(), archives.ExtractOptions{
+		Extension: ".tar",
+		Layer:     archives.LayerApply,
+		Atomic:    true,
+	})
+	assert.ErrorContains(t, err, "atomically")
+}
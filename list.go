@@ -0,0 +1,185 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// WalkFn is called by [Walk] for every entry of an archive.
+type WalkFn func(h *Header) error
+
+// Walk calls fn for every entry of a, in the order they are stored. fn
+// may read the contents of the entry from a, contents that aren't read
+// are skipped without being decompressed where the format allows it
+// (e.g., zip), or discarded otherwise (e.g., tar).
+//
+// If fn returns an error, Walk stops and returns it, unless it is
+// [fs.SkipAll], in which case Walk returns nil.
+func Walk(a Archive, fn WalkFn) error {
+	for {
+		h, err := a.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to read archive header: %w", err)
+		}
+
+		if err := fn(h); err != nil {
+			if errors.Is(err, fs.SkipAll) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// List returns the headers of every entry of a, without reading their
+// contents. See [Walk].
+func List(a Archive) ([]Header, error) {
+	var hdrs []Header
+	err := Walk(a, func(h *Header) error {
+		hdrs = append(hdrs, *h)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hdrs, nil
+}
+
+// ArchiveInfo contains information about an archive, as returned by
+// [Info].
+type ArchiveInfo struct {
+	// Format is the format of the archive (e.g., tar, zip or 7z). It is
+	// empty for single compressed files.
+	Format string `json:"format,omitempty"`
+
+	// Codec is the compression codec of the container wrapping the
+	// archive (e.g., gz for .tar.gz), or of single compressed files. It
+	// is empty if the archive isn't wrapped in a container.
+	Codec string `json:"codec,omitempty"`
+
+	// Entries is the number of entries in the archive.
+	Entries int `json:"entries"`
+
+	// Size is the total uncompressed size of the files in the archive.
+	Size int64 `json:"size"`
+
+	// CompressedSize is the size of the archive.
+	CompressedSize int64 `json:"compressedSize"`
+
+	// Ratio is the compression ratio of the archive, Size divided by
+	// CompressedSize. It is zero if the archive is empty.
+	Ratio float64 `json:"ratio"`
+
+	// Comment is the comment of the archive, if supported by the format
+	// (e.g., zip).
+	Comment string `json:"comment,omitempty"`
+}
+
+// commenter is implemented by archives whose format supports comments.
+type commenter interface {
+	Comment() string
+}
+
+// Info reads the archive from r and returns information about it. The
+// format is determined by opts.Extension, as done by [Open]. The
+// contents of files are only read if their size isn't stored in the
+// archive (e.g., single compressed files).
+func Info(r io.Reader, opts OpenOptions) (*ArchiveInfo, error) {
+	var compressedSize int64
+	cr := &countingReader{r: r, n: &compressedSize}
+	a, err := Open(cr, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close() //nolint:errcheck // Why: Best effort.
+
+	info := &ArchiveInfo{}
+	info.Format, info.Codec = archiveFormat(strings.TrimPrefix(opts.Extension, "."))
+
+	err = Walk(a, func(h *Header) error {
+		info.Entries++
+		if h.Type != HeaderFile {
+			return nil
+		}
+
+		if h.Size >= 0 {
+			info.Size += h.Size
+			return nil
+		}
+
+		n, err := io.Copy(io.Discard, a)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", h.Name, err)
+		}
+		info.Size += n
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := a.(commenter); ok {
+		info.Comment = c.Comment()
+	}
+
+	// Formats may not read all the way to the end of the archive (e.g.,
+	// tar padding).
+	if _, err := io.Copy(io.Discard, cr); err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	info.CompressedSize = compressedSize
+	if info.CompressedSize > 0 {
+		info.Ratio = float64(info.Size) / float64(info.CompressedSize)
+	}
+
+	return info, nil
+}
+
+// archiveFormat returns the format and codec of archives with the
+// provided extension, which must be supported.
+func archiveFormat(ext string) (format, codec string) {
+	switch archiver := extensions[ext].(type) {
+	case *tar:
+		return "tar", tarCodec(ext)
+	case *compressed:
+		return "", ext
+	case nil:
+		return "", ""
+	default:
+		// Only extensions of the form <format>.<codec> (e.g., cpio.gz)
+		// are compressed, other aliases (e.g., a for ar) aren't.
+		format = archiver.Extensions()[0]
+		if codec, ok := strings.CutPrefix(ext, format+"."); ok {
+			return format, codec
+		}
+		return format, ""
+	}
+}
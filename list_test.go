@@ -0,0 +1,142 @@
+package archives_test
+
+import (
+	stdzip "archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/artest"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"gotest.tools/v3/assert"
+)
+
+func TestList(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "a.txt", Contents: []byte("hello")},
+		// The contents of entries are not decompressed while listing.
+		{Name: "corrupt.txt", Contents: []byte("hello"), Method: stdzip.Deflate, Compressed: []byte{0xff, 0xff}},
+		{Name: "dir/b.txt", Contents: []byte("hello world")},
+	})
+	assert.NilError(t, err)
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	hdrs, err := archives.List(a)
+	assert.NilError(t, err)
+
+	var names []string
+	for _, h := range hdrs {
+		names = append(names, h.Name)
+	}
+	assert.DeepEqual(t, names, []string{"a.txt", "corrupt.txt", "dir/b.txt"})
+	assert.Equal(t, hdrs[2].Size, int64(11))
+}
+
+func TestWalk(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "a.txt", Contents: []byte("hello")},
+		{Name: "b.txt", Contents: []byte("world")},
+		{Name: "c.txt", Contents: []byte("!")},
+	})
+	assert.NilError(t, err)
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	var got []string
+	err = archives.Walk(a, func(h *archives.Header) error {
+		if h.Name == "c.txt" {
+			return fs.SkipAll
+		}
+
+		b, err := io.ReadAll(a)
+		if err != nil {
+			return err
+		}
+		got = append(got, string(b))
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, []string{"hello", "world"})
+}
+
+func TestWalkError(t *testing.T) {
+	r, err := tartest.Create()
+	assert.NilError(t, err)
+
+	a, err := archives.Open(r, archives.OpenOptions{Extension: ".tar"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	errStop := errors.New("stop")
+	err = archives.Walk(a, func(*archives.Header) error { return errStop })
+	assert.ErrorIs(t, err, errStop)
+}
+
+func TestInfo(t *testing.T) {
+	tgz, err := tartest.Create(tartest.WithContainer(tartest.ContainerGz))
+	assert.NilError(t, err)
+	tgzBytes, err := io.ReadAll(tgz)
+	assert.NilError(t, err)
+
+	zipBuf := new(bytes.Buffer)
+	zw := stdzip.NewWriter(zipBuf)
+	for _, name := range []string{"dir/", "dir/a.txt"} {
+		w, err := zw.Create(name)
+		assert.NilError(t, err)
+		if name == "dir/a.txt" {
+			_, err = w.Write(bytes.Repeat([]byte("a"), 1000))
+			assert.NilError(t, err)
+		}
+	}
+	assert.NilError(t, zw.SetComment("release 1.0"))
+	assert.NilError(t, zw.Close())
+
+	arBytes, err := artest.Create([]artest.File{{Name: "a.o", Contents: []byte("hello"), Mode: 0o644}})
+	assert.NilError(t, err)
+
+	gzBuf := new(bytes.Buffer)
+	gw := gzip.NewWriter(gzBuf)
+	_, err = gw.Write([]byte("hello world"))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	tests := []struct {
+		name string
+		data []byte
+		opts archives.OpenOptions
+		want archives.ArchiveInfo
+	}{
+		{"tar.gz", tgzBytes, archives.OpenOptions{Extension: ".tar.gz"}, archives.ArchiveInfo{
+			Format: "tar", Codec: "gz", Entries: 1, Size: 11,
+		}},
+		{"zip", zipBuf.Bytes(), archives.OpenOptions{Extension: ".zip"}, archives.ArchiveInfo{
+			Format: "zip", Entries: 2, Size: 1000, Comment: "release 1.0",
+		}},
+		{"a", arBytes, archives.OpenOptions{Extension: ".a"}, archives.ArchiveInfo{
+			Format: "ar", Entries: 1, Size: 5,
+		}},
+		{"gz", gzBuf.Bytes(), archives.OpenOptions{Extension: ".gz", Name: "file.gz"}, archives.ArchiveInfo{
+			Codec: "gz", Entries: 1, Size: 11,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := archives.Info(bytes.NewReader(tt.data), tt.opts)
+			assert.NilError(t, err)
+
+			tt.want.CompressedSize = int64(len(tt.data))
+			tt.want.Ratio = float64(tt.want.Size) / float64(len(tt.data))
+			assert.DeepEqual(t, *info, tt.want)
+		})
+	}
+}
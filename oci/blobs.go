@@ -0,0 +1,154 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package oci
+
+import (
+	stdtar "archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// blobStore provides access to the files of an image.
+type blobStore interface {
+	// open opens the file with the provided slash-separated name.
+	open(name string) (io.ReadCloser, error)
+
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// dirBlobs is a [blobStore] reading the files of an image from a
+// directory.
+type dirBlobs string
+
+// open implements [blobStore].
+func (d dirBlobs) open(name string) (io.ReadCloser, error) {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%w: %s", archives.ErrPathTraversal, name)
+	}
+
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// Close implements [blobStore].
+func (dirBlobs) Close() error {
+	return nil
+}
+
+// tarBlobs is a [blobStore] reading the files of an image from an
+// uncompressed tarball, which is indexed when opened so that files can
+// be read in any order.
+type tarBlobs struct {
+	f     *os.File
+	files map[string]*stdtar.Header
+
+	// offsets contains the offsets of the contents of files.
+	offsets map[string]int64
+}
+
+// openTarBlobs opens and indexes the tarball at path.
+func openTarBlobs(path string) (*tarBlobs, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tarBlobs{f: f, files: map[string]*stdtar.Header{}, offsets: map[string]int64{}}
+	if err := t.index(); err != nil {
+		f.Close() //nolint:errcheck,gosec // Why: Best effort.
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// index reads the headers of all files in the tarball.
+func (t *tarBlobs) index() error {
+	fi, err := t.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// archive/tar seeks over the contents of files, and reads nothing
+	// but the headers, so the offset after reading a header is the
+	// offset of the contents.
+	sr := io.NewSectionReader(t.f, 0, fi.Size())
+	tr := stdtar.NewReader(sr)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: failed to read image tarball: %w", archives.ErrCorrupt, err)
+		}
+
+		off, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		name := cleanName(h.Name)
+		t.files[name] = h
+		t.offsets[name] = off
+	}
+}
+
+// open implements [blobStore]. Links are followed.
+func (t *tarBlobs) open(name string) (io.ReadCloser, error) {
+	name = cleanName(name)
+	for range 16 {
+		h, ok := t.files[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+		}
+
+		switch h.Typeflag {
+		case stdtar.TypeReg:
+			return io.NopCloser(io.NewSectionReader(t.f, t.offsets[name], h.Size)), nil
+		case stdtar.TypeSymlink:
+			// docker save links layers that are identical to others.
+			name = cleanName(path.Join(path.Dir(name), h.Linkname))
+		case stdtar.TypeLink:
+			name = cleanName(h.Linkname)
+		default:
+			return nil, fmt.Errorf("%w: %s is not a file", archives.ErrUnsupportedEntryType, name)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: too many levels of links for %s", archives.ErrCorrupt, name)
+}
+
+// Close implements [blobStore].
+func (t *tarBlobs) Close() error {
+	return t.f.Close()
+}
+
+// cleanName cleans the name of a file in a tarball.
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,586 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package oci reads container images stored in an OCI image layout,
+// either as a directory or a tarball, or in a tarball created by
+// "docker save", exposing their layers as archives.
+package oci
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"go.rgst.io/jaredallard/archives/v2"
+)
+
+// Contains the media types of image indexes and manifests.
+const (
+	mediaTypeIndex          = "application/vnd.oci.image.index.v1+json"
+	mediaTypeManifest       = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// Contains the annotations used to select images by reference.
+const (
+	annotationRefName   = "org.opencontainers.image.ref.name"
+	annotationImageName = "io.containerd.image.name"
+)
+
+// maxIndexDepth is the maximum number of nested image indexes followed
+// when resolving an image.
+const maxIndexDepth = 8
+
+// Options configures how an image is selected by [Open].
+type Options struct {
+	// Ref selects the image by reference if the image layout or tarball
+	// contains more than one. It is matched against the
+	// "org.opencontainers.image.ref.name" and "io.containerd.image.name"
+	// annotations of OCI image layouts (e.g., "latest" or
+	// "docker.io/library/alpine:latest"), and against the tags of images
+	// created by docker save (e.g., "alpine:latest").
+	Ref string
+
+	// Platform selects the image of multi-platform images, in the form
+	// os/architecture[/variant] (e.g., "linux/arm64/v8").
+	Platform string
+}
+
+// Image is a container image, see [Open].
+type Image struct {
+	// Layers contains the layers of the image, from the lowest to the
+	// highest.
+	Layers []*Layer
+
+	blobs blobStore
+}
+
+// Layer is a layer of an [Image].
+type Layer struct {
+	// MediaType is the media type of the layer. Empty for images created
+	// by docker save without an OCI image layout, whose compression is
+	// detected instead.
+	MediaType string
+
+	// Digest is the digest of the layer (e.g., "sha256:..."), if known.
+	// The layer is verified against it once read to the end.
+	Digest string
+
+	// Size is the size of the layer, if known, or -1.
+	Size int64
+
+	name  string
+	blobs blobStore
+}
+
+// descriptor is an OCI content descriptor.
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *platform         `json:"platform,omitempty"`
+}
+
+// platform is the platform of an image in an image index.
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// index is an OCI image index.
+type index struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// manifest is an OCI image manifest.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+}
+
+// dockerManifest is an image in the manifest.json of tarballs created by
+// docker save.
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// Open opens the container image at path, an OCI image layout directory
+// or a tarball containing an OCI image layout or created by docker
+// save. The image must be closed once done.
+func Open(path string, opts Options) (*Image, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs blobStore
+	if fi.IsDir() {
+		blobs = dirBlobs(path)
+	} else if blobs, err = openTarBlobs(path); err != nil {
+		return nil, err
+	}
+
+	img, err := open(blobs, &opts)
+	if err != nil {
+		blobs.Close() //nolint:errcheck,gosec // Why: Best effort.
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// open reads the image selected by opts from blobs.
+func open(blobs blobStore, opts *Options) (*Image, error) {
+	b, err := readFile(blobs, "index.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		// Images created by docker save before version 25 only contain
+		// a manifest.json.
+		return openDocker(blobs, opts)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var idx index
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return nil, fmt.Errorf("%w: invalid index.json: %w", archives.ErrCorrupt, err)
+	}
+
+	m, err := resolve(blobs, &idx, opts, opts.Ref, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{blobs: blobs}
+	for _, l := range m.Layers {
+		name, err := blobPath(l.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		img.Layers = append(img.Layers, &Layer{
+			MediaType: l.MediaType,
+			Digest:    l.Digest,
+			Size:      l.Size,
+			name:      name,
+			blobs:     blobs,
+		})
+	}
+
+	return img, nil
+}
+
+// resolve returns the manifest of the image in idx selected by opts,
+// descending into nested indexes up to maxIndexDepth deep. Images are
+// only selected by ref in the top level index.
+func resolve(blobs blobStore, idx *index, opts *Options, ref string, depth int) (*manifest, error) {
+	if depth > maxIndexDepth {
+		return nil, fmt.Errorf("%w: image indexes are nested more than %d deep", archives.ErrCorrupt, maxIndexDepth)
+	}
+
+	var candidates []descriptor
+	for _, d := range idx.Manifests {
+		switch d.MediaType {
+		case mediaTypeIndex, mediaTypeDockerList, mediaTypeManifest, mediaTypeDockerManifest:
+		default:
+			continue
+		}
+
+		// Attestations (e.g., by buildkit) use an unknown platform.
+		if d.Platform != nil && d.Platform.OS == "unknown" {
+			continue
+		}
+		if ref != "" && d.Annotations[annotationRefName] != ref && d.Annotations[annotationImageName] != ref {
+			continue
+		}
+		if opts.Platform != "" && d.Platform != nil && !matchPlatform(d.Platform, opts.Platform) {
+			continue
+		}
+
+		candidates = append(candidates, d)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w: no image matches", archives.ErrNotFound)
+	case 1:
+	default:
+		return nil, fmt.Errorf("found %d images, select one using Options.Ref or Options.Platform", len(candidates))
+	}
+
+	d := candidates[0]
+	b, err := readBlob(blobs, d.Digest, d.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	switch d.MediaType {
+	case mediaTypeIndex, mediaTypeDockerList:
+		var nested index
+		if err := json.Unmarshal(b, &nested); err != nil {
+			return nil, fmt.Errorf("%w: invalid image index %s: %w", archives.ErrCorrupt, d.Digest, err)
+		}
+		return resolve(blobs, &nested, opts, "", depth+1)
+	default:
+		var m manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("%w: invalid image manifest %s: %w", archives.ErrCorrupt, d.Digest, err)
+		}
+		return &m, nil
+	}
+}
+
+// matchPlatform returns true if p matches want, in the form
+// os/architecture[/variant].
+func matchPlatform(p *platform, want string) bool {
+	parts := strings.SplitN(want, "/", 3)
+	if len(parts) < 2 || parts[0] != p.OS || parts[1] != p.Architecture {
+		return false
+	}
+	return len(parts) == 2 || parts[2] == p.Variant
+}
+
+// openDocker reads the image selected by opts from the manifest.json of
+// tarballs created by docker save.
+func openDocker(blobs blobStore, opts *Options) (*Image, error) {
+	b, err := readFile(blobs, "manifest.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: neither index.json nor manifest.json found", archives.ErrUnsupportedFormat)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var manifests []dockerManifest
+	if err := json.Unmarshal(b, &manifests); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest.json: %w", archives.ErrCorrupt, err)
+	}
+
+	var candidates []dockerManifest
+	for _, m := range manifests {
+		if opts.Ref == "" || slices.Contains(m.RepoTags, opts.Ref) {
+			candidates = append(candidates, m)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w: no image matches", archives.ErrNotFound)
+	case 1:
+	default:
+		return nil, fmt.Errorf("found %d images, select one using Options.Ref", len(candidates))
+	}
+
+	img := &Image{blobs: blobs}
+	for _, name := range candidates[0].Layers {
+		img.Layers = append(img.Layers, &Layer{Size: -1, name: name, blobs: blobs})
+	}
+	return img, nil
+}
+
+// Close closes the image. Layers can't be opened afterwards.
+func (i *Image) Close() error {
+	return i.blobs.Close()
+}
+
+// Open opens the layer as a tar archive. Its whiteout files aren't
+// interpreted, see [archives.ExtractOptions.Layer].
+func (l *Layer) Open() (archives.Archive, error) {
+	r, ext, err := l.open()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := archives.Open(r, archives.OpenOptions{Extension: ext})
+	if err != nil {
+		r.Close() //nolint:errcheck,gosec // Why: Best effort.
+		return nil, err
+	}
+
+	return &layerArchive{a, r}, nil
+}
+
+// open opens the blob of the layer, returning it and the extension of
+// its format.
+func (l *Layer) open() (io.ReadCloser, string, error) {
+	rc, err := l.blobs.open(l.name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	vr, err := newVerifier(rc, l.Digest, l.Size)
+	if err != nil {
+		rc.Close() //nolint:errcheck,gosec // Why: Best effort.
+		return nil, "", err
+	}
+
+	if l.MediaType != "" {
+		ext, err := layerExtension(l.MediaType)
+		if err != nil {
+			rc.Close() //nolint:errcheck,gosec // Why: Best effort.
+			return nil, "", err
+		}
+		return readCloser{vr, rc}, ext, nil
+	}
+
+	ext, r, err := archives.Detect(vr)
+	if errors.Is(err, archives.ErrUnsupportedFormat) {
+		// Empty layers only consist of the end of archive marker, which
+		// has no signature.
+		ext = ".tar"
+	} else if err != nil {
+		rc.Close() //nolint:errcheck,gosec // Why: Best effort.
+		return nil, "", err
+	}
+
+	return readCloser{r, rc}, ext, nil
+}
+
+// layerExtension returns the extension of layers with the provided
+// media type.
+func layerExtension(mediaType string) (string, error) {
+	switch mediaType {
+	case "application/vnd.oci.image.layer.v1.tar",
+		"application/vnd.oci.image.layer.nondistributable.v1.tar",
+		"application/vnd.docker.image.rootfs.diff.tar":
+		return ".tar", nil
+	case "application/vnd.oci.image.layer.v1.tar+gzip",
+		"application/vnd.oci.image.layer.nondistributable.v1.tar+gzip",
+		"application/vnd.docker.image.rootfs.diff.tar.gzip",
+		"application/vnd.docker.image.rootfs.foreign.diff.tar.gzip":
+		return ".tar.gz", nil
+	case "application/vnd.oci.image.layer.v1.tar+zstd",
+		"application/vnd.oci.image.layer.nondistributable.v1.tar+zstd":
+		return ".tar.zst", nil
+	default:
+		return "", fmt.Errorf("%w: layer media type %s", archives.ErrUnsupportedFormat, mediaType)
+	}
+}
+
+// Flatten extracts all layers of img into dest, from the lowest to the
+// highest, applying their whiteout files to yield the root filesystem
+// of the image. opts.Extension and opts.Layer are set for each layer,
+// Atomic and Verifier can't be used. Layers are verified against their
+// digest after being extracted.
+func Flatten(img *Image, dest string, opts archives.ExtractOptions) error {
+	opts.Layer = archives.LayerApply
+	for i, l := range img.Layers {
+		r, ext, err := l.open()
+		if err != nil {
+			return fmt.Errorf("failed to open layer %d: %w", i, err)
+		}
+
+		opts.Extension = ext
+		err = archives.Extract(r, dest, opts)
+		if err == nil {
+			// Read the remainder of the blob (e.g., tar padding) for it to
+			// be verified.
+			_, err = io.Copy(io.Discard, r)
+		}
+		r.Close() //nolint:errcheck,gosec // Why: Best effort.
+		if err != nil {
+			return fmt.Errorf("failed to extract layer %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// readBlob reads the blob with the provided digest and size, verifying
+// it against both.
+func readBlob(blobs blobStore, digest string, size int64) ([]byte, error) {
+	name, err := blobPath(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := blobs.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck // Why: Best effort.
+
+	vr, err := newVerifier(rc, digest, size)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := io.ReadAll(vr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+	return b, nil
+}
+
+// blobPath returns the path of the blob with the provided digest in an
+// OCI image layout.
+func blobPath(digest string) (string, error) {
+	if _, err := newDigestHash(digest); err != nil {
+		return "", err
+	}
+
+	alg, encoded, _ := strings.Cut(digest, ":")
+	return "blobs/" + alg + "/" + encoded, nil
+}
+
+// newDigestHash returns the hash used to compute digest, which must use
+// one of the algorithms registered by the OCI image specification.
+// Digests using other algorithms can't be verified and are rejected.
+func newDigestHash(digest string) (hash.Hash, error) {
+	alg, encoded, _ := strings.Cut(digest, ":")
+
+	var h hash.Hash
+	switch alg {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("%w: digest algorithm of %q", archives.ErrUnsupportedFormat, digest)
+	}
+
+	if len(encoded) != hex.EncodedLen(h.Size()) || strings.ToLower(encoded) != encoded {
+		return nil, fmt.Errorf("%w: invalid digest %q", archives.ErrCorrupt, digest)
+	}
+	if _, err := hex.DecodeString(encoded); err != nil {
+		return nil, fmt.Errorf("%w: invalid digest %q", archives.ErrCorrupt, digest)
+	}
+
+	return h, nil
+}
+
+// verifier is an [io.Reader] verifying that the blob read from r matches
+// a digest and size once it has been read to the end.
+type verifier struct {
+	r      io.Reader
+	digest string
+	h      hash.Hash
+
+	// size is the expected size, or -1 if unknown, and n the number of
+	// bytes read so far.
+	size int64
+	n    int64
+}
+
+// newVerifier returns a [verifier] reading from r. An empty digest
+// isn't verified.
+func newVerifier(r io.Reader, digest string, size int64) (*verifier, error) {
+	v := &verifier{r: r, digest: digest, size: size}
+	if digest != "" {
+		h, err := newDigestHash(digest)
+		if err != nil {
+			return nil, err
+		}
+		v.h = h
+	}
+
+	return v, nil
+}
+
+// Read implements [io.Reader].
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.n += int64(n)
+	if v.h != nil {
+		v.h.Write(p[:n]) //nolint:errcheck,gosec // Why: Hashes never fail.
+	}
+
+	if v.size >= 0 && v.n > v.size {
+		return n, fmt.Errorf("%w: blob %s is larger than %d bytes", archives.ErrCorrupt, v.digest, v.size)
+	}
+
+	if errors.Is(err, io.EOF) {
+		if v.size >= 0 && v.n != v.size {
+			return n, fmt.Errorf("%w: blob %s is %d bytes, expected %d", archives.ErrCorrupt, v.digest, v.n, v.size)
+		}
+
+		if v.h != nil {
+			_, encoded, _ := strings.Cut(v.digest, ":")
+			if hex.EncodeToString(v.h.Sum(nil)) != encoded {
+				return n, fmt.Errorf("%w: digest mismatch for blob %s", archives.ErrCorrupt, v.digest)
+			}
+		}
+	}
+
+	return n, err
+}
+
+// readFile reads the file name from blobs.
+func readFile(blobs blobStore, name string) ([]byte, error) {
+	rc, err := blobs.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck // Why: Best effort.
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// layerArchive is an [archives.Archive] of a layer that closes the blob
+// it is read from.
+type layerArchive struct {
+	archives.Archive
+	blob io.ReadCloser
+}
+
+// Next implements [archives.Archive]. Once the last entry has been read,
+// the remainder of the blob is read to verify it.
+func (a *layerArchive) Next() (*archives.Header, error) {
+	h, err := a.Archive.Next()
+	if errors.Is(err, io.EOF) {
+		if _, err := io.Copy(io.Discard, a.blob); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, err
+}
+
+// Close implements [archives.Archive].
+func (a *layerArchive) Close() error {
+	err := a.Archive.Close()
+	if cerr := a.blob.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readCloser combines a reader and the closer of the reader it reads
+// from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,428 @@
+package oci_test
+
+import (
+	stdtar "archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/oci"
+	"gotest.tools/v3/assert"
+)
+
+// createLayer creates an uncompressed layer containing the provided
+// files, whose contents are their names. Names ending with a slash are
+// directories.
+func createLayer(t *testing.T, names ...string) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	tw := stdtar.NewWriter(buf)
+	for _, name := range names {
+		h := &stdtar.Header{Name: name, Typeflag: stdtar.TypeReg, Mode: 0o644, Size: int64(len(name))}
+		if strings.HasSuffix(name, "/") {
+			h = &stdtar.Header{Name: name, Typeflag: stdtar.TypeDir, Mode: 0o755}
+		}
+		assert.NilError(t, tw.WriteHeader(h))
+
+		if h.Typeflag == stdtar.TypeReg {
+			_, err := tw.Write([]byte(name))
+			assert.NilError(t, err)
+		}
+	}
+	assert.NilError(t, tw.Close())
+
+	return buf.Bytes()
+}
+
+// compress compresses layer using the compression denoted by the suffix
+// of mediaType.
+func compress(t *testing.T, layer []byte, mediaType string) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	switch {
+	case strings.HasSuffix(mediaType, "+gzip"):
+		w := gzip.NewWriter(buf)
+		_, err := w.Write(layer)
+		assert.NilError(t, err)
+		assert.NilError(t, w.Close())
+	case strings.HasSuffix(mediaType, "+zstd"):
+		w, err := zstd.NewWriter(buf)
+		assert.NilError(t, err)
+		_, err = w.Write(layer)
+		assert.NilError(t, err)
+		assert.NilError(t, w.Close())
+	default:
+		return layer
+	}
+	return buf.Bytes()
+}
+
+// layout writes the blobs of an OCI image layout.
+type layout struct {
+	t   *testing.T
+	dir string
+}
+
+// blob writes b as a blob, returning its descriptor.
+func (l *layout) blob(mediaType string, b []byte) map[string]any {
+	sum := sha256.Sum256(b)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	p := filepath.Join(l.dir, "blobs", "sha256", hex.EncodeToString(sum[:]))
+	assert.NilError(l.t, os.MkdirAll(filepath.Dir(p), 0o755))
+	assert.NilError(l.t, os.WriteFile(p, b, 0o644))
+
+	return map[string]any{"mediaType": mediaType, "digest": digest, "size": len(b)}
+}
+
+// image writes the manifest of an image with the provided layers
+// (media type followed by file names), returning its descriptor.
+func (l *layout) image(layers ...[]string) map[string]any {
+	var descs []map[string]any
+	for _, layer := range layers {
+		descs = append(descs, l.blob(layer[0], compress(l.t, createLayer(l.t, layer[1:]...), layer[0])))
+	}
+
+	m, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        l.blob("application/vnd.oci.image.config.v1+json", []byte("{}")),
+		"layers":        descs,
+	})
+	assert.NilError(l.t, err)
+
+	return l.blob("application/vnd.oci.image.manifest.v1+json", m)
+}
+
+// index writes an image index containing the provided manifests. If top
+// is set, it is written as index.json.
+func (l *layout) index(top bool, manifests ...map[string]any) map[string]any {
+	b, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.index.v1+json",
+		"manifests":     manifests,
+	})
+	assert.NilError(l.t, err)
+
+	if top {
+		assert.NilError(l.t, os.WriteFile(filepath.Join(l.dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o644))
+		assert.NilError(l.t, os.WriteFile(filepath.Join(l.dir, "index.json"), b, 0o644))
+		return nil
+	}
+	return l.blob("application/vnd.oci.image.index.v1+json", b)
+}
+
+// with returns d with the provided key set.
+func with(d map[string]any, key string, value any) map[string]any {
+	d[key] = value
+	return d
+}
+
+// createImage creates an OCI image layout with a single image whose
+// layers use every supported compression.
+func createImage(t *testing.T) string {
+	t.Helper()
+
+	l := &layout{t, t.TempDir()}
+	l.index(true, with(l.image(
+		[]string{"application/vnd.oci.image.layer.v1.tar+gzip", "etc/", "etc/passwd", "etc/group", "opt/", "opt/old"},
+		[]string{"application/vnd.oci.image.layer.v1.tar+zstd", "etc/.wh.group", "opt/", "opt/.wh..wh..opq", "opt/new"},
+		[]string{"application/vnd.oci.image.layer.v1.tar", "usr/bin/tool"},
+	), "annotations", map[string]string{"org.opencontainers.image.ref.name": "latest"}))
+	return l.dir
+}
+
+// wantRootfs contains the files of the root filesystem of the image
+// created by createImage.
+var wantRootfs = []string{"etc/", "etc/passwd", "opt/", "opt/new", "usr/", "usr/bin/", "usr/bin/tool"}
+
+// tarDir creates a tarball of the contents of dir.
+func tarDir(t *testing.T, dir string) string {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	tw := stdtar.NewWriter(buf)
+	assert.NilError(t, tw.AddFS(os.DirFS(dir)))
+	assert.NilError(t, tw.Close())
+
+	p := filepath.Join(t.TempDir(), "image.tar")
+	assert.NilError(t, os.WriteFile(p, buf.Bytes(), 0o644))
+	return p
+}
+
+// listTree returns the paths of the files in dir, relative to it, with
+// a trailing slash for directories.
+func listTree(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			rel += "/"
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	assert.NilError(t, err)
+	return paths
+}
+
+func TestFlatten(t *testing.T) {
+	dir := createImage(t)
+	for name, path := range map[string]string{"dir": dir, "tarball": tarDir(t, dir)} {
+		t.Run(name, func(t *testing.T) {
+			img, err := oci.Open(path, oci.Options{})
+			assert.NilError(t, err)
+			defer img.Close()
+			assert.Equal(t, len(img.Layers), 3)
+
+			dest := t.TempDir()
+			assert.NilError(t, oci.Flatten(img, dest, archives.ExtractOptions{}))
+			assert.DeepEqual(t, listTree(t, dest), wantRootfs)
+
+			b, err := os.ReadFile(filepath.Join(dest, "opt", "new"))
+			assert.NilError(t, err)
+			assert.Equal(t, string(b), "opt/new")
+		})
+	}
+}
+
+func TestLayerOpen(t *testing.T) {
+	img, err := oci.Open(createImage(t), oci.Options{Ref: "latest"})
+	assert.NilError(t, err)
+	defer img.Close()
+
+	a, err := img.Layers[1].Open()
+	assert.NilError(t, err)
+	defer a.Close()
+
+	hdrs, err := archives.List(a)
+	assert.NilError(t, err)
+
+	var names []string
+	for _, h := range hdrs {
+		names = append(names, h.Name)
+	}
+	assert.DeepEqual(t, names, []string{"etc/.wh.group", "opt/", "opt/.wh..wh..opq", "opt/new"})
+	assert.Equal(t, img.Layers[1].MediaType, "application/vnd.oci.image.layer.v1.tar+zstd")
+}
+
+func TestOpenSelectsImage(t *testing.T) {
+	l := &layout{t, t.TempDir()}
+	amd64 := l.image([]string{"application/vnd.oci.image.layer.v1.tar", "amd64"})
+	arm64 := l.image([]string{"application/vnd.oci.image.layer.v1.tar", "arm64"})
+	l.index(true,
+		with(l.index(false,
+			with(amd64, "platform", map[string]string{"os": "linux", "architecture": "amd64"}),
+			with(arm64, "platform", map[string]string{"os": "linux", "architecture": "arm64", "variant": "v8"}),
+		), "annotations", map[string]string{"org.opencontainers.image.ref.name": "multi"}),
+		with(l.image([]string{"application/vnd.oci.image.layer.v1.tar", "other"}),
+			"annotations", map[string]string{"org.opencontainers.image.ref.name": "other"}),
+	)
+
+	_, err := oci.Open(l.dir, oci.Options{})
+	assert.ErrorContains(t, err, "found 2 images")
+
+	_, err = oci.Open(l.dir, oci.Options{Ref: "multi"})
+	assert.ErrorContains(t, err, "found 2 images")
+
+	_, err = oci.Open(l.dir, oci.Options{Ref: "missing"})
+	assert.ErrorIs(t, err, archives.ErrNotFound)
+
+	tests := []struct {
+		opts oci.Options
+		want string
+	}{
+		{oci.Options{Ref: "multi", Platform: "linux/amd64"}, "amd64"},
+		{oci.Options{Ref: "multi", Platform: "linux/arm64/v8"}, "arm64"},
+		{oci.Options{Ref: "other"}, "other"},
+	}
+	for _, tt := range tests {
+		img, err := oci.Open(l.dir, tt.opts)
+		assert.NilError(t, err)
+
+		dest := t.TempDir()
+		assert.NilError(t, oci.Flatten(img, dest, archives.ExtractOptions{}))
+		assert.NilError(t, img.Close())
+		assert.DeepEqual(t, listTree(t, dest), []string{tt.want})
+	}
+}
+
+func TestOpenDockerSave(t *testing.T) {
+	dir := t.TempDir()
+	for name, b := range map[string][]byte{
+		"a/layer.tar": createLayer(t, "etc/", "etc/passwd", "etc/group"),
+		"b/layer.tar": createLayer(t, "etc/.wh.group", "usr/"),
+		"c/layer.tar": createLayer(t, "usr/bin/tool"),
+	} {
+		assert.NilError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), b, 0o644))
+	}
+
+	// docker save links layers that are identical to earlier ones.
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "d"), 0o755))
+	assert.NilError(t, os.Symlink("../c/layer.tar", filepath.Join(dir, "d", "layer.tar")))
+
+	m, err := json.Marshal([]map[string]any{
+		{"Config": "config.json", "RepoTags": []string{"image:latest"}, "Layers": []string{"a/layer.tar", "b/layer.tar", "d/layer.tar"}},
+		{"Config": "config.json", "RepoTags": []string{"other:latest"}, "Layers": []string{"c/layer.tar"}},
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "manifest.json"), m, 0o644))
+
+	buf := new(bytes.Buffer)
+	tw := stdtar.NewWriter(buf)
+	assert.NilError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link, _ := os.Readlink(path) //nolint:errcheck // Why: Only set for symlinks.
+		h, err := stdtar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		h.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+
+		if fi.Mode().IsRegular() {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			_, err = tw.Write(b)
+			return err
+		}
+		return nil
+	}))
+	assert.NilError(t, tw.Close())
+
+	p := filepath.Join(t.TempDir(), "image.tar")
+	assert.NilError(t, os.WriteFile(p, buf.Bytes(), 0o644))
+
+	img, err := oci.Open(p, oci.Options{Ref: "image:latest"})
+	assert.NilError(t, err)
+	defer img.Close()
+
+	dest := t.TempDir()
+	assert.NilError(t, oci.Flatten(img, dest, archives.ExtractOptions{}))
+	assert.DeepEqual(t, listTree(t, dest), []string{"etc/", "etc/passwd", "usr/", "usr/bin/", "usr/bin/tool"})
+}
+
+func TestOpenDigestMismatch(t *testing.T) {
+	dir := createImage(t)
+
+	var idx struct {
+		Manifests []struct {
+			Digest string `json:"digest"`
+		} `json:"manifests"`
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(b, &idx))
+
+	manifest := filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(idx.Manifests[0].Digest, "sha256:"))
+	assert.NilError(t, os.WriteFile(manifest, []byte(`{"layers":[]}`), 0o644))
+
+	_, err = oci.Open(dir, oci.Options{})
+	assert.ErrorIs(t, err, archives.ErrCorrupt)
+}
+
+func TestOpenNestedIndexes(t *testing.T) {
+	l := &layout{t, t.TempDir()}
+	d := l.image([]string{"application/vnd.oci.image.layer.v1.tar", "file"})
+	for range 10 {
+		d = l.index(false, d)
+	}
+	l.index(true, d)
+
+	_, err := oci.Open(l.dir, oci.Options{})
+	assert.ErrorContains(t, err, "nested more than")
+}
+
+func TestOpenUnverifiableDigest(t *testing.T) {
+	tests := []struct {
+		digest string
+		want   error
+	}{
+		{"md5:d41d8cd98f00b204e9800998ecf8427e", archives.ErrUnsupportedFormat},
+		{"sha512:loop", archives.ErrCorrupt},
+		{"sha256:../../index.json", archives.ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.digest, func(t *testing.T) {
+			l := &layout{t, t.TempDir()}
+			l.index(true, map[string]any{
+				"mediaType": "application/vnd.oci.image.index.v1+json",
+				"digest":    tt.digest,
+				"size":      2,
+			})
+
+			_, err := oci.Open(l.dir, oci.Options{})
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestLayerDigestMismatch(t *testing.T) {
+	l := &layout{t, t.TempDir()}
+	l.index(true, l.image([]string{"application/vnd.oci.image.layer.v1.tar", "file"}))
+
+	// Flip a byte of the contents of the layer, keeping its size.
+	blobs, err := filepath.Glob(filepath.Join(l.dir, "blobs", "sha256", "*"))
+	assert.NilError(t, err)
+	for _, p := range blobs {
+		b, err := os.ReadFile(p)
+		assert.NilError(t, err)
+		if !bytes.Contains(b, []byte("ustar")) {
+			continue
+		}
+
+		b[bytes.LastIndex(b, []byte("file"))] ^= 0xff
+		assert.NilError(t, os.WriteFile(p, b, 0o644))
+	}
+
+	img, err := oci.Open(l.dir, oci.Options{})
+	assert.NilError(t, err)
+	defer img.Close()
+
+	err = oci.Flatten(img, t.TempDir(), archives.ExtractOptions{})
+	assert.ErrorIs(t, err, archives.ErrCorrupt)
+
+	a, err := img.Layers[0].Open()
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = archives.Pick(a, archives.PickFilterByName("missing"))
+	assert.ErrorIs(t, err, archives.ErrCorrupt)
+}
@@ -0,0 +1,234 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrLimitExceeded is returned when an archive exceeds the limits set
+// in [RecurseOptions] or [ExtractOptions], such as archive bombs.
+var ErrLimitExceeded = errors.New("archive exceeds limits")
+
+// NestedSeparator separates the name of a nested archive from the names
+// of its entries, e.g. inner.tar.gz!/bin/tool.
+const NestedSeparator = "!/"
+
+// RecurseOptions configures [Recurse].
+type RecurseOptions struct {
+	// MaxDepth is the maximum depth of nested archives to descend into.
+	// Archives contained in the archive passed to [Recurse] have a depth
+	// of one. If zero, nested archives are returned as regular files.
+	MaxDepth int
+
+	// MaxEntries, if greater than zero, is the maximum number of entries
+	// read across all levels, including the nested archives themselves.
+	MaxEntries int
+
+	// MaxSize, if greater than zero, is the maximum number of bytes read
+	// from the contents of entries across all levels. The contents of
+	// nested archives are not counted, as they are counted as the
+	// contents of their entries instead, but each nested archive may not
+	// be larger than the remaining size.
+	MaxSize int64
+
+	// OpenOptions are the options used to open nested archives. The
+	// Extension and Name are set to those of each nested archive.
+	OpenOptions OpenOptions
+}
+
+// Recurse returns an [Archive] returning the entries of a, descending
+// into entries that are archives themselves (see [Ext]) up to
+// opts.MaxDepth levels deep. Nested archives are replaced by their
+// entries, named after the nested archive followed by
+// [NestedSeparator], e.g. outer.zip!/inner.tar.gz!/bin/tool for the file
+// bin/tool of inner.tar.gz contained in outer.zip contained in a.
+//
+// The limits of opts are applied across all levels, returning an error
+// wrapping [ErrLimitExceeded] once exceeded. Nested archives that fail
+// to open are returned as an [*EntryError] containing their header, as
+// their contents have already been consumed. Next can be called again
+// to skip them. Closing the returned archive closes a.
+func Recurse(a Archive, opts RecurseOptions) Archive {
+	r := &recursiveArchive{opts: opts}
+	r.stack = []nestedArchive{{a: a}}
+	return r
+}
+
+// nestedArchive is an archive being read by a [recursiveArchive].
+type nestedArchive struct {
+	a Archive
+
+	// prefix is prepended to the names of the entries of the archive.
+	prefix string
+}
+
+// recursiveArchive is the [Archive] returned by [Recurse].
+type recursiveArchive struct {
+	opts RecurseOptions
+
+	// stack contains the archives being read, the outermost first.
+	stack []nestedArchive
+
+	// cur is the reader of the contents of the current entry.
+	cur *limitReader
+
+	// entries and size are the number of entries and bytes read so far.
+	entries int
+	size    int64
+}
+
+// Read implements [io.Reader].
+func (r *recursiveArchive) Read(p []byte) (int, error) {
+	if r.cur == nil {
+		return 0, io.EOF
+	}
+
+	return r.cur.Read(p)
+}
+
+// skipHole implements [holeSkipper].
+func (r *recursiveArchive) skipHole(n int64) error {
+	if r.cur == nil {
+		return io.EOF
+	}
+
+	return r.cur.skipHole(n)
+}
+
+// Next implements [Archive].
+func (r *recursiveArchive) Next() (*Header, error) {
+	r.cur = nil
+	for {
+		top := r.stack[len(r.stack)-1]
+		h, err := top.a.Next()
+		if errors.Is(err, io.EOF) && len(r.stack) > 1 {
+			r.stack = r.stack[:len(r.stack)-1]
+			if err := top.a.Close(); err != nil {
+				return nil, fmt.Errorf("failed to close nested archive %s: %w", strings.TrimSuffix(top.prefix, NestedSeparator), err)
+			}
+			continue
+		}
+		if err != nil {
+			if top.prefix != "" && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("failed to read nested archive %s: %w", strings.TrimSuffix(top.prefix, NestedSeparator), err)
+			}
+			return nil, err
+		}
+
+		r.entries++
+		if r.opts.MaxEntries > 0 && r.entries > r.opts.MaxEntries {
+			return nil, fmt.Errorf("%w: more than %d entries", ErrLimitExceeded, r.opts.MaxEntries)
+		}
+
+		name := top.prefix + h.Name
+		if r.opts.MaxSize > 0 && h.Size > r.opts.MaxSize-r.size {
+			return nil, fmt.Errorf("%w: %s is larger than the remaining %d bytes", ErrLimitExceeded, name, r.opts.MaxSize-r.size)
+		}
+
+		if h.Type == HeaderFile && len(r.stack) <= r.opts.MaxDepth && Supported(Ext(h.Name)) {
+			opts := r.opts.OpenOptions
+			opts.Extension = Ext(h.Name)
+			opts.Name = h.Name
+
+			// The nested archive is read as it is, without counting towards
+			// the size of its entries.
+			nested, err := Open(&limitReader{r: top.a, archive: r, name: name}, opts)
+			if err != nil {
+				h.Name = name
+				return nil, &EntryError{Header: h, Err: fmt.Errorf("failed to open nested archive: %w", err)}
+			}
+
+			r.stack = append(r.stack, nestedArchive{a: nested, prefix: name + NestedSeparator})
+			continue
+		}
+
+		h.Name = name
+		if h.Type == HeaderHardlink {
+			h.Linkname = top.prefix + h.Linkname
+		}
+
+		r.cur = &limitReader{r: top.a, archive: r, name: name, count: true}
+		return h, nil
+	}
+}
+
+// Close closes all archives being read.
+func (r *recursiveArchive) Close() error {
+	var errs []error
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		errs = append(errs, r.stack[i].a.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// limitReader enforces the MaxSize limit of a [recursiveArchive] while
+// reading the contents of an entry.
+type limitReader struct {
+	r       io.Reader
+	archive *recursiveArchive
+	name    string
+
+	// count is true if the bytes read count towards the size of the
+	// archive, n is the number of bytes read otherwise.
+	count bool
+	n     int64
+}
+
+// Read implements [io.Reader].
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	return n, l.add(int64(n), err)
+}
+
+// skipHole implements [holeSkipper]. Holes count towards the size of
+// the archive like the zeros they're read as.
+func (l *limitReader) skipHole(n int64) error {
+	s, ok := l.r.(holeSkipper)
+	if !ok {
+		_, err := io.CopyN(io.Discard, l, n)
+		return err
+	}
+
+	if err := s.skipHole(n); err != nil {
+		return err
+	}
+	return l.add(n, nil)
+}
+
+// add records that n bytes were read, returning err unless the limit
+// has been exceeded.
+func (l *limitReader) add(n int64, err error) error {
+	read := &l.n
+	if l.count {
+		read = &l.archive.size
+	}
+	*read += n
+
+	maxSize := l.archive.opts.MaxSize
+	if maxSize > 0 && l.archive.size+l.n > maxSize {
+		return fmt.Errorf("%w: %s exceeds the maximum size of %d bytes", ErrLimitExceeded, l.name, maxSize)
+	}
+
+	return err
+}
@@ -0,0 +1,182 @@
+package archives_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"gotest.tools/v3/assert"
+)
+
+// createNested creates outer.zip, containing a file, inner.tar.gz and
+// inner.zip, which contains deeper.tar.gz. Both tar archives contain
+// file.txt.
+func createNested(t *testing.T) []byte {
+	t.Helper()
+
+	r, err := tartest.Create(tartest.WithContainer(tartest.ContainerGz))
+	assert.NilError(t, err)
+	tgz, err := io.ReadAll(r)
+	assert.NilError(t, err)
+
+	inner, err := ziptest.Create([]ziptest.File{
+		{Name: "deeper.tar.gz", Contents: tgz},
+	})
+	assert.NilError(t, err)
+
+	outer, err := ziptest.Create([]ziptest.File{
+		{Name: "readme.txt", Contents: []byte("hello")},
+		{Name: "inner.tar.gz", Contents: tgz},
+		{Name: "inner.zip", Contents: inner},
+	})
+	assert.NilError(t, err)
+	return outer
+}
+
+func TestRecurse(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDepth int
+		want     []string
+	}{
+		{"depth-0", 0, []string{"readme.txt", "inner.tar.gz", "inner.zip"}},
+		{"depth-1", 1, []string{"readme.txt", "inner.tar.gz!/file.txt", "inner.zip!/deeper.tar.gz"}},
+		{"depth-2", 2, []string{"readme.txt", "inner.tar.gz!/file.txt", "inner.zip!/deeper.tar.gz!/file.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := archives.Open(bytes.NewReader(createNested(t)), archives.OpenOptions{Extension: ".zip"})
+			assert.NilError(t, err)
+
+			ra := archives.Recurse(a, archives.RecurseOptions{MaxDepth: tt.maxDepth})
+			defer ra.Close()
+
+			names := slices.Sorted(maps.Keys(readAll(t, ra)))
+			assert.DeepEqual(t, names, slices.Sorted(slices.Values(tt.want)))
+		})
+	}
+}
+
+func TestRecursePick(t *testing.T) {
+	a, err := archives.Open(bytes.NewReader(createNested(t)), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+
+	ra := archives.Recurse(a, archives.RecurseOptions{MaxDepth: 2})
+	defer ra.Close()
+
+	r, err := archives.Pick(ra, archives.PickFilterByName("inner.zip!/deeper.tar.gz!/file.txt"))
+	assert.NilError(t, err)
+
+	got, err := io.ReadAll(r)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello world")
+}
+
+func TestRecurseLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		opts archives.RecurseOptions
+	}{
+		// 3 entries in outer.zip, 1 in inner.tar.gz and 1 in inner.zip.
+		{"entries", archives.RecurseOptions{MaxDepth: 2, MaxEntries: 5}},
+		// The contents of the files are 5 + 11 + 11 bytes.
+		{"size", archives.RecurseOptions{MaxDepth: 2, MaxSize: 26}},
+		// Without descending, inner.zip is larger than the remaining size.
+		{"nested-size", archives.RecurseOptions{MaxSize: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := archives.Open(bytes.NewReader(createNested(t)), archives.OpenOptions{Extension: ".zip"})
+			assert.NilError(t, err)
+
+			ra := archives.Recurse(a, tt.opts)
+			defer ra.Close()
+
+			var lastErr error
+			for {
+				if _, err := ra.Next(); err != nil {
+					lastErr = err
+					break
+				}
+				if _, err := io.Copy(io.Discard, ra); err != nil {
+					lastErr = err
+					break
+				}
+			}
+			assert.Assert(t, errors.Is(lastErr, archives.ErrLimitExceeded), "unexpected error: %v", lastErr)
+		})
+	}
+}
+
+func TestExtractRecurse(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(createNested(t)), dir, archives.ExtractOptions{
+		Extension: ".zip",
+		Recurse:   2,
+	}))
+
+	for _, name := range []string{"inner.tar.gz!/file.txt", "inner.zip!/deeper.tar.gz!/file.txt"} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), "hello world")
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "inner.zip"))
+	assert.Assert(t, os.IsNotExist(err), "nested archive was written: %v", err)
+}
+
+func TestExtractMaxSize(t *testing.T) {
+	err := archives.Extract(bytes.NewReader(createNested(t)), t.TempDir(), archives.ExtractOptions{
+		Extension: ".zip",
+		MaxSize:   10,
+	})
+	assert.Assert(t, errors.Is(err, archives.ErrLimitExceeded), "unexpected error: %v", err)
+}
+
+func TestRecurseSkipsBadNestedArchive(t *testing.T) {
+	outer, err := ziptest.Create([]ziptest.File{
+		{Name: "bad.tar.gz", Contents: []byte("not gzip")},
+		{Name: "readme.txt", Contents: []byte("hello")},
+	})
+	assert.NilError(t, err)
+
+	a, err := archives.Open(bytes.NewReader(outer), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+
+	ra := archives.Recurse(a, archives.RecurseOptions{MaxDepth: 1})
+	defer ra.Close()
+
+	_, err = ra.Next()
+	var entryErr *archives.EntryError
+	assert.Assert(t, errors.As(err, &entryErr), "unexpected error: %v", err)
+	assert.Equal(t, entryErr.Header.Name, "bad.tar.gz")
+
+	// The archive can still be read after the bad nested archive.
+	h, err := ra.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "readme.txt")
+
+	dir := t.TempDir()
+	var skipped []string
+	assert.NilError(t, archives.Extract(bytes.NewReader(outer), dir, archives.ExtractOptions{
+		Extension: ".zip",
+		Recurse:   1,
+		OnEntryError: func(h *archives.Header, _ error) error {
+			skipped = append(skipped, h.Name)
+			return nil
+		},
+	}))
+	assert.DeepEqual(t, skipped, []string{"bad.tar.gz"})
+
+	got, err := os.ReadFile(filepath.Join(dir, "readme.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello")
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sourceDateEpochEnv is the environment variable containing the
+// timestamp used for reproducible builds, see
+// https://reproducible-builds.org/specs/source-date-epoch/.
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// defaultReproducibleModTime is the modification time of entries of
+// reproducible archives if no other time was provided. It is the
+// earliest time representable by zip archives.
+var defaultReproducibleModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// reproducibleModTime returns the modification time of the entries of
+// reproducible archives created using opts.
+func reproducibleModTime(opts *CreateOptions) (time.Time, error) {
+	if !opts.ModTime.IsZero() {
+		return opts.ModTime.UTC(), nil
+	}
+
+	epoch := os.Getenv(sourceDateEpochEnv)
+	if epoch == "" {
+		return defaultReproducibleModTime, nil
+	}
+
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: %w", sourceDateEpochEnv, epoch, err)
+	}
+
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// reproducibleWriter is an [ArchiveWriter] normalizing the headers of
+// entries before writing them, see [CreateOptions.Reproducible].
+type reproducibleWriter struct {
+	ArchiveWriter
+	modTime time.Time
+}
+
+// WriteHeader implements [ArchiveWriter].
+func (r *reproducibleWriter) WriteHeader(h *Header) error {
+	nh := *h
+	nh.ModTime = r.modTime
+	nh.AccessTime = time.Time{}
+	nh.UID, nh.GID = 0, 0
+	nh.Uname, nh.Gname = "", ""
+	nh.Mode = reproducibleMode(h)
+
+	return r.ArchiveWriter.WriteHeader(&nh)
+}
+
+// reproducibleMode returns the normalized mode of the entry h: 0755 for
+// directories and executable files, 0777 for symbolic links and 0644
+// otherwise. Special bits (e.g., setuid) are dropped.
+func reproducibleMode(h *Header) os.FileMode {
+	switch h.Type {
+	case HeaderDir:
+		return os.ModeDir | 0o755
+	case HeaderSymlink:
+		return os.ModeSymlink | 0o777
+	}
+
+	if h.Mode&0o111 != 0 {
+		return 0o755
+	}
+	return 0o644
+}
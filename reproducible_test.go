@@ -0,0 +1,125 @@
+package archives_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"gotest.tools/v3/assert"
+)
+
+// createReproducible creates an archive of the contents of src using a
+// reproducible [archives.ArchiveWriter].
+func createReproducible(t *testing.T, ext, src string) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	aw, err := archives.Create(buf, archives.CreateOptions{Extension: ext, Reproducible: true})
+	assert.NilError(t, err)
+	assert.NilError(t, archives.AddPath(aw, src, "."))
+	assert.NilError(t, aw.Close())
+	return buf.Bytes()
+}
+
+// createTree creates the same files in a new directory, using the
+// provided modification time and permissions of files.
+func createTree(t *testing.T, modTime time.Time, perm os.FileMode) string {
+	t.Helper()
+
+	src := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(src, "bin"), 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "bin", "tool"), []byte("#!/bin/sh\n"), perm|0o100))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "README"), bytes.Repeat([]byte("hello "), 100), perm))
+	for _, p := range []string{"bin/tool", "README", "bin", "."} {
+		assert.NilError(t, os.Chtimes(filepath.Join(src, p), modTime, modTime))
+	}
+	return src
+}
+
+func TestCreateReproducible(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions aren't supported on Windows")
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	want := time.Unix(1700000000, 0)
+
+	for _, ext := range []string{".tar", ".tar.gz", ".tar.zst", ".tar.xz", ".tar.lz4", ".zip"} {
+		t.Run(ext, func(t *testing.T) {
+			a := createReproducible(t, ext, createTree(t, time.Now(), 0o600))
+			b := createReproducible(t, ext, createTree(t, time.Now().Add(-time.Hour), 0o640))
+			assert.Assert(t, bytes.Equal(a, b), "archives differ")
+
+			r, err := archives.Open(bytes.NewReader(a), archives.OpenOptions{Extension: ext})
+			assert.NilError(t, err)
+			defer r.Close()
+
+			hdrs, err := archives.List(r)
+			assert.NilError(t, err)
+
+			var names []string
+			for _, h := range hdrs {
+				names = append(names, h.Name)
+				assert.Assert(t, h.ModTime.Equal(want), "%s: %v", h.Name, h.ModTime)
+				assert.Equal(t, h.UID, 0)
+				assert.Equal(t, h.GID, 0)
+				assert.Equal(t, h.Uname, "")
+
+				wantPerm := os.FileMode(0o644)
+				if h.Type == archives.HeaderDir || h.Name == "bin/tool" {
+					wantPerm = 0o755
+				}
+				assert.Equal(t, h.Mode.Perm(), wantPerm, h.Name)
+			}
+			assert.DeepEqual(t, names, []string{"README", "bin/", "bin/tool"})
+		})
+	}
+}
+
+func TestCreateReproducibleGzipHeader(t *testing.T) {
+	b := createReproducible(t, ".tar.gz", createTree(t, time.Now(), 0o644))
+
+	// The modification time (bytes 4-7) is zero and the operating system
+	// (byte 9) is unknown.
+	assert.DeepEqual(t, b[4:8], []byte{0, 0, 0, 0})
+	assert.Equal(t, b[9], byte(255))
+}
+
+func TestCreateReproducibleModTime(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for _, opts := range []archives.CreateOptions{
+		{Extension: ".tar", Reproducible: true, ModTime: modTime},
+		{Extension: ".tar", Reproducible: true},
+	} {
+		buf := new(bytes.Buffer)
+		aw, err := archives.Create(buf, opts)
+		assert.NilError(t, err)
+		assert.NilError(t, aw.WriteHeader(&archives.Header{Name: "a.txt", Type: archives.HeaderFile, ModTime: time.Now()}))
+		assert.NilError(t, aw.Close())
+
+		r, err := archives.Open(buf, archives.OpenOptions{Extension: ".tar"})
+		assert.NilError(t, err)
+		h, err := r.Next()
+		assert.NilError(t, err)
+
+		want := opts.ModTime
+		if want.IsZero() {
+			want = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		assert.Assert(t, h.ModTime.Equal(want), h.ModTime)
+		_, err = io.Copy(io.Discard, r)
+		assert.NilError(t, err)
+		assert.NilError(t, r.Close())
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err := archives.Create(new(bytes.Buffer), archives.CreateOptions{Extension: ".tar", Reproducible: true})
+	assert.ErrorContains(t, err, "invalid SOURCE_DATE_EPOCH")
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ExtractResult describes the outcome of extracting an archive, as
+// returned by [ExtractWithResult].
+type ExtractResult struct {
+	// Written contains the entries that were extracted, in the order
+	// they were read, and the directories created for them (see
+	// [ExtractedEntry.Implicit]). It contains every path created in the
+	// destination, e.g. to remove them when uninstalling.
+	Written []ExtractedEntry
+
+	// Skipped contains the entries that failed to be extracted and were
+	// skipped, as [ExtractOptions.OnEntryError] returned nil. The error
+	// of each entry is the reason it was skipped.
+	Skipped []EntryError
+
+	// Failed is the entry whose failure stopped extraction, if any.
+	Failed *EntryError
+}
+
+// ExtractedEntry is an entry written by [ExtractWithResult].
+type ExtractedEntry struct {
+	// Header is the header of the entry.
+	Header Header
+
+	// Path is the path the entry was written to, inside the destination.
+	Path string
+
+	// Size is the number of bytes written for files.
+	Size int64
+
+	// Digest is the digest of the contents of files, computed using
+	// [ExtractOptions.Digest] if set.
+	Digest []byte
+
+	// Implicit is true for directories that aren't entries of the
+	// archive, but were created for entries whose parent directories are
+	// missing from the archive. Their header is synthesized.
+	Implicit bool
+}
+
+// record adds e, written to its path inside dir, to the written entries.
+// The path is recorded relative to dir.
+func (r *ExtractResult) record(dir string, e ExtractedEntry) error {
+	rel, err := filepath.Rel(dir, e.Path)
+	if err != nil {
+		return fmt.Errorf("failed to determine path of %s: %w", e.Header.Name, err)
+	}
+
+	e.Path = rel
+	r.Written = append(r.Written, e)
+	return nil
+}
+
+// resolve joins dest with the paths of the written entries, which are
+// recorded relative to the directory the archive is extracted into.
+func (r *ExtractResult) resolve(dest string) {
+	for i := range r.Written {
+		r.Written[i].Path = filepath.Join(dest, r.Written[i].Path)
+	}
+}
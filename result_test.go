@@ -0,0 +1,150 @@
+package archives_test
+
+import (
+	stdtar "archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"gotest.tools/v3/assert"
+)
+
+// writtenPaths returns the paths of the entries written according to
+// res.
+func writtenPaths(res *archives.ExtractResult) []string {
+	paths := []string{}
+	for _, e := range res.Written {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+func TestExtractWithResult(t *testing.T) {
+	dest := t.TempDir()
+	res, err := archives.ExtractWithResult(createTar(t,
+		&stdtar.Header{Name: "dir/", Typeflag: stdtar.TypeDir, Mode: 0o755},
+		&stdtar.Header{Name: "dir/a.txt", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "x/y/b.txt", Typeflag: stdtar.TypeReg, Mode: 0o644},
+		&stdtar.Header{Name: "link", Typeflag: stdtar.TypeSymlink, Linkname: "dir/a.txt"},
+	), dest, archives.ExtractOptions{Extension: ".tar", Digest: sha256.New})
+	assert.NilError(t, err)
+
+	type entry struct {
+		Name     string
+		Path     string
+		Size     int64
+		Digest   string
+		Implicit bool
+	}
+	var got []entry
+	for _, e := range res.Written {
+		got = append(got, entry{e.Header.Name, e.Path, e.Size, hex.EncodeToString(e.Digest), e.Implicit})
+	}
+
+	// The contents of files created by createTar are their names.
+	digest := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	assert.DeepEqual(t, got, []entry{
+		{"dir/", filepath.Join(dest, "dir"), 0, "", false},
+		{"dir/a.txt", filepath.Join(dest, "dir", "a.txt"), 9, digest("dir/a.txt"), false},
+		{"x/", filepath.Join(dest, "x"), 0, "", true},
+		{"x/y/", filepath.Join(dest, "x", "y"), 0, "", true},
+		{"x/y/b.txt", filepath.Join(dest, "x", "y", "b.txt"), 9, digest("x/y/b.txt"), false},
+		{"link", filepath.Join(dest, "link"), 0, "", false},
+	})
+	assert.Equal(t, len(res.Skipped), 0)
+	assert.Assert(t, res.Failed == nil)
+}
+
+func TestExtractWithResultAtomic(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "dest")
+	res, err := archives.ExtractWithResult(createTar(t,
+		&stdtar.Header{Name: "dir/a.txt", Typeflag: stdtar.TypeReg, Mode: 0o644},
+	), dest, archives.ExtractOptions{Extension: ".tar", Atomic: true})
+	assert.NilError(t, err)
+
+	// Paths are reported inside dest rather than the staging directory.
+	assert.DeepEqual(t, writtenPaths(res), []string{filepath.Join(dest, "dir"), filepath.Join(dest, "dir", "a.txt")})
+	for _, p := range writtenPaths(res) {
+		_, err := os.Stat(p)
+		assert.NilError(t, err)
+	}
+}
+
+func TestExtractOnEntryErrorSkip(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "a.txt", Contents: []byte("hello")},
+		{Name: "../evil.txt", Contents: []byte("evil")},
+		{Name: "corrupt.txt", Contents: []byte("world")},
+		{Name: "b.txt", Contents: []byte("hello")},
+	})
+	assert.NilError(t, err)
+
+	// Corrupt the contents of corrupt.txt, which is stored, so that its
+	// checksum doesn't match.
+	b = bytes.Replace(b, []byte("world"), []byte("w0rld"), 1)
+
+	var called []string
+	dest := t.TempDir()
+	res, err := archives.ExtractWithResult(bytes.NewReader(b), dest, archives.ExtractOptions{
+		Extension: ".zip",
+		OnEntryError: func(h *archives.Header, _ error) error {
+			called = append(called, h.Name)
+			return nil
+		},
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, called, []string{"../evil.txt", "corrupt.txt"})
+	assert.DeepEqual(t, writtenPaths(res), []string{filepath.Join(dest, "a.txt"), filepath.Join(dest, "b.txt")})
+	assert.Equal(t, len(res.Skipped), 2)
+	assert.ErrorIs(t, &res.Skipped[0], archives.ErrPathTraversal)
+	assert.ErrorIs(t, &res.Skipped[1], archives.ErrCorrupt)
+	assert.Assert(t, res.Failed == nil)
+
+	// Partially written files are removed.
+	_, err = os.Stat(filepath.Join(dest, "corrupt.txt"))
+	assert.Assert(t, errors.Is(err, os.ErrNotExist), err)
+	got, err := os.ReadFile(filepath.Join(dest, "b.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello")
+}
+
+func TestExtractOnEntryErrorStop(t *testing.T) {
+	errStop := errors.New("stop")
+	res, err := archives.ExtractWithResult(createTar(t,
+		&stdtar.Header{Name: "a.txt", Typeflag: stdtar.TypeReg},
+		&stdtar.Header{Name: "../evil.txt", Typeflag: stdtar.TypeReg},
+		&stdtar.Header{Name: "b.txt", Typeflag: stdtar.TypeReg},
+	), t.TempDir(), archives.ExtractOptions{
+		Extension:    ".tar",
+		OnEntryError: func(*archives.Header, error) error { return errStop },
+	})
+	assert.ErrorIs(t, err, errStop)
+
+	assert.Equal(t, len(res.Written), 1)
+	assert.Equal(t, res.Failed.Header.Name, "../evil.txt")
+	assert.ErrorIs(t, res.Failed, archives.ErrPathTraversal)
+}
+
+func TestExtractWithResultAtomicFailure(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "dest")
+	res, err := archives.ExtractWithResult(createTar(t,
+		&stdtar.Header{Name: "a.txt", Typeflag: stdtar.TypeReg},
+		&stdtar.Header{Name: "../evil.txt", Typeflag: stdtar.TypeReg},
+	), dest, archives.ExtractOptions{Extension: ".tar", Atomic: true})
+	assert.ErrorIs(t, err, archives.ErrPathTraversal)
+
+	// Nothing was written to dest.
+	assert.Equal(t, len(res.Written), 0)
+	_, err = os.Stat(dest)
+	assert.Assert(t, errors.Is(err, os.ErrNotExist), err)
+}
@@ -0,0 +1,548 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Contains constants for the RPM file format.
+const (
+	rpmLeadMagic   = "\xed\xab\xee\xdb"
+	rpmLeadSize    = 96
+	rpmHeaderMagic = "\x8e\xad\xe8\x01"
+
+	// rpmSignatureTypeHeader is the only signature type in use, denoting
+	// that the signature is stored in a header structure.
+	rpmSignatureTypeHeader = 5
+
+	// Limits to guard against corrupt headers causing large allocations.
+	rpmMaxIndexEntries = 1 << 16
+	rpmMaxStoreSize    = 256 << 20
+)
+
+// Contains the RPM header tag types.
+const (
+	rpmTypeInt16       = 3
+	rpmTypeInt32       = 4
+	rpmTypeInt64       = 5
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// Contains the RPM header tags used by the rpm extractor.
+const (
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagEpoch             = 1003
+	rpmTagSummary           = 1004
+	rpmTagDescription       = 1005
+	rpmTagBuildTime         = 1006
+	rpmTagSize              = 1009
+	rpmTagVendor            = 1011
+	rpmTagLicense           = 1014
+	rpmTagPackager          = 1015
+	rpmTagGroup             = 1016
+	rpmTagURL               = 1020
+	rpmTagOS                = 1021
+	rpmTagArch              = 1022
+	rpmTagOldFilenames      = 1027
+	rpmTagFileSizes         = 1028
+	rpmTagFileModes         = 1030
+	rpmTagFileMTimes        = 1034
+	rpmTagFileLinkTos       = 1036
+	rpmTagFileUserName      = 1039
+	rpmTagFileGroupName     = 1040
+	rpmTagSourceRPM         = 1044
+	rpmTagProvideName       = 1047
+	rpmTagRequireName       = 1049
+	rpmTagDirIndexes        = 1116
+	rpmTagBasenames         = 1117
+	rpmTagDirNames          = 1118
+	rpmTagPayloadFormat     = 1124
+	rpmTagPayloadCompressor = 1125
+	rpmTagLongFileSizes     = 5008
+	rpmTagLongSize          = 5009
+)
+
+// RPMPackage contains the metadata of an RPM package.
+type RPMPackage struct {
+	// Name, Version and Release identify the package. Epoch is 0 if not
+	// set.
+	Name    string
+	Version string
+	Release string
+	Epoch   int
+
+	// Arch and OS are the architecture and operating system the package
+	// was built for.
+	Arch string
+	OS   string
+
+	Summary     string
+	Description string
+	License     string
+	URL         string
+	Vendor      string
+	Packager    string
+	Group       string
+
+	// SourceRPM is the name of the source package this package was built
+	// from. It is empty for source packages.
+	SourceRPM string
+
+	// BuildTime is the time the package was built.
+	BuildTime time.Time
+
+	// Size is the installed size of the package in bytes.
+	Size int64
+
+	// Provides and Requires are the names of the capabilities provided
+	// and required by the package.
+	Provides []string
+	Requires []string
+
+	// PayloadFormat and PayloadCompressor describe how the files of the
+	// package are stored, e.g. "cpio" and "xz".
+	PayloadFormat     string
+	PayloadCompressor string
+}
+
+// RPMArchive is implemented by the [Archive] returned when opening an
+// RPM package.
+type RPMArchive interface {
+	Archive
+
+	// Package returns the metadata of the package.
+	Package() *RPMPackage
+}
+
+// _ ensures that rpm implements the [Archiver] interface.
+var _ Archiver = (&rpm{})
+
+// rpm implements the [Archiver] interface for RPM packages. The files
+// of the package are read from its cpio payload.
+type rpm struct{}
+
+// Extensions returns the supported extensions for the rpm extractor.
+func (r *rpm) Extensions() []string {
+	return []string{"rpm"}
+}
+
+// Open creates a new [Archive] from the provided RPM package. The
+// returned archive implements [RPMArchive].
+func (r *rpm) Open(rr io.Reader, _ string) (Archive, error) {
+	var lead [rpmLeadSize]byte
+	if _, err := io.ReadFull(rr, lead[:]); err != nil {
+		return nil, fmt.Errorf("failed to read rpm lead: %w", err)
+	}
+
+	if string(lead[:4]) != rpmLeadMagic {
+		return nil, fmt.Errorf("%w: not an rpm package", ErrCorrupt)
+	}
+
+	if sigType := binary.BigEndian.Uint16(lead[78:80]); sigType != rpmSignatureTypeHeader {
+		return nil, fmt.Errorf("%w: rpm signature type %d", ErrUnsupportedFormat, sigType)
+	}
+
+	// The signature is stored in a header structure padded to 8 bytes.
+	if _, err := readRPMHeader(rr, true); err != nil {
+		return nil, fmt.Errorf("failed to read rpm signature: %w", err)
+	}
+
+	hdr, err := readRPMHeader(rr, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rpm header: %w", err)
+	}
+
+	pkg := hdr.pkg()
+	if pkg.PayloadFormat != "cpio" {
+		return nil, fmt.Errorf("%w: rpm payload format %q", ErrUnsupportedFormat, pkg.PayloadFormat)
+	}
+
+	var codec string
+	switch pkg.PayloadCompressor {
+	case "gzip", "":
+		codec = "gz"
+	case "bzip2":
+		codec = "bz2"
+	case "xz":
+		codec = "xz"
+	case "lzma":
+		codec = "lzma"
+	case "zstd":
+		codec = "zst"
+	case "identity":
+	default:
+		return nil, fmt.Errorf("%w: rpm payload compressor %q", ErrUnsupportedFormat, pkg.PayloadCompressor)
+	}
+
+	container, err := newContainerReader(rr, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &rpmArchive{pkg: pkg, files: hdr.files()}
+	a.index = make(map[string]int, len(a.files))
+	for i, f := range a.files {
+		a.index["."+f.name] = i
+	}
+	a.cpioArchive = newCPIOArchive(container, a.resolve)
+
+	return a, nil
+}
+
+// rpmFile contains the metadata of a file from the RPM header.
+type rpmFile struct {
+	name     string
+	size     int64
+	mode     uint32
+	mtime    int64
+	linkname string
+	user     string
+	group    string
+}
+
+// rpmArchive implements [RPMArchive].
+type rpmArchive struct {
+	*cpioArchive
+
+	pkg *RPMPackage
+
+	// files contains the files from the RPM header, index maps the names
+	// used in the payload to their index in files.
+	files []rpmFile
+	index map[string]int
+}
+
+// Package implements [RPMArchive].
+func (a *rpmArchive) Package() *RPMPackage {
+	return a.pkg
+}
+
+// resolve fills in the metadata of a payload entry from the RPM header.
+func (a *rpmArchive) resolve(e *cpioEntry) error {
+	if e.magic != cpioMagicStripped {
+		if i, ok := a.index[e.name]; ok {
+			e.uname = a.files[i].user
+			e.gname = a.files[i].group
+		}
+
+		return nil
+	}
+
+	// Stripped entries only reference the file in the header.
+	if int(e.ino) >= len(a.files) {
+		return fmt.Errorf("%w: invalid file index %d in rpm payload", ErrCorrupt, e.ino)
+	}
+
+	f := &a.files[e.ino]
+	e.name = "." + f.name
+	e.mode = f.mode
+	e.mtime = f.mtime
+	e.nlink = 1
+	e.uname = f.user
+	e.gname = f.group
+
+	switch f.mode & 0o170000 {
+	case 0o100000:
+		e.size = f.size
+	case 0o120000:
+		e.size = int64(len(f.linkname))
+	default:
+		e.size = 0
+	}
+	a.cpioArchive.r.setSize(e.size)
+
+	return nil
+}
+
+// rpmTag is an entry in an RPM header.
+type rpmTag struct {
+	typ   uint32
+	count uint32
+
+	// data is the store of the header starting at the data of the tag.
+	data []byte
+}
+
+// rpmHeader is a parsed RPM header structure.
+type rpmHeader struct {
+	tags map[uint32]rpmTag
+}
+
+// readRPMHeader reads a header structure from r. If pad is true, the
+// padding to an 8 byte boundary after the header is skipped.
+func readRPMHeader(r io.Reader, pad bool) (*rpmHeader, error) {
+	var intro [16]byte
+	if _, err := io.ReadFull(r, intro[:]); err != nil {
+		return nil, err
+	}
+
+	if string(intro[:4]) != rpmHeaderMagic {
+		return nil, fmt.Errorf("%w: invalid header magic", ErrCorrupt)
+	}
+
+	nindex := binary.BigEndian.Uint32(intro[8:12])
+	hsize := binary.BigEndian.Uint32(intro[12:16])
+	if nindex > rpmMaxIndexEntries || hsize > rpmMaxStoreSize {
+		return nil, fmt.Errorf("%w: header too large (%d entries, %d bytes)", ErrCorrupt, nindex, hsize)
+	}
+
+	index := make([]byte, nindex*16)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, err
+	}
+
+	store := make([]byte, hsize)
+	if _, err := io.ReadFull(r, store); err != nil {
+		return nil, err
+	}
+
+	if pad {
+		if n := (8 - hsize%8) % 8; n > 0 {
+			if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	h := &rpmHeader{tags: make(map[uint32]rpmTag, nindex)}
+	for i := uint32(0); i < nindex; i++ {
+		e := index[i*16 : (i+1)*16]
+		offset := binary.BigEndian.Uint32(e[8:12])
+		if offset > hsize {
+			return nil, fmt.Errorf("%w: invalid offset %d for tag %d", ErrCorrupt, offset, binary.BigEndian.Uint32(e[0:4]))
+		}
+
+		h.tags[binary.BigEndian.Uint32(e[0:4])] = rpmTag{
+			typ:   binary.BigEndian.Uint32(e[4:8]),
+			count: binary.BigEndian.Uint32(e[12:16]),
+			data:  store[offset:],
+		}
+	}
+
+	return h, nil
+}
+
+// errRPMTagType is returned when a tag has an unexpected type.
+var errRPMTagType = fmt.Errorf("%w: unexpected rpm tag type", ErrCorrupt)
+
+// strings returns the value of a string, string array or i18n string
+// tag. For i18n strings, the first (default) translation is returned.
+func (h *rpmHeader) strings(tag uint32) ([]string, error) {
+	t, ok := h.tags[tag]
+	if !ok {
+		return nil, nil
+	}
+
+	count := t.count
+	switch t.typ {
+	case rpmTypeString:
+		count = 1
+	case rpmTypeStringArray, rpmTypeI18NString:
+	default:
+		return nil, errRPMTagType
+	}
+
+	if count > uint32(len(t.data)) { //nolint:gosec // Why: Store size is limited.
+		return nil, fmt.Errorf("%w: invalid count %d for tag %d", ErrCorrupt, count, tag)
+	}
+
+	vals := make([]string, 0, count)
+	data := t.data
+	for i := uint32(0); i < count; i++ {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated string in tag %d", ErrCorrupt, tag)
+		}
+
+		vals = append(vals, string(data[:end]))
+		data = data[end+1:]
+	}
+
+	return vals, nil
+}
+
+// string returns the first value of a string tag.
+func (h *rpmHeader) string(tag uint32) string {
+	vals, err := h.strings(tag)
+	if err != nil || len(vals) == 0 {
+		return ""
+	}
+
+	return vals[0]
+}
+
+// ints returns the value of an integer tag.
+func (h *rpmHeader) ints(tag uint32) ([]int64, error) {
+	t, ok := h.tags[tag]
+	if !ok {
+		return nil, nil
+	}
+
+	var size uint32
+	switch t.typ {
+	case rpmTypeInt16:
+		size = 2
+	case rpmTypeInt32:
+		size = 4
+	case rpmTypeInt64:
+		size = 8
+	default:
+		return nil, errRPMTagType
+	}
+
+	if uint64(t.count)*uint64(size) > uint64(len(t.data)) {
+		return nil, fmt.Errorf("%w: invalid count %d for tag %d", ErrCorrupt, t.count, tag)
+	}
+
+	vals := make([]int64, t.count)
+	for i := range vals {
+		b := t.data[uint32(i)*size:] //nolint:gosec // Why: Bounds checked above.
+		switch size {
+		case 2:
+			vals[i] = int64(binary.BigEndian.Uint16(b))
+		case 4:
+			vals[i] = int64(binary.BigEndian.Uint32(b))
+		case 8:
+			vals[i] = int64(binary.BigEndian.Uint64(b)) //nolint:gosec // Why: Sizes fit.
+		}
+	}
+
+	return vals, nil
+}
+
+// int returns the first value of an integer tag.
+func (h *rpmHeader) int(tag uint32) int64 {
+	vals, err := h.ints(tag)
+	if err != nil || len(vals) == 0 {
+		return 0
+	}
+
+	return vals[0]
+}
+
+// pkg returns the package metadata contained in the header.
+func (h *rpmHeader) pkg() *RPMPackage {
+	pkg := &RPMPackage{
+		Name:              h.string(rpmTagName),
+		Version:           h.string(rpmTagVersion),
+		Release:           h.string(rpmTagRelease),
+		Epoch:             int(h.int(rpmTagEpoch)),
+		Arch:              h.string(rpmTagArch),
+		OS:                h.string(rpmTagOS),
+		Summary:           h.string(rpmTagSummary),
+		Description:       h.string(rpmTagDescription),
+		License:           h.string(rpmTagLicense),
+		URL:               h.string(rpmTagURL),
+		Vendor:            h.string(rpmTagVendor),
+		Packager:          h.string(rpmTagPackager),
+		Group:             h.string(rpmTagGroup),
+		SourceRPM:         h.string(rpmTagSourceRPM),
+		Size:              h.int(rpmTagLongSize),
+		PayloadFormat:     h.string(rpmTagPayloadFormat),
+		PayloadCompressor: h.string(rpmTagPayloadCompressor),
+	}
+
+	if buildTime := h.int(rpmTagBuildTime); buildTime != 0 {
+		pkg.BuildTime = time.Unix(buildTime, 0)
+	}
+
+	if pkg.Size == 0 {
+		pkg.Size = h.int(rpmTagSize)
+	}
+
+	pkg.Provides, _ = h.strings(rpmTagProvideName) //nolint:errcheck // Why: Optional.
+	pkg.Requires, _ = h.strings(rpmTagRequireName) //nolint:errcheck // Why: Optional.
+
+	// Packages without these tags predate them and use the defaults.
+	if pkg.PayloadFormat == "" {
+		pkg.PayloadFormat = "cpio"
+	}
+	if pkg.PayloadCompressor == "" {
+		pkg.PayloadCompressor = "gzip"
+	}
+
+	return pkg
+}
+
+// files returns the files contained in the header. Any malformed
+// metadata results in the affected fields being left empty.
+func (h *rpmHeader) files() []rpmFile {
+	names, _ := h.strings(rpmTagOldFilenames) //nolint:errcheck // Why: Optional.
+	if len(names) == 0 {
+		basenames, _ := h.strings(rpmTagBasenames) //nolint:errcheck // Why: Optional.
+		dirnames, _ := h.strings(rpmTagDirNames)   //nolint:errcheck // Why: Optional.
+		dirindexes, _ := h.ints(rpmTagDirIndexes)  //nolint:errcheck // Why: Optional.
+		if len(dirindexes) != len(basenames) {
+			return nil
+		}
+
+		names = make([]string, len(basenames))
+		for i := range basenames {
+			if dirindexes[i] < 0 || dirindexes[i] >= int64(len(dirnames)) {
+				return nil
+			}
+			names[i] = dirnames[dirindexes[i]] + basenames[i]
+		}
+	}
+
+	sizes, _ := h.ints(rpmTagLongFileSizes) //nolint:errcheck // Why: Optional.
+	if len(sizes) == 0 {
+		sizes, _ = h.ints(rpmTagFileSizes) //nolint:errcheck // Why: Optional.
+	}
+	modes, _ := h.ints(rpmTagFileModes)         //nolint:errcheck // Why: Optional.
+	mtimes, _ := h.ints(rpmTagFileMTimes)       //nolint:errcheck // Why: Optional.
+	links, _ := h.strings(rpmTagFileLinkTos)    //nolint:errcheck // Why: Optional.
+	users, _ := h.strings(rpmTagFileUserName)   //nolint:errcheck // Why: Optional.
+	groups, _ := h.strings(rpmTagFileGroupName) //nolint:errcheck // Why: Optional.
+
+	files := make([]rpmFile, len(names))
+	for i, name := range names {
+		f := rpmFile{name: name, mode: 0o100644}
+		if i < len(sizes) {
+			f.size = sizes[i]
+		}
+		if i < len(modes) {
+			f.mode = uint32(modes[i]) //nolint:gosec // Why: Modes are 16 bits.
+		}
+		if i < len(mtimes) {
+			f.mtime = mtimes[i]
+		}
+		if i < len(links) {
+			f.linkname = links[i]
+		}
+		if i < len(users) {
+			f.user = users[i]
+		}
+		if i < len(groups) {
+			f.group = groups[i]
+		}
+		files[i] = f
+	}
+
+	return files
+}
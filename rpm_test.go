@@ -0,0 +1,130 @@
+package archives_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/rpmtest"
+	"gotest.tools/v3/assert"
+)
+
+// rpmFiles are the files used by the RPM tests.
+var rpmFiles = []rpmtest.File{
+	{Name: "/usr/bin", Mode: 0o40755, User: "root", Group: "root"},
+	{Name: "/usr/bin/tool", Contents: []byte("#!/bin/sh\necho hi\n"), Mode: 0o100755, User: "root", Group: "wheel"},
+	{Name: "/usr/bin/tool-link", Mode: 0o120777, Linkname: "tool", User: "root", Group: "root"},
+	{Name: "/etc/tool.conf", Contents: []byte("key=value\n"), Mode: 0o100640, User: "tool", Group: "tool"},
+}
+
+func TestRPM(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []rpmtest.OptionFn
+	}{
+		{"gzip", nil},
+		{"xz", []rpmtest.OptionFn{rpmtest.WithCompressor("xz")}},
+		{"zstd", []rpmtest.OptionFn{rpmtest.WithCompressor("zstd")}},
+		{"stripped", []rpmtest.OptionFn{rpmtest.WithStripped()}},
+		{"no compressor tag", []rpmtest.OptionFn{rpmtest.WithCompressor("")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := rpmtest.Create(rpmtest.Package{
+				Name:     "tool",
+				Version:  "1.2.3",
+				Release:  "1.el9",
+				Epoch:    2,
+				Arch:     "x86_64",
+				Summary:  "A tool",
+				License:  "MIT",
+				Provides: []string{"tool", "tool(x86-64)"},
+				Requires: []string{"/bin/sh"},
+			}, rpmFiles, tt.options...)
+			assert.NilError(t, err)
+
+			a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+				Extension: archives.Ext("tool-1.2.3-1.el9.x86_64.rpm"),
+			})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			ra, ok := a.(archives.RPMArchive)
+			assert.Assert(t, ok)
+
+			pkg := ra.Package()
+			assert.Equal(t, pkg.Name, "tool")
+			assert.Equal(t, pkg.Version, "1.2.3")
+			assert.Equal(t, pkg.Release, "1.el9")
+			assert.Equal(t, pkg.Epoch, 2)
+			assert.Equal(t, pkg.Arch, "x86_64")
+			assert.Equal(t, pkg.OS, "linux")
+			assert.Equal(t, pkg.Summary, "A tool")
+			assert.Equal(t, pkg.License, "MIT")
+			assert.DeepEqual(t, pkg.Provides, []string{"tool", "tool(x86-64)"})
+			assert.DeepEqual(t, pkg.Requires, []string{"/bin/sh"})
+			assert.Assert(t, pkg.BuildTime.Equal(time.Unix(1700000000, 0)))
+
+			h, err := a.Next()
+			assert.NilError(t, err)
+			assert.Equal(t, h.Name, "./usr/bin/")
+			assert.Equal(t, h.Type, archives.HeaderDir)
+			assert.Equal(t, h.Mode, os.ModeDir|0o755)
+
+			h, err = a.Next()
+			assert.NilError(t, err)
+			assert.Equal(t, h.Name, "./usr/bin/tool")
+			assert.Equal(t, h.Type, archives.HeaderFile)
+			assert.Equal(t, h.Mode, os.FileMode(0o755))
+			assert.Equal(t, h.Uname, "root")
+			assert.Equal(t, h.Gname, "wheel")
+			contents, err := io.ReadAll(a)
+			assert.NilError(t, err)
+			assert.Equal(t, string(contents), "#!/bin/sh\necho hi\n")
+
+			h, err = a.Next()
+			assert.NilError(t, err)
+			assert.Equal(t, h.Name, "./usr/bin/tool-link")
+			assert.Equal(t, h.Type, archives.HeaderSymlink)
+			assert.Equal(t, h.Linkname, "tool")
+
+			h, err = a.Next()
+			assert.NilError(t, err)
+			assert.Equal(t, h.Name, "./etc/tool.conf")
+			assert.Equal(t, h.Size, int64(10))
+			assert.Equal(t, h.Mode, os.FileMode(0o640))
+			assert.Equal(t, h.Uname, "tool")
+			assert.Equal(t, h.Gname, "tool")
+
+			_, err = a.Next()
+			assert.Equal(t, err, io.EOF)
+		})
+	}
+}
+
+func TestRPMExtract(t *testing.T) {
+	b, err := rpmtest.Create(rpmtest.Package{Name: "tool", Version: "1", Release: "1"}, rpmFiles,
+		rpmtest.WithCompressor("xz"))
+	assert.NilError(t, err)
+
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(b), dest, archives.ExtractOptions{
+		Extension: ".rpm",
+	}))
+
+	got, err := os.ReadFile(filepath.Join(dest, "usr", "bin", "tool-link"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "#!/bin/sh\necho hi\n")
+
+	target, err := os.Readlink(filepath.Join(dest, "usr", "bin", "tool-link"))
+	assert.NilError(t, err)
+	assert.Equal(t, target, "tool")
+
+	got, err = os.ReadFile(filepath.Join(dest, "etc", "tool.conf"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "key=value\n")
+}
@@ -0,0 +1,655 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+	"go.rgst.io/jaredallard/archives/v2/internal/bcj"
+)
+
+// Contains constants for the 7z signature header.
+const (
+	sevenZipSignature = "7z\xbc\xaf\x27\x1c"
+
+	// sevenZipSignatureHeaderSize is the size of the signature header,
+	// all offsets in the archive are relative to the end of it.
+	sevenZipSignatureHeaderSize = 32
+)
+
+// Contains the Windows file attributes used by 7z.
+const (
+	windowsAttributeReadOnly  = 0x01
+	windowsAttributeDirectory = 0x10
+
+	// windowsAttributeUnixExtension denotes that the high 16 bits of the
+	// attributes contain a Unix mode.
+	windowsAttributeUnixExtension = 0x8000
+)
+
+// Contains the 7z coder method IDs that are supported.
+var (
+	sevenZipMethodCopy    = []byte{0x00}
+	sevenZipMethodDelta   = []byte{0x03}
+	sevenZipMethodARM64   = []byte{0x0a}
+	sevenZipMethodLZMA2   = []byte{0x21}
+	sevenZipMethodLZMA    = []byte{0x03, 0x01, 0x01}
+	sevenZipMethodX86     = []byte{0x03, 0x03, 0x01, 0x03}
+	sevenZipMethodBCJ2    = []byte{0x03, 0x03, 0x01, 0x1b}
+	sevenZipMethodARM     = []byte{0x03, 0x03, 0x05, 0x01}
+	sevenZipMethodDeflate = []byte{0x04, 0x01, 0x08}
+	sevenZipMethodBzip2   = []byte{0x04, 0x02, 0x02}
+	sevenZipMethodZstd    = []byte{0x04, 0xf7, 0x11, 0x01}
+	sevenZipMethodAES     = []byte{0x06, 0xf1, 0x07, 0x01}
+)
+
+// _ ensures that sevenzip implements the [Archiver] interface.
+var _ Archiver = (&sevenzip{})
+
+// _ ensures that sevenzip implements the [ReaderAtArchiver] interface.
+var _ ReaderAtArchiver = (&sevenzip{})
+
+// sevenzip implements the [Archiver] interface for 7z archives.
+type sevenzip struct{}
+
+// Extensions returns the supported extensions for the 7z extractor.
+func (s *sevenzip) Extensions() []string {
+	return []string{"7z"}
+}
+
+// Open creates a new [Archive] from the provided reader using the 7z
+// format. Like zip, the headers of a 7z archive are stored at the end
+// of the archive so the entire archive is read into memory.
+func (s *sevenzip) Open(r io.Reader, _ string) (Archive, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	return openSevenZip(bytes.NewReader(b), int64(len(b)))
+}
+
+// OpenReaderAt implements [ReaderAtArchiver].
+func (s *sevenzip) OpenReaderAt(r io.ReaderAt, size int64, _ string, _ *OpenOptions) (Archive, error) {
+	return openSevenZip(r, size)
+}
+
+// openSevenZip reads the headers of a 7z archive.
+func openSevenZip(r io.ReaderAt, size int64) (*sevenZipArchive, error) {
+	var sig [sevenZipSignatureHeaderSize]byte
+	if _, err := r.ReadAt(sig[:], 0); err != nil {
+		return nil, fmt.Errorf("failed to read 7z signature header: %w", err)
+	}
+
+	if string(sig[:6]) != sevenZipSignature {
+		return nil, fmt.Errorf("%w: not a 7z archive", ErrCorrupt)
+	}
+
+	if sig[6] != 0 {
+		return nil, fmt.Errorf("%w: 7z version %d.%d", ErrUnsupportedFormat, sig[6], sig[7])
+	}
+
+	if crc32.ChecksumIEEE(sig[12:]) != binary.LittleEndian.Uint32(sig[8:12]) {
+		return nil, fmt.Errorf("%w: signature header crc mismatch", errSevenZipCorrupt)
+	}
+
+	nextOffset := binary.LittleEndian.Uint64(sig[12:20])
+	nextSize := binary.LittleEndian.Uint64(sig[20:28])
+	nextCRC := binary.LittleEndian.Uint32(sig[28:32])
+
+	a := &sevenZipArchive{r: r, size: size}
+	if nextSize == 0 {
+		// Empty archive.
+		return a, nil
+	}
+
+	start := uint64(sevenZipSignatureHeaderSize) + nextOffset
+	if nextOffset > uint64(size) || nextSize > uint64(size) || start+nextSize > uint64(size) { //nolint:gosec // Why: size is positive.
+		return nil, fmt.Errorf("%w: header is out of bounds", errSevenZipCorrupt)
+	}
+
+	hdr := make([]byte, nextSize)
+	if _, err := r.ReadAt(hdr, int64(start)); err != nil { //nolint:gosec // Why: Checked above.
+		return nil, fmt.Errorf("failed to read 7z header: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(hdr) != nextCRC {
+		return nil, fmt.Errorf("%w: header crc mismatch", errSevenZipCorrupt)
+	}
+
+	if err := a.readHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// sevenZipArchive is an implementation of the [Archive] interface for
+// 7z archives.
+type sevenZipArchive struct {
+	r    io.ReaderAt
+	size int64
+
+	streams *sevenZipStreamsInfo
+	files   []sevenZipFile
+
+	// pos is the index of the next file to return.
+	pos int
+
+	// folder is the index of the folder that folderReader reads from,
+	// and subStream is the index of the next file in streams to read.
+	folder       int
+	folderReader io.Reader
+	subStream    int
+
+	// cur reads the contents of the current file.
+	cur io.Reader
+}
+
+// readHeader parses the (possibly encoded) header of an archive.
+func (a *sevenZipArchive) readHeader(hdr []byte) error {
+	for {
+		r := &sevenZipHeaderReader{bytes.NewReader(hdr)}
+		id, err := r.number()
+		if err != nil {
+			return err
+		}
+
+		if id == sevenZipIDHeader {
+			return a.readPlainHeader(r)
+		}
+
+		if id != sevenZipIDEncodedHeader {
+			return fmt.Errorf("%w: unexpected property %#x", errSevenZipCorrupt, id)
+		}
+
+		// The header is compressed using a folder, decode it and try again.
+		si, err := r.streamsInfo()
+		if err != nil {
+			return fmt.Errorf("failed to read encoded header: %w", err)
+		}
+
+		if len(si.folders) == 0 {
+			return fmt.Errorf("%w: encoded header has no folders", errSevenZipCorrupt)
+		}
+
+		fr, err := a.newFolderReader(si, 0)
+		if err != nil {
+			return fmt.Errorf("failed to decode header: %w", err)
+		}
+
+		f := &si.folders[0]
+		if f.unpackSize() > sevenZipMaxHeaderSize {
+			return fmt.Errorf("%w: encoded header is too large", errSevenZipCorrupt)
+		}
+
+		hdr = make([]byte, f.unpackSize())
+		if _, err := io.ReadFull(fr, hdr); err != nil {
+			return fmt.Errorf("failed to decode header: %w", err)
+		}
+
+		if f.crcDefined && crc32.ChecksumIEEE(hdr) != f.crc {
+			return fmt.Errorf("%w: encoded header crc mismatch", errSevenZipCorrupt)
+		}
+	}
+}
+
+// readPlainHeader parses a decoded header.
+func (a *sevenZipArchive) readPlainHeader(r *sevenZipHeaderReader) error {
+	id, err := r.number()
+	if err != nil {
+		return err
+	}
+
+	if id == sevenZipIDArchiveProperties {
+		for {
+			prop, err := r.number()
+			if err != nil {
+				return err
+			}
+
+			if prop == sevenZipIDEnd {
+				break
+			}
+
+			size, err := r.number()
+			if err != nil {
+				return err
+			}
+
+			if err := r.skip(size); err != nil {
+				return err
+			}
+		}
+
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	if id == sevenZipIDAdditionalStreamsInfo {
+		if _, err := r.streamsInfo(); err != nil {
+			return err
+		}
+
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	a.streams = &sevenZipStreamsInfo{}
+	if id == sevenZipIDMainStreamsInfo {
+		if a.streams, err = r.streamsInfo(); err != nil {
+			return err
+		}
+
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	if id == sevenZipIDFilesInfo {
+		if a.files, err = r.filesInfo(); err != nil {
+			return err
+		}
+
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	if id != sevenZipIDEnd {
+		return fmt.Errorf("%w: unexpected property %#x in header", errSevenZipCorrupt, id)
+	}
+
+	// Assign the streams to the files that have them.
+	var s int
+	for i := range a.files {
+		f := &a.files[i]
+		if !f.hasStream {
+			continue
+		}
+
+		if s >= len(a.streams.subStreamSizes) {
+			return fmt.Errorf("%w: more files than streams", errSevenZipCorrupt)
+		}
+
+		f.size = a.streams.subStreamSizes[s]
+		f.crc = a.streams.subStreamCRCs[s]
+		f.crcDefined = a.streams.subStreamCRCDefined[s]
+		s++
+	}
+
+	a.folder = -1
+	return nil
+}
+
+// newFolderReader returns a reader for the unpacked output of a folder.
+func (a *sevenZipArchive) newFolderReader(si *sevenZipStreamsInfo, folder int) (io.Reader, error) {
+	// Determine the offset of the first packed stream of the folder.
+	var packIndex int
+	for i := 0; i < folder; i++ {
+		packIndex += len(si.folders[i].packedStreams)
+	}
+
+	f := &si.folders[folder]
+	if packIndex+len(f.packedStreams) > len(si.packSizes) {
+		return nil, fmt.Errorf("%w: missing packed streams", errSevenZipCorrupt)
+	}
+
+	offset := sevenZipSignatureHeaderSize + si.packPos
+	for i := 0; i < packIndex; i++ {
+		offset += si.packSizes[i]
+	}
+
+	packed := make([]io.Reader, len(f.packedStreams))
+	for i := range packed {
+		size := si.packSizes[packIndex+i]
+		if offset+size > uint64(a.size) { //nolint:gosec // Why: size is positive.
+			return nil, fmt.Errorf("%w: packed stream is out of bounds", errSevenZipCorrupt)
+		}
+
+		packed[i] = io.NewSectionReader(a.r, int64(offset), int64(size)) //nolint:gosec // Why: Checked above.
+		offset += size
+	}
+
+	out, err := f.mainOutStream()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.outStreamReader(out, packed, 0)
+}
+
+// outStreamReader returns a reader for the provided output stream of a
+// folder. Only coders with a single input and output are supported,
+// which means output stream i belongs to coder i and so does input
+// stream i.
+func (f *sevenZipFolder) outStreamReader(out uint64, packed []io.Reader, depth int) (io.Reader, error) {
+	if depth > len(f.coders) || out >= uint64(len(f.coders)) {
+		return nil, errSevenZipCorrupt
+	}
+
+	c := &f.coders[out]
+	if c.numInStreams != 1 || c.numOutStreams != 1 {
+		if bytes.Equal(c.method, sevenZipMethodBCJ2) {
+			return nil, fmt.Errorf("%w: 7z coder BCJ2", ErrUnsupportedFormat)
+		}
+		return nil, fmt.Errorf("%w: 7z coder with %d inputs and %d outputs", ErrUnsupportedFormat, c.numInStreams, c.numOutStreams)
+	}
+
+	// Find the input of the coder, either another coder or a packed
+	// stream.
+	var in io.Reader
+	if bp := f.findBindPairForIn(out); bp != -1 {
+		var err error
+		in, err = f.outStreamReader(f.bindPairs[bp].outIndex, packed, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for i, ps := range f.packedStreams {
+			if ps == out {
+				in = packed[i]
+				break
+			}
+		}
+
+		if in == nil {
+			return nil, fmt.Errorf("%w: coder has no input", errSevenZipCorrupt)
+		}
+	}
+
+	return newSevenZipCoderReader(c, in, f.unpackSizes[out])
+}
+
+// newSevenZipCoderReader returns a reader that decodes in using the
+// provided coder. size is the size of the coder's output.
+func newSevenZipCoderReader(c *sevenZipCoder, in io.Reader, size uint64) (io.Reader, error) {
+	switch {
+	case bytes.Equal(c.method, sevenZipMethodCopy):
+		return in, nil
+	case bytes.Equal(c.method, sevenZipMethodLZMA):
+		if len(c.props) != 5 {
+			return nil, fmt.Errorf("%w: invalid lzma properties", errSevenZipCorrupt)
+		}
+
+		// Construct a classic .lzma header from the properties and the
+		// known size.
+		hdr := make([]byte, lzma.HeaderLen)
+		copy(hdr, c.props)
+		binary.LittleEndian.PutUint64(hdr[5:], size)
+		return lzma.NewReader(bufio.NewReader(io.MultiReader(bytes.NewReader(hdr), in)))
+	case bytes.Equal(c.method, sevenZipMethodLZMA2):
+		if len(c.props) != 1 || c.props[0] > 40 {
+			return nil, fmt.Errorf("%w: invalid lzma2 properties", errSevenZipCorrupt)
+		}
+
+		dictCap := lzma.MaxDictCap
+		if c.props[0] < 40 {
+			dictCap = (2 | int(c.props[0]&1)) << (c.props[0]/2 + 11)
+		}
+		dictCap = max(dictCap, lzma.MinDictCap)
+
+		return lzma.Reader2Config{DictCap: dictCap}.NewReader2(bufio.NewReader(in))
+	case bytes.Equal(c.method, sevenZipMethodX86):
+		return bcj.NewReader(in, bcj.NewX86()), nil
+	case bytes.Equal(c.method, sevenZipMethodARM):
+		return bcj.NewReader(in, bcj.NewARM()), nil
+	case bytes.Equal(c.method, sevenZipMethodARM64):
+		return bcj.NewReader(in, bcj.NewARM64()), nil
+	case bytes.Equal(c.method, sevenZipMethodDelta):
+		distance := 1
+		if len(c.props) == 1 {
+			distance = int(c.props[0]) + 1
+		}
+		return &deltaReader{r: in, distance: distance}, nil
+	case bytes.Equal(c.method, sevenZipMethodDeflate):
+		return flate.NewReader(in), nil
+	case bytes.Equal(c.method, sevenZipMethodBzip2):
+		return bzip2.NewReader(in), nil
+	case bytes.Equal(c.method, sevenZipMethodZstd):
+		zr, err := zstd.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case bytes.Equal(c.method, sevenZipMethodAES):
+		return nil, fmt.Errorf("%w: encrypted 7z archives", ErrUnsupportedFormat)
+	default:
+		return nil, fmt.Errorf("%w: 7z coder method %x", ErrUnsupportedFormat, c.method)
+	}
+}
+
+// deltaReader decodes the 7z/xz delta filter.
+type deltaReader struct {
+	r        io.Reader
+	distance int
+
+	// history contains the last 256 decoded bytes.
+	history [256]byte
+	pos     byte
+}
+
+// Read implements [io.Reader].
+func (d *deltaReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] += d.history[byte(d.distance+int(d.pos))]
+		d.history[d.pos] = p[i]
+		d.pos--
+	}
+	return n, err
+}
+
+// Close implements [Archive].
+func (a *sevenZipArchive) Close() error {
+	return nil
+}
+
+// Read implements [io.Reader].
+func (a *sevenZipArchive) Read(p []byte) (int, error) {
+	if a.cur == nil {
+		return 0, io.EOF
+	}
+
+	n, err := a.cur.Read(p)
+	return n, classifyError(err)
+}
+
+// Next implements [Archive].
+func (a *sevenZipArchive) Next() (*Header, error) {
+	h, err := a.next()
+	return h, classifyError(err)
+}
+
+// next returns the header of the next entry.
+func (a *sevenZipArchive) next() (*Header, error) {
+	// Skip any unread data of the current file, the folder reader is
+	// shared by all files in a solid block.
+	if a.cur != nil {
+		if _, err := io.Copy(io.Discard, a.cur); err != nil {
+			return nil, fmt.Errorf("failed to skip file: %w", err)
+		}
+		a.cur = nil
+	}
+
+	for a.pos < len(a.files) {
+		f := &a.files[a.pos]
+		a.pos++
+
+		if f.hasStream {
+			if err := a.openSubStream(f); err != nil {
+				return nil, err
+			}
+		}
+
+		// Anti items denote deletions in update archives.
+		if f.isAnti {
+			continue
+		}
+
+		h := f.header()
+		if h.Type == HeaderSymlink {
+			// The target of a symlink is stored as its contents.
+			target, err := io.ReadAll(a)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read symlink target: %w", err)
+			}
+			h.Linkname = string(target)
+			h.Size = 0
+		}
+
+		return h, nil
+	}
+
+	return nil, io.EOF
+}
+
+// openSubStream sets a.cur to a reader for the contents of f, which is
+// the next stream in the archive.
+func (a *sevenZipArchive) openSubStream(f *sevenZipFile) error {
+	// Advance to the folder containing the next stream, skipping any
+	// folders without streams.
+	for a.folderReader == nil || a.subStream >= a.folderEnd() {
+		a.folder++
+		if a.folder >= len(a.streams.folders) {
+			return fmt.Errorf("%w: more files than folders", errSevenZipCorrupt)
+		}
+
+		if a.streams.folders[a.folder].numUnpackStreams == 0 {
+			continue
+		}
+
+		fr, err := a.newFolderReader(a.streams, a.folder)
+		if err != nil {
+			return fmt.Errorf("failed to open folder: %w", err)
+		}
+		a.folderReader = fr
+	}
+	a.subStream++
+
+	var r io.Reader = io.LimitReader(a.folderReader, int64(f.size)) //nolint:gosec // Why: Sizes fit.
+	if f.crcDefined {
+		r = &crcReader{r: r, hash: crc32.NewIEEE(), want: f.crc, size: f.size}
+	}
+	a.cur = r
+
+	return nil
+}
+
+// folderEnd returns the index of the first stream after the current
+// folder.
+func (a *sevenZipArchive) folderEnd() int {
+	var end uint64
+	for i := 0; i <= a.folder && i < len(a.streams.folders); i++ {
+		end += a.streams.folders[i].numUnpackStreams
+	}
+	return int(end) //nolint:gosec // Why: Bounded by sevenZipMaxEntries.
+}
+
+// header converts a 7z file entry into a [Header].
+func (f *sevenZipFile) header() *Header {
+	name := strings.ReplaceAll(f.name, "\\", "/")
+
+	isDir := f.isDir
+	if f.attribSet && f.attrib&windowsAttributeDirectory != 0 {
+		isDir = true
+	}
+
+	var mode os.FileMode
+	switch {
+	case f.attribSet && f.attrib&windowsAttributeUnixExtension != 0:
+		mode = unixModeToFileMode(f.attrib >> 16)
+	case isDir:
+		mode = 0o755
+	default:
+		mode = 0o644
+	}
+
+	if f.attribSet && f.attrib&windowsAttributeReadOnly != 0 {
+		mode &^= 0o222
+	}
+
+	h := &Header{
+		Name:       name,
+		Type:       HeaderFile,
+		Size:       int64(f.size), //nolint:gosec // Why: Sizes fit.
+		Mode:       mode,
+		AccessTime: f.atime,
+		ModTime:    f.mtime,
+	}
+
+	switch {
+	case isDir:
+		h.Type = HeaderDir
+		h.Mode |= os.ModeDir
+		h.Size = 0
+		if !strings.HasSuffix(h.Name, "/") {
+			h.Name += "/"
+		}
+	case mode&os.ModeSymlink != 0:
+		h.Type = HeaderSymlink
+	case mode&specialModes != 0:
+		h.Type = HeaderSpecial
+	}
+
+	return h
+}
+
+// crcReader verifies the CRC32 of the data read through it once size
+// bytes have been read.
+type crcReader struct {
+	r    io.Reader
+	hash hash.Hash32
+	want uint32
+	size uint64
+	read uint64
+}
+
+// Read implements [io.Reader].
+func (c *crcReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n]) //nolint:errcheck // Why: hash.Hash never returns an error.
+	c.read += uint64(n) //nolint:gosec // Why: n is never negative.
+
+	if err == io.EOF {
+		if c.read != c.size {
+			return n, io.ErrUnexpectedEOF
+		}
+
+		if c.hash.Sum32() != c.want {
+			return n, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,879 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf16"
+)
+
+// Contains the property IDs used in 7z headers.
+const (
+	sevenZipIDEnd                   = 0x00
+	sevenZipIDHeader                = 0x01
+	sevenZipIDArchiveProperties     = 0x02
+	sevenZipIDAdditionalStreamsInfo = 0x03
+	sevenZipIDMainStreamsInfo       = 0x04
+	sevenZipIDFilesInfo             = 0x05
+	sevenZipIDPackInfo              = 0x06
+	sevenZipIDUnpackInfo            = 0x07
+	sevenZipIDSubStreamsInfo        = 0x08
+	sevenZipIDSize                  = 0x09
+	sevenZipIDCRC                   = 0x0a
+	sevenZipIDFolder                = 0x0b
+	sevenZipIDCodersUnpackSize      = 0x0c
+	sevenZipIDNumUnpackStream       = 0x0d
+	sevenZipIDEmptyStream           = 0x0e
+	sevenZipIDEmptyFile             = 0x0f
+	sevenZipIDAnti                  = 0x10
+	sevenZipIDName                  = 0x11
+	sevenZipIDATime                 = 0x13
+	sevenZipIDMTime                 = 0x14
+	sevenZipIDWinAttributes         = 0x15
+	sevenZipIDEncodedHeader         = 0x17
+)
+
+// Contains limits used to guard against corrupt headers causing
+// excessive allocations.
+const (
+	sevenZipMaxEntries    = 1 << 24
+	sevenZipMaxCoders     = 64
+	sevenZipMaxHeaderSize = 64 << 20
+)
+
+// errSevenZipCorrupt is returned when a 7z header is malformed.
+var errSevenZipCorrupt = fmt.Errorf("%w: 7z header", ErrCorrupt)
+
+// sevenZipCoder is a single coder (compression method or filter) in a
+// folder.
+type sevenZipCoder struct {
+	method        []byte
+	numInStreams  uint64
+	numOutStreams uint64
+	props         []byte
+}
+
+// sevenZipBindPair connects the output of one coder to the input of
+// another.
+type sevenZipBindPair struct {
+	inIndex, outIndex uint64
+}
+
+// sevenZipFolder is a set of coders that together decode one or more
+// packed streams into a single unpacked stream. A folder may contain
+// multiple files (solid compression).
+type sevenZipFolder struct {
+	coders        []sevenZipCoder
+	bindPairs     []sevenZipBindPair
+	packedStreams []uint64
+	unpackSizes   []uint64
+
+	crc        uint32
+	crcDefined bool
+
+	// numUnpackStreams is the number of files stored in the folder.
+	numUnpackStreams uint64
+}
+
+// sevenZipStreamsInfo describes the packed streams and folders of an
+// archive.
+type sevenZipStreamsInfo struct {
+	packPos   uint64
+	packSizes []uint64
+	folders   []sevenZipFolder
+
+	// subStreamSizes and subStreamCRCs contain the size and CRC (if
+	// defined) of every file stored in the folders, in order.
+	subStreamSizes      []uint64
+	subStreamCRCs       []uint32
+	subStreamCRCDefined []bool
+}
+
+// sevenZipFile is a file entry in the FilesInfo section.
+type sevenZipFile struct {
+	name       string
+	hasStream  bool
+	isDir      bool
+	isAnti     bool
+	mtime      time.Time
+	atime      time.Time
+	attrib     uint32
+	attribSet  bool
+	crc        uint32
+	crcDefined bool
+	size       uint64
+}
+
+// sevenZipHeaderReader reads 7z header structures.
+type sevenZipHeaderReader struct {
+	*bytes.Reader
+}
+
+// number reads a 7z variable length number. The number of leading one
+// bits of the first byte denotes the number of additional bytes.
+func (r *sevenZipHeaderReader) number() (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	var v uint64
+	mask := byte(0x80)
+	for i := 0; i < 8; i++ {
+		if first&mask == 0 {
+			high := uint64(first & (mask - 1))
+			return v | high<<(8*i), nil
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b) << (8 * i)
+		mask >>= 1
+	}
+
+	return v, nil
+}
+
+// count reads a number that is used to size an allocation. Every
+// counted item takes at least one byte of the header, so the count is
+// also bounded by the remaining bytes.
+func (r *sevenZipHeaderReader) count(limit uint64) (int, error) {
+	n, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+
+	if n > limit || n > uint64(r.Len()) {
+		return 0, errSevenZipCorrupt
+	}
+
+	return int(n), nil //nolint:gosec // Why: Checked above.
+}
+
+// uint32 reads a little endian uint32.
+func (r *sevenZipHeaderReader) uint32() (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// uint64 reads a little endian uint64.
+func (r *sevenZipHeaderReader) uint64() (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// expect reads a property ID and ensures it matches id.
+func (r *sevenZipHeaderReader) expect(id uint64) error {
+	got, err := r.number()
+	if err != nil {
+		return err
+	}
+
+	if got != id {
+		return fmt.Errorf("%w: unexpected property %#x, expected %#x", errSevenZipCorrupt, got, id)
+	}
+	return nil
+}
+
+// bitVector reads a bit vector of n items, most significant bit first.
+func (r *sevenZipHeaderReader) bitVector(n int) ([]bool, error) {
+	v := make([]bool, n)
+
+	var b byte
+	for i := 0; i < n; i++ {
+		if i%8 == 0 {
+			var err error
+			if b, err = r.ReadByte(); err != nil {
+				return nil, err
+			}
+		}
+		v[i] = b&(0x80>>(i%8)) != 0
+	}
+
+	return v, nil
+}
+
+// definedVector reads a bit vector that is prefixed with an "all
+// defined" byte.
+func (r *sevenZipHeaderReader) definedVector(n int) ([]bool, error) {
+	all, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if all == 0 {
+		return r.bitVector(n)
+	}
+
+	v := make([]bool, n)
+	for i := range v {
+		v[i] = true
+	}
+	return v, nil
+}
+
+// digests reads n optional CRC32 digests.
+func (r *sevenZipHeaderReader) digests(n int) ([]uint32, []bool, error) {
+	defined, err := r.definedVector(n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	crcs := make([]uint32, n)
+	for i := range crcs {
+		if !defined[i] {
+			continue
+		}
+
+		if crcs[i], err = r.uint32(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return crcs, defined, nil
+}
+
+// skip skips n bytes.
+func (r *sevenZipHeaderReader) skip(n uint64) error {
+	if n > uint64(r.Len()) {
+		return errSevenZipCorrupt
+	}
+
+	_, err := r.Seek(int64(n), io.SeekCurrent) //nolint:gosec // Why: Checked above.
+	return err
+}
+
+// streamsInfo reads a StreamsInfo structure.
+func (r *sevenZipHeaderReader) streamsInfo() (*sevenZipStreamsInfo, error) {
+	si := &sevenZipStreamsInfo{}
+	for {
+		id, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case sevenZipIDEnd:
+			if si.subStreamSizes == nil {
+				// Without SubStreamsInfo, every folder contains exactly one
+				// file.
+				for i := range si.folders {
+					f := &si.folders[i]
+					f.numUnpackStreams = 1
+					si.subStreamSizes = append(si.subStreamSizes, f.unpackSize())
+					si.subStreamCRCs = append(si.subStreamCRCs, f.crc)
+					si.subStreamCRCDefined = append(si.subStreamCRCDefined, f.crcDefined)
+				}
+			}
+			return si, nil
+		case sevenZipIDPackInfo:
+			if err := r.packInfo(si); err != nil {
+				return nil, err
+			}
+		case sevenZipIDUnpackInfo:
+			if err := r.unpackInfo(si); err != nil {
+				return nil, err
+			}
+		case sevenZipIDSubStreamsInfo:
+			if err := r.subStreamsInfo(si); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: unexpected property %#x in streams info", errSevenZipCorrupt, id)
+		}
+	}
+}
+
+// packInfo reads a PackInfo structure.
+func (r *sevenZipHeaderReader) packInfo(si *sevenZipStreamsInfo) error {
+	var err error
+	if si.packPos, err = r.number(); err != nil {
+		return err
+	}
+
+	n, err := r.count(sevenZipMaxEntries)
+	if err != nil {
+		return err
+	}
+
+	for {
+		id, err := r.number()
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case sevenZipIDEnd:
+			if len(si.packSizes) != n {
+				return fmt.Errorf("%w: missing pack sizes", errSevenZipCorrupt)
+			}
+			return nil
+		case sevenZipIDSize:
+			si.packSizes = make([]uint64, n)
+			for i := range si.packSizes {
+				if si.packSizes[i], err = r.number(); err != nil {
+					return err
+				}
+			}
+		case sevenZipIDCRC:
+			// Packed stream CRCs are optional and not used.
+			if _, _, err := r.digests(n); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unexpected property %#x in pack info", errSevenZipCorrupt, id)
+		}
+	}
+}
+
+// unpackInfo reads an UnpackInfo (CodersInfo) structure.
+func (r *sevenZipHeaderReader) unpackInfo(si *sevenZipStreamsInfo) error {
+	if err := r.expect(sevenZipIDFolder); err != nil {
+		return err
+	}
+
+	n, err := r.count(sevenZipMaxEntries)
+	if err != nil {
+		return err
+	}
+
+	if external, err := r.ReadByte(); err != nil {
+		return err
+	} else if external != 0 {
+		return fmt.Errorf("%w: external folders are not supported", errSevenZipCorrupt)
+	}
+
+	si.folders = make([]sevenZipFolder, n)
+	for i := range si.folders {
+		if err := r.folder(&si.folders[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := r.expect(sevenZipIDCodersUnpackSize); err != nil {
+		return err
+	}
+
+	for i := range si.folders {
+		f := &si.folders[i]
+		for j := range f.unpackSizes {
+			if f.unpackSizes[j], err = r.number(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		id, err := r.number()
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case sevenZipIDEnd:
+			return nil
+		case sevenZipIDCRC:
+			crcs, defined, err := r.digests(n)
+			if err != nil {
+				return err
+			}
+
+			for i := range si.folders {
+				si.folders[i].crc, si.folders[i].crcDefined = crcs[i], defined[i]
+			}
+		default:
+			return fmt.Errorf("%w: unexpected property %#x in unpack info", errSevenZipCorrupt, id)
+		}
+	}
+}
+
+// folder reads a Folder structure.
+func (r *sevenZipHeaderReader) folder(f *sevenZipFolder) error {
+	numCoders, err := r.count(sevenZipMaxCoders)
+	if err != nil {
+		return err
+	}
+
+	var totalIn, totalOut uint64
+	f.coders = make([]sevenZipCoder, numCoders)
+	for i := range f.coders {
+		c := &f.coders[i]
+
+		flags, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		if flags&0x80 != 0 {
+			return fmt.Errorf("%w: alternative coder methods are not supported", errSevenZipCorrupt)
+		}
+
+		c.method = make([]byte, flags&0x0f)
+		if _, err := io.ReadFull(r, c.method); err != nil {
+			return err
+		}
+
+		c.numInStreams, c.numOutStreams = 1, 1
+		if flags&0x10 != 0 {
+			if c.numInStreams, err = r.number(); err != nil {
+				return err
+			}
+			if c.numOutStreams, err = r.number(); err != nil {
+				return err
+			}
+
+			if c.numInStreams > sevenZipMaxCoders || c.numOutStreams > sevenZipMaxCoders {
+				return errSevenZipCorrupt
+			}
+		}
+
+		if flags&0x20 != 0 {
+			n, err := r.count(uint64(r.Len()))
+			if err != nil {
+				return err
+			}
+
+			c.props = make([]byte, n)
+			if _, err := io.ReadFull(r, c.props); err != nil {
+				return err
+			}
+		}
+
+		totalIn += c.numInStreams
+		totalOut += c.numOutStreams
+	}
+
+	if totalOut == 0 || totalIn < totalOut-1 {
+		return errSevenZipCorrupt
+	}
+
+	f.bindPairs = make([]sevenZipBindPair, totalOut-1)
+	for i := range f.bindPairs {
+		if f.bindPairs[i].inIndex, err = r.number(); err != nil {
+			return err
+		}
+		if f.bindPairs[i].outIndex, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	numPacked := totalIn - uint64(len(f.bindPairs))
+	if numPacked == 1 {
+		// The packed stream is the only input that isn't bound.
+		for i := uint64(0); i < totalIn; i++ {
+			if f.findBindPairForIn(i) == -1 {
+				f.packedStreams = []uint64{i}
+				break
+			}
+		}
+
+		if len(f.packedStreams) != 1 {
+			return errSevenZipCorrupt
+		}
+	} else {
+		f.packedStreams = make([]uint64, numPacked)
+		for i := range f.packedStreams {
+			if f.packedStreams[i], err = r.number(); err != nil {
+				return err
+			}
+		}
+	}
+
+	f.unpackSizes = make([]uint64, totalOut)
+	return nil
+}
+
+// findBindPairForIn returns the index of the bind pair for the input
+// stream in, or -1.
+func (f *sevenZipFolder) findBindPairForIn(in uint64) int {
+	for i, bp := range f.bindPairs {
+		if bp.inIndex == in {
+			return i
+		}
+	}
+	return -1
+}
+
+// findBindPairForOut returns the index of the bind pair for the output
+// stream out, or -1.
+func (f *sevenZipFolder) findBindPairForOut(out uint64) int {
+	for i, bp := range f.bindPairs {
+		if bp.outIndex == out {
+			return i
+		}
+	}
+	return -1
+}
+
+// mainOutStream returns the index of the output stream that isn't
+// bound to another coder, which is the final output of the folder.
+func (f *sevenZipFolder) mainOutStream() (uint64, error) {
+	for i := range f.unpackSizes {
+		if f.findBindPairForOut(uint64(i)) == -1 {
+			return uint64(i), nil
+		}
+	}
+	return 0, errSevenZipCorrupt
+}
+
+// unpackSize returns the size of the final output of the folder.
+func (f *sevenZipFolder) unpackSize() uint64 {
+	out, err := f.mainOutStream()
+	if err != nil {
+		return 0
+	}
+	return f.unpackSizes[out]
+}
+
+// subStreamsInfo reads a SubStreamsInfo structure.
+func (r *sevenZipHeaderReader) subStreamsInfo(si *sevenZipStreamsInfo) error {
+	for i := range si.folders {
+		si.folders[i].numUnpackStreams = 1
+	}
+
+	id, err := r.number()
+	if err != nil {
+		return err
+	}
+
+	if id == sevenZipIDNumUnpackStream {
+		var total uint64
+		for i := range si.folders {
+			n, err := r.number()
+			if err != nil {
+				return err
+			}
+
+			total += n
+			if n > sevenZipMaxEntries || total > sevenZipMaxEntries {
+				return errSevenZipCorrupt
+			}
+			si.folders[i].numUnpackStreams = n
+		}
+
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	// Sizes are stored for all but the last file in a folder, which is
+	// the remainder of the folder.
+	hasSizes := id == sevenZipIDSize
+	for i := range si.folders {
+		f := &si.folders[i]
+		if f.numUnpackStreams == 0 {
+			continue
+		}
+
+		if !hasSizes && f.numUnpackStreams > 1 {
+			return fmt.Errorf("%w: missing substream sizes", errSevenZipCorrupt)
+		}
+
+		var sum uint64
+		for j := uint64(1); j < f.numUnpackStreams; j++ {
+			var size uint64
+			if hasSizes {
+				if size, err = r.number(); err != nil {
+					return err
+				}
+			}
+
+			si.subStreamSizes = append(si.subStreamSizes, size)
+			sum += size
+		}
+
+		if sum > f.unpackSize() {
+			return errSevenZipCorrupt
+		}
+		si.subStreamSizes = append(si.subStreamSizes, f.unpackSize()-sum)
+	}
+
+	if hasSizes {
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	// Digests are only stored for files whose CRC isn't already known
+	// from the folder.
+	var numDigests int
+	for i := range si.folders {
+		f := &si.folders[i]
+		if f.numUnpackStreams != 1 || !f.crcDefined {
+			numDigests += int(f.numUnpackStreams) //nolint:gosec // Why: Bounded above.
+		}
+	}
+
+	var crcs []uint32
+	var defined []bool
+	for id != sevenZipIDEnd {
+		switch id {
+		case sevenZipIDCRC:
+			if crcs, defined, err = r.digests(numDigests); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unexpected property %#x in substreams info", errSevenZipCorrupt, id)
+		}
+
+		if id, err = r.number(); err != nil {
+			return err
+		}
+	}
+
+	var d int
+	for i := range si.folders {
+		f := &si.folders[i]
+		if f.numUnpackStreams == 1 && f.crcDefined {
+			si.subStreamCRCs = append(si.subStreamCRCs, f.crc)
+			si.subStreamCRCDefined = append(si.subStreamCRCDefined, true)
+			continue
+		}
+
+		for j := uint64(0); j < f.numUnpackStreams; j++ {
+			if crcs == nil {
+				si.subStreamCRCs = append(si.subStreamCRCs, 0)
+				si.subStreamCRCDefined = append(si.subStreamCRCDefined, false)
+				continue
+			}
+
+			si.subStreamCRCs = append(si.subStreamCRCs, crcs[d])
+			si.subStreamCRCDefined = append(si.subStreamCRCDefined, defined[d])
+			d++
+		}
+	}
+
+	return nil
+}
+
+// filesInfo reads a FilesInfo structure.
+func (r *sevenZipHeaderReader) filesInfo() ([]sevenZipFile, error) {
+	n, err := r.count(sevenZipMaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]sevenZipFile, n)
+	for i := range files {
+		files[i].hasStream = true
+	}
+
+	var emptyStreams []bool
+	var numEmpty int
+	for {
+		id, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+
+		if id == sevenZipIDEnd {
+			break
+		}
+
+		size, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+
+		if size > uint64(r.Len()) {
+			return nil, errSevenZipCorrupt
+		}
+		end := r.Size() - int64(r.Len()) + int64(size) //nolint:gosec // Why: Checked above.
+
+		switch id {
+		case sevenZipIDEmptyStream:
+			if emptyStreams, err = r.bitVector(n); err != nil {
+				return nil, err
+			}
+
+			numEmpty = 0
+			for i, empty := range emptyStreams {
+				files[i].hasStream = !empty
+				// Empty streams are directories unless marked as an empty
+				// file.
+				files[i].isDir = empty
+				if empty {
+					numEmpty++
+				}
+			}
+		case sevenZipIDEmptyFile, sevenZipIDAnti:
+			v, err := r.bitVector(numEmpty)
+			if err != nil {
+				return nil, err
+			}
+
+			var j int
+			for i := range files {
+				if files[i].hasStream {
+					continue
+				}
+
+				if v[j] {
+					if id == sevenZipIDEmptyFile {
+						files[i].isDir = false
+					} else {
+						files[i].isAnti = true
+					}
+				}
+				j++
+			}
+		case sevenZipIDName:
+			if err := r.names(files); err != nil {
+				return nil, err
+			}
+		case sevenZipIDMTime, sevenZipIDATime:
+			if err := r.times(files, id); err != nil {
+				return nil, err
+			}
+		case sevenZipIDWinAttributes:
+			if err := r.attributes(files); err != nil {
+				return nil, err
+			}
+		}
+
+		// Skip any unknown properties (and any padding in known ones).
+		if _, err := r.Seek(end, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// external reads the "external" byte that precedes some properties and
+// ensures it is not set.
+func (r *sevenZipHeaderReader) external() error {
+	external, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if external != 0 {
+		return fmt.Errorf("%w: external data is not supported", errSevenZipCorrupt)
+	}
+	return nil
+}
+
+// names reads the UTF-16LE, null terminated names of all files.
+func (r *sevenZipHeaderReader) names(files []sevenZipFile) error {
+	if err := r.external(); err != nil {
+		return err
+	}
+
+	for i := range files {
+		var name []uint16
+		for {
+			c1, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			c2, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			c := uint16(c1) | uint16(c2)<<8
+			if c == 0 {
+				break
+			}
+			name = append(name, c)
+		}
+
+		files[i].name = string(utf16.Decode(name))
+	}
+
+	return nil
+}
+
+// times reads the MTime or ATime property for all files.
+func (r *sevenZipHeaderReader) times(files []sevenZipFile, id uint64) error {
+	defined, err := r.definedVector(len(files))
+	if err != nil {
+		return err
+	}
+
+	if err := r.external(); err != nil {
+		return err
+	}
+
+	for i := range files {
+		if !defined[i] {
+			continue
+		}
+
+		ft, err := r.uint64()
+		if err != nil {
+			return err
+		}
+
+		t := filetimeToTime(ft)
+		if id == sevenZipIDMTime {
+			files[i].mtime = t
+		} else {
+			files[i].atime = t
+		}
+	}
+
+	return nil
+}
+
+// attributes reads the Windows attributes for all files.
+func (r *sevenZipHeaderReader) attributes(files []sevenZipFile) error {
+	defined, err := r.definedVector(len(files))
+	if err != nil {
+		return err
+	}
+
+	if err := r.external(); err != nil {
+		return err
+	}
+
+	for i := range files {
+		if !defined[i] {
+			continue
+		}
+
+		if files[i].attrib, err = r.uint32(); err != nil {
+			return err
+		}
+		files[i].attribSet = true
+	}
+
+	return nil
+}
+
+// filetimeToTime converts a Windows FILETIME (100ns intervals since
+// 1601-01-01) into a [time.Time].
+func filetimeToTime(ft uint64) time.Time {
+	// Number of 100ns intervals between 1601-01-01 and 1970-01-01.
+	const epochDiff = 116444736000000000
+	return time.Unix(0, (int64(ft)-epochDiff)*100).UTC() //nolint:gosec // Why: FILETIMEs fit.
+}
@@ -0,0 +1,189 @@
+package archives_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/sevenziptest"
+	"gotest.tools/v3/assert"
+)
+
+// sevenZipFiles are the files used by the 7z tests.
+var sevenZipFiles = []sevenziptest.File{
+	{Name: "dir", Dir: true, Attributes: sevenziptest.UnixAttributes(os.ModeDir | 0o750)},
+	{Name: `dir\file.txt`, Contents: []byte("hello world"), Attributes: sevenziptest.UnixAttributes(0o644)},
+	{Name: "empty.txt"},
+	{Name: "code.bin", Contents: bytes.Repeat([]byte{0xe8, 0x10, 0x00, 0x00, 0x00, 0x90}, 4096)},
+	{Name: "readme.txt", Contents: []byte("read me"), Attributes: 0x01},
+}
+
+func TestSevenZip(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []sevenziptest.OptionFn
+	}{
+		{"lzma2", nil},
+		{"lzma", []sevenziptest.OptionFn{sevenziptest.WithMethod(sevenziptest.MethodLZMA)}},
+		{"copy", []sevenziptest.OptionFn{sevenziptest.WithMethod(sevenziptest.MethodCopy)}},
+		{"solid", []sevenziptest.OptionFn{sevenziptest.WithSolid()}},
+		{"bcj", []sevenziptest.OptionFn{sevenziptest.WithBCJ(), sevenziptest.WithSolid()}},
+		{"encoded header", []sevenziptest.OptionFn{sevenziptest.WithEncodedHeader(), sevenziptest.WithSolid()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := sevenziptest.Create(sevenZipFiles, tt.options...)
+			assert.NilError(t, err)
+
+			a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+				Extension: archives.Ext("archive.7z"),
+			})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			got := make(map[string]string)
+			for {
+				h, err := a.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NilError(t, err)
+
+				contents, err := io.ReadAll(a)
+				assert.NilError(t, err)
+				got[h.Name] = string(contents)
+			}
+
+			assert.DeepEqual(t, got, map[string]string{
+				"dir/":         "",
+				"dir/file.txt": "hello world",
+				"empty.txt":    "",
+				"code.bin":     string(sevenZipFiles[3].Contents),
+				"readme.txt":   "read me",
+			})
+		})
+	}
+}
+
+func TestSevenZipHeaders(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	b, err := sevenziptest.Create([]sevenziptest.File{
+		{Name: "dir", Dir: true, Attributes: sevenziptest.UnixAttributes(os.ModeDir | 0o750), ModTime: modTime},
+		{Name: "file.txt", Contents: []byte("hello world"), Attributes: sevenziptest.UnixAttributes(0o600), ModTime: modTime},
+		{Name: "readonly.txt", Attributes: 0x01},
+		{Name: "link", Contents: []byte("file.txt"), Attributes: sevenziptest.UnixAttributes(os.ModeSymlink | 0o777)},
+	})
+	assert.NilError(t, err)
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".7z"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	h, err := a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "dir/")
+	assert.Equal(t, h.Type, archives.HeaderDir)
+	assert.Equal(t, h.Mode, os.ModeDir|0o750)
+	assert.Assert(t, h.ModTime.Equal(modTime))
+
+	h, err = a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "file.txt")
+	assert.Equal(t, h.Type, archives.HeaderFile)
+	assert.Equal(t, h.Size, int64(11))
+	assert.Equal(t, h.Mode, os.FileMode(0o600))
+
+	h, err = a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "readonly.txt")
+	assert.Equal(t, h.Size, int64(0))
+	assert.Equal(t, h.Mode, os.FileMode(0o444))
+
+	h, err = a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "link")
+	assert.Equal(t, h.Type, archives.HeaderSymlink)
+	assert.Equal(t, h.Linkname, "file.txt")
+
+	_, err = a.Next()
+	assert.Equal(t, err, io.EOF)
+}
+
+func TestSevenZipExtract(t *testing.T) {
+	b, err := sevenziptest.Create(sevenZipFiles, sevenziptest.WithSolid(), sevenziptest.WithEncodedHeader())
+	assert.NilError(t, err)
+
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(b), dest, archives.ExtractOptions{
+		Extension: ".7z",
+	}))
+
+	got, err := os.ReadFile(filepath.Join(dest, "dir", "file.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello world")
+
+	got, err = os.ReadFile(filepath.Join(dest, "empty.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "")
+
+	info, err := os.Stat(filepath.Join(dest, "dir"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o750))
+}
+
+func TestSevenZipCorrupt(t *testing.T) {
+	b, err := sevenziptest.Create(sevenZipFiles, sevenziptest.WithMethod(sevenziptest.MethodCopy))
+	assert.NilError(t, err)
+
+	// Flip a byte of the stored contents of dir/file.txt.
+	i := bytes.Index(b, []byte("hello world"))
+	assert.Assert(t, i > 0)
+	b[i] ^= 0xff
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".7z"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = archives.Pick(a, archives.PickFilterByName("dir/file.txt"))
+	assert.NilError(t, err)
+
+	_, err = io.ReadAll(a)
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestSevenZipMalformedHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		next []byte
+	}{
+		{"encoded header size", []byte{
+			0x17,                         // EncodedHeader
+			0x06, 0x00, 0x01, 0x09, 0x00, // PackInfo, one empty stream
+			0x00,
+			0x07, 0x0b, 0x01, 0x00, // UnpackInfo, one folder
+			0x01, 0x01, 0x00, // Copy coder
+			0x0c, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, // CodersUnpackSize
+			0x00,
+			0x00,
+		}},
+		{"pack stream count", []byte{
+			0x01, 0x04, // Header, MainStreamsInfo
+			0x06, 0x00, 0xe0, 0xff, 0xff, 0xff, 0x09, // PackInfo
+		}},
+		{"folder count", []byte{
+			0x01, 0x04, // Header, MainStreamsInfo
+			0x07, 0x0b, 0xe0, 0xff, 0xff, 0xff, 0x00, // UnpackInfo
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := sevenziptest.Raw(nil, tt.next)
+			_, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".7z"})
+			assert.ErrorIs(t, err, archives.ErrCorrupt)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// staging is a temporary directory that an archive is extracted into
+// before being moved into its final destination. It is created next to
+// the destination so that the final move is a rename on the same
+// filesystem.
+type staging struct {
+	dir string
+}
+
+// newStaging creates a new staging directory for dest.
+func newStaging(dest string) (*staging, error) {
+	dest = filepath.Clean(dest)
+
+	parent := filepath.Dir(dest)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	dir, err := os.MkdirTemp(parent, "."+filepath.Base(dest)+".staging-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &staging{dir}, nil
+}
+
+// Cleanup removes the staging directory and anything left in it. It is
+// safe to call after Commit.
+func (s *staging) Cleanup() error {
+	return os.RemoveAll(s.dir)
+}
+
+// Commit moves the contents of the staging directory into dest. If
+// dest does not exist, the staging directory is renamed to it.
+// Otherwise, the contents are merged into dest, replacing any existing
+// files.
+//
+// Moving a directory into another one requires it to be writable, so
+// the metadata of extracted directories has to be applied after Commit.
+func (s *staging) Commit(dest string) error {
+	dest = filepath.Clean(dest)
+	if _, err := os.Lstat(dest); errors.Is(err, fs.ErrNotExist) {
+		// MkdirTemp always creates the directory as 0700.
+		if err := os.Chmod(s.dir, 0o755); err != nil {
+			return fmt.Errorf("failed to set directory permissions: %w", err)
+		}
+
+		if err := os.Rename(s.dir, dest); err != nil {
+			return fmt.Errorf("failed to move staging directory into place: %w", err)
+		}
+
+		return nil
+	}
+
+	return mergeDir(s.dir, dest)
+}
+
+// mergeDir moves all entries in src into dest, recursing into
+// directories that exist in both.
+func mergeDir(src, dest string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("failed to read staging directory: %w", err)
+	}
+
+	for _, e := range entries {
+		s := filepath.Join(src, e.Name())
+		d := filepath.Join(dest, e.Name())
+
+		di, err := os.Lstat(d)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("failed to stat %s: %w", d, err)
+		case e.IsDir() && di.IsDir():
+			if err := mergeDir(s, d); err != nil {
+				return err
+			}
+			continue
+		case e.IsDir() || di.IsDir():
+			// Renaming across a file and a directory is not possible.
+			if err := os.RemoveAll(d); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", d, err)
+			}
+		}
+
+		if err := os.Rename(s, d); err != nil {
+			return fmt.Errorf("failed to move %s into place: %w", e.Name(), err)
+		}
+	}
+
+	return nil
+}
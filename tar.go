@@ -22,6 +22,7 @@ import (
 	stdtar "archive/tar"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // _ ensures that tar implements the [Archiver] interface.
@@ -33,73 +34,120 @@ type tar struct{}
 
 // Extensions returns the supported extensions for the tar extractor.
 func (t *tar) Extensions() []string {
-	return []string{"tar", "tgz", "tar.gz", "txz", "tar.xz", "tbz2", "tar.bz2", "tar.zst"}
+	return []string{
+		"tar", "tgz", "tar.gz", "txz", "tar.xz", "tbz2", "tar.bz2", "tar.zst",
+		"tar.lz4", "tar.lz", "tar.lzma", "tar.br", "taz", "tar.Z",
+	}
 }
 
 func (t *tar) Open(r io.Reader, ext string) (Archive, error) {
 	// Determine if we're dealing with a compressed tar archive and if so,
 	// create the appropriate reader.
-	var container io.ReadCloser
+	container, err := newContainerReader(r, tarCodec(ext))
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &tarReader{r: container, cur: &io.LimitedReader{R: container}}
+	return &tarArchive{r: tr, closer: container}, nil
+}
+
+// tarCodec returns the codec of the container of tar archives with the
+// provided extension, or an empty string if they aren't compressed.
+func tarCodec(ext string) string {
 	switch ext {
 	case "tar":
-		container = io.NopCloser(r)
-	case "tgz", "tar.gz":
-		var err error
-		container, err = newGzipReader(r)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
-		}
-	case "tbz2", "tar.bz2":
-		container = newBzip2Reader(r)
-	case "txz", "tar.xz":
-		var err error
-		container, err = newXZReader(r)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create xz reader: %w", err)
-		}
-	case "tar.zst":
-		var err error
-		container, err = newZstdReader(r)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
-		}
+		return ""
+	case "tgz":
+		return "gz"
+	case "txz":
+		return "xz"
+	case "tbz2":
+		return "bz2"
+	case "taz":
+		return "Z"
 	default:
-		// This only happens if we're missing a case in the switch statement.
-		return nil, fmt.Errorf("unsupported tar extension: %s", ext)
+		return strings.TrimPrefix(ext, "tar.")
 	}
-
-	tr := stdtar.NewReader(container)
-	return &tarArchive{tr, container}, nil
 }
 
 type tarArchive struct {
-	*stdtar.Reader
+	r      *tarReader
 	closer io.Closer
+
+	// sparse reads the contents of the current file if it has holes.
+	sparse *tarSparseReader
 }
 
+// _ ensures that tarArchive implements the [holeSkipper] interface.
+var _ holeSkipper = (&tarArchive{})
+
 func (t *tarArchive) Close() error {
 	return t.closer.Close()
 }
 
+// Read implements [io.Reader].
+func (t *tarArchive) Read(p []byte) (int, error) {
+	if t.sparse != nil {
+		n, err := t.sparse.Read(p)
+		return n, classifyError(err)
+	}
+
+	n, err := t.r.Read(p)
+	return n, classifyError(err)
+}
+
+// skipHole implements [holeSkipper].
+func (t *tarArchive) skipHole(n int64) error {
+	if t.sparse == nil {
+		return fmt.Errorf("%d bytes at the current offset aren't part of a hole", n)
+	}
+
+	return t.sparse.skipHole(n)
+}
+
 func (t *tarArchive) Next() (*Header, error) {
-	h, err := t.Reader.Next()
+	t.sparse = nil
+	h, holes, err := t.r.Next()
+	// Global PAX headers only contain defaults for the following entries.
+	for err == nil && h.Typeflag == stdtar.TypeXGlobalHeader {
+		h, holes, err = t.r.Next()
+	}
 	if err != nil {
-		return nil, err
+		return nil, classifyError(err)
+	}
+	if len(holes) > 0 {
+		t.sparse = &tarSparseReader{r: t.r, holes: holes, size: h.Size}
 	}
 
-	hType := HeaderFile
-	if h.FileInfo().IsDir() {
+	hType := HeaderSpecial
+	var linkname string
+	switch {
+	case h.FileInfo().IsDir():
 		hType = HeaderDir
+	case h.Typeflag == stdtar.TypeSymlink:
+		hType = HeaderSymlink
+		linkname = h.Linkname
+	case h.Typeflag == stdtar.TypeLink:
+		hType = HeaderHardlink
+		linkname = h.Linkname
+	case h.Typeflag == stdtar.TypeReg || h.Typeflag == stdtar.TypeCont || h.Typeflag == stdtar.TypeGNUSparse:
+		// archive/tar converts the old regular file type to TypeReg.
+		hType = HeaderFile
 	}
 
 	return &Header{
-		Name:       h.Name,
-		Type:       hType,
-		Mode:       h.FileInfo().Mode(),
-		Size:       h.Size,
-		AccessTime: h.AccessTime,
-		ModTime:    h.ModTime,
-		UID:        h.Uid,
-		GID:        h.Gid,
+		Name:        h.Name,
+		Type:        hType,
+		Mode:        h.FileInfo().Mode(),
+		Linkname:    linkname,
+		Size:        h.Size,
+		AccessTime:  h.AccessTime,
+		ModTime:     h.ModTime,
+		UID:         h.Uid,
+		GID:         h.Gid,
+		Uname:       h.Uname,
+		Gname:       h.Gname,
+		SparseHoles: holes,
 	}, nil
 }
@@ -19,13 +19,78 @@
 package archives
 
 import (
+	"bufio"
 	"compress/bzip2"
 	"compress/gzip"
+	"fmt"
 	"io"
 
+	"github.com/andybalholm/brotli"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+	"go.rgst.io/jaredallard/archives/v2/internal/lzip"
+	"go.rgst.io/jaredallard/archives/v2/internal/unixcompress"
 )
 
+// containerExtensions contains the extensions of all compression
+// containers supported by [newContainerReader].
+var containerExtensions = []string{"gz", "xz", "bz2", "zst", "lz4", "lz", "lzma", "br", "Z"}
+
+// newContainerReader creates a new reader that decompresses r using the
+// codec denoted by the provided extension (see [containerExtensions]).
+// An empty codec returns r as-is.
+func newContainerReader(r io.Reader, codec string) (io.ReadCloser, error) {
+	var container io.ReadCloser
+	var err error
+	switch codec {
+	case "":
+		container = io.NopCloser(r)
+	case "gz":
+		container, err = newGzipReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+	case "bz2":
+		container = newBzip2Reader(r)
+	case "xz":
+		container, err = newXZReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create xz reader: %w", err)
+		}
+	case "zst":
+		container, err = newZstdReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+	case "lz4":
+		container = newLz4Reader(r)
+	case "lz":
+		container, err = newLzipReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lzip reader: %w", err)
+		}
+	case "lzma":
+		container, err = newLzmaReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lzma reader: %w", err)
+		}
+	case "br":
+		container = newBrotliReader(r)
+	case "Z":
+		container, err = newCompressReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create compress reader: %w", err)
+		}
+	default:
+		// This only happens if we're missing a case in the switch statement.
+		return nil, fmt.Errorf("%w: compression container %s", ErrUnsupportedFormat, codec)
+	}
+
+	return container, nil
+}
+
 // newGzipReader creates a new gzip reader from the provided reader.
 func newGzipReader(r io.Reader) (io.ReadCloser, error) {
 	return gzip.NewReader(r)
@@ -44,3 +109,116 @@ func newZstdReader(r io.Reader) (io.ReadCloser, error) {
 	}
 	return io.NopCloser(r), nil
 }
+
+// newLz4Reader creates a new lz4 frame reader from the provided reader.
+func newLz4Reader(r io.Reader) io.ReadCloser {
+	return io.NopCloser(lz4.NewReader(r))
+}
+
+// newLzipReader creates a new lzip reader from the provided reader.
+func newLzipReader(r io.Reader) (io.ReadCloser, error) {
+	lr, err := lzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(lr), nil
+}
+
+// newLzmaReader creates a new reader for the legacy .lzma format
+// (LZMA-Alone) from the provided reader.
+func newLzmaReader(r io.Reader) (io.ReadCloser, error) {
+	lr, err := lzma.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(lr), nil
+}
+
+// newBrotliReader creates a new brotli reader from the provided reader.
+func newBrotliReader(r io.Reader) io.ReadCloser {
+	return io.NopCloser(brotli.NewReader(r))
+}
+
+// newCompressReader creates a new reader for the Unix compress(1) (.Z)
+// format from the provided reader.
+func newCompressReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := unixcompress.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(zr), nil
+}
+
+// newContainerWriter creates a new writer that compresses data written
+// to it into w using the codec denoted by the provided extension (see
+// [containerExtensions]). An empty codec writes to w as-is. Closing the
+// returned writer does not close w.
+//
+// If reproducible is set, compressors use fixed settings that don't
+// depend on the environment (see [CreateOptions.Reproducible]).
+func newContainerWriter(w io.Writer, codec string, reproducible bool) (io.WriteCloser, error) {
+	var container io.WriteCloser
+	var err error
+	switch codec {
+	case "":
+		container = nopWriteCloser{w}
+	case "gz":
+		if !reproducible {
+			container = gzip.NewWriter(w)
+			break
+		}
+
+		var gw *gzip.Writer
+		if gw, err = gzip.NewWriterLevel(w, gzip.BestCompression); err == nil {
+			// No name, modification time or operating system.
+			gw.Header = gzip.Header{OS: gzipUnknownOS}
+			container = gw
+		}
+	case "xz":
+		container, err = xz.NewWriter(w)
+	case "zst":
+		if reproducible {
+			// Use a single goroutine, so that the output doesn't depend on
+			// the number of CPUs.
+			container, err = zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		} else {
+			container, err = zstd.NewWriter(w)
+		}
+	case "lz4":
+		lw := lz4.NewWriter(w)
+		if reproducible {
+			err = lw.Apply(lz4.CompressionLevelOption(lz4.Fast), lz4.ConcurrencyOption(1))
+		}
+		container = lw
+	case "lz":
+		container, err = lzip.NewWriter(w)
+	case "lzma":
+		container, err = lzma.NewWriter(w)
+	case "br":
+		container = brotli.NewWriter(w)
+	case "Z":
+		container = unixcompress.NewWriter(w)
+	default:
+		// bzip2 has no writer in the standard library.
+		return nil, fmt.Errorf("%w: compression container %s for writing", ErrUnsupportedFormat, codec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s writer: %w", codec, err)
+	}
+
+	return container, nil
+}
+
+// gzipUnknownOS is the operating system of gzip headers denoting an
+// unknown operating system.
+const gzipUnknownOS = 255
+
+// nopWriteCloser is an [io.WriteCloser] whose Close does nothing.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements [io.Closer].
+func (nopWriteCloser) Close() error {
+	return nil
+}
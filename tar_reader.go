@@ -0,0 +1,340 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdtar "archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// tarBlockSize is the size of the blocks tar archives consist of.
+const tarBlockSize = 512
+
+// tarMaxSpecialSize is the maximum size of the PAX and GNU long name
+// headers preceding an entry, like archive/tar.
+const tarMaxSpecialSize = 1 << 20
+
+// tarReader reads the entries of a tar archive. archive/tar doesn't
+// expose the sparse maps of sparse files, and can't skip their holes
+// without reading them as zeros, so it's only used to decode headers:
+// tarReader reads the blocks making up the header of each entry, which
+// are decoded by a [stdtar.Reader] reading them alone, and reads the
+// contents of entries itself.
+type tarReader struct {
+	r io.Reader
+
+	// blocks contains the blocks making up the header of the current
+	// entry.
+	blocks []byte
+
+	// cur reads the stored contents of the current entry, and pad is the
+	// number of bytes of padding following them.
+	cur *io.LimitedReader
+	pad int64
+
+	// err is the error returned by Next once the end of the archive was
+	// reached or it failed.
+	err error
+}
+
+// Next advances to the next entry, returning its header and the holes
+// of its contents if it's a sparse file (see [Header.SparseHoles]).
+func (t *tarReader) Next() (*stdtar.Header, []SparseEntry, error) {
+	if t.err != nil {
+		return nil, nil, t.err
+	}
+
+	h, holes, err := t.next()
+	if err != nil {
+		t.err = err
+		return nil, nil, err
+	}
+	return h, holes, nil
+}
+
+// next implements Next.
+func (t *tarReader) next() (*stdtar.Header, []SparseEntry, error) {
+	if _, err := io.CopyN(io.Discard, t.r, t.cur.N); err != nil {
+		return nil, nil, unexpectedEOF(err)
+	}
+
+	// Like archive/tar, archives ending in the padding following the
+	// contents of the last entry are accepted.
+	if _, err := io.CopyN(io.Discard, t.r, t.pad); err != nil {
+		return nil, nil, err
+	}
+	t.cur.N, t.pad = 0, 0
+
+	if err := t.readHeader(); err != nil {
+		return nil, nil, err
+	}
+
+	h, err := stdtar.NewReader(bytes.NewReader(t.blocks)).Next()
+	if err != nil {
+		// All blocks of the header were read, so running out of them means
+		// that the header is malformed.
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			err = stdtar.ErrHeader
+		}
+		return nil, nil, err
+	}
+
+	holes, err := t.sparseHoles(h)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Only the data of sparse files is stored in the archive, and entries
+	// that can't have contents never have any.
+	size := h.Size
+	for _, hole := range holes {
+		size -= hole.Length
+	}
+	switch h.Typeflag {
+	case stdtar.TypeLink, stdtar.TypeSymlink, stdtar.TypeChar, stdtar.TypeBlock, stdtar.TypeDir, stdtar.TypeFifo:
+		size = 0
+	}
+	t.cur.N, t.pad = size, alignTarBlock(size)-size
+
+	return h, holes, nil
+}
+
+// Read reads the stored contents of the current entry, which only
+// contain the data of sparse files.
+func (t *tarReader) Read(p []byte) (int, error) {
+	n, err := t.cur.Read(p)
+	if err == io.EOF && t.cur.N > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+// readHeader reads the blocks making up the header of the next entry
+// into t.blocks: the PAX and GNU long name headers preceding it, the
+// header itself, and the sparse map following it for sparse files.
+func (t *tarReader) readHeader() error {
+	t.blocks = t.blocks[:0]
+
+	var paxRecords []byte
+	for {
+		blk, err := t.readBlocks(1)
+		if err != nil {
+			if len(t.blocks) > 0 {
+				return unexpectedEOF(err)
+			}
+			return err
+		}
+
+		// The end of the archive is marked by two zero blocks.
+		if isZeroTarBlock(blk) {
+			blk, err := t.readBlocks(1)
+			if err != nil {
+				return err
+			}
+			if !isZeroTarBlock(blk) {
+				return stdtar.ErrHeader
+			}
+			return io.EOF
+		}
+
+		typeflag, extended := blk[156], blk[482]
+		switch typeflag {
+		case stdtar.TypeXHeader, stdtar.TypeXGlobalHeader, stdtar.TypeGNULongName, stdtar.TypeGNULongLink:
+			size, err := parseTarNumber(blk[124:136])
+			if err != nil || size > tarMaxSpecialSize {
+				return stdtar.ErrHeader
+			}
+
+			data, err := t.readBlocks(alignTarBlock(size) / tarBlockSize)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+
+			// Global PAX headers are returned as entries by archive/tar.
+			if typeflag == stdtar.TypeXGlobalHeader {
+				return nil
+			}
+			if typeflag == stdtar.TypeXHeader {
+				paxRecords = append(paxRecords, data[:size]...)
+			}
+			continue
+		case stdtar.TypeGNUSparse:
+			// The sparse map of old GNU format sparse files continues in
+			// extension headers if it doesn't fit into the header.
+			for extended != 0 {
+				ext, err := t.readBlocks(1)
+				if err != nil {
+					return unexpectedEOF(err)
+				}
+				extended = ext[504]
+			}
+			return nil
+		}
+
+		// The sparse map of PAX format 1.0 sparse files is stored before
+		// their data.
+		if paxRecord(paxRecords, "GNU.sparse.major") == "1" && paxRecord(paxRecords, "GNU.sparse.minor") == "0" {
+			size, err := parseTarNumber(blk[124:136])
+			if s := paxRecord(paxRecords, "size"); s != "" {
+				size, err = strconv.ParseInt(s, 10, 64)
+			}
+			if err != nil {
+				return stdtar.ErrHeader
+			}
+			return t.readSparseMap1x0(size)
+		}
+		return nil
+	}
+}
+
+// readSparseMap1x0 reads the blocks containing the sparse map of a PAX
+// format 1.0 sparse file (see [parseGNUSparseMap1x0]), which are part of
+// its stored contents of the provided size.
+func (t *tarReader) readSparseMap1x0(size int64) error {
+	start := len(t.blocks)
+
+	// The map consists of the number of entries and their offsets and
+	// lengths, one per line.
+	var lines, want int64 = 0, 1
+	for lines < want {
+		if int64(len(t.blocks)-start) >= size {
+			return stdtar.ErrHeader
+		}
+
+		blk, err := t.readBlocks(1)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		for i, c := range blk {
+			if c != '\n' {
+				continue
+			}
+
+			lines++
+			if lines == 1 {
+				end := len(t.blocks) - tarBlockSize + i
+				count, err := strconv.ParseInt(string(t.blocks[start:end]), 10, 64)
+				// Every entry takes at least 4 bytes.
+				if err != nil || count < 0 || count > size/4 {
+					return stdtar.ErrHeader
+				}
+				want = 1 + 2*count
+			}
+		}
+	}
+	return nil
+}
+
+// readBlocks appends the next n blocks of the archive to t.blocks and
+// returns them.
+func (t *tarReader) readBlocks(n int64) ([]byte, error) {
+	start := len(t.blocks)
+	t.blocks = append(t.blocks, make([]byte, n*tarBlockSize)...)
+	if _, err := io.ReadFull(t.r, t.blocks[start:]); err != nil {
+		t.blocks = t.blocks[:start]
+		return nil, err
+	}
+	return t.blocks[start:], nil
+}
+
+// isZeroTarBlock returns true if blk only consists of zeros.
+func isZeroTarBlock(blk []byte) bool {
+	for _, c := range blk {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// paxRecord returns the value of the record with the provided key in
+// data, the contents of PAX headers, or an empty string if it's
+// missing. Malformed records are ignored, archive/tar rejects them.
+func paxRecord(data []byte, key string) string {
+	var v string
+	for len(data) > 0 {
+		// Records have the form "<length> <key>=<value>\n", where length
+		// is the length of the entire record.
+		sp := bytes.IndexByte(data, ' ')
+		if sp < 0 {
+			break
+		}
+		n, err := strconv.Atoi(string(data[:sp]))
+		if err != nil || n <= sp || n > len(data) {
+			break
+		}
+
+		k, val, ok := strings.Cut(strings.TrimSuffix(string(data[sp+1:n]), "\n"), "=")
+		if ok && k == key {
+			v = val
+		}
+		data = data[n:]
+	}
+	return v
+}
+
+// parseTarNumber parses a numeric field of a tar header, which is either
+// an octal number or, if the high bit of the first byte is set, a
+// big-endian base-256 number (a GNU extension for large values).
+func parseTarNumber(b []byte) (int64, error) {
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		if b[0]&0x40 != 0 {
+			return 0, fmt.Errorf("negative number")
+		}
+
+		var n int64
+		for i, c := range b {
+			if i == 0 {
+				c &= 0x7f
+			}
+			if n>>55 != 0 {
+				return 0, fmt.Errorf("number out of range")
+			}
+			n = n<<8 | int64(c)
+		}
+		return n, nil
+	}
+
+	s := strings.Trim(string(b), " \x00")
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 8, 64)
+}
+
+// alignTarBlock rounds n up to a multiple of [tarBlockSize].
+func alignTarBlock(n int64) int64 {
+	return (n + tarBlockSize - 1) / tarBlockSize * tarBlockSize
+}
+
+// unexpectedEOF converts [io.EOF] into [io.ErrUnexpectedEOF], for reads
+// that are expected to return data.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,268 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdtar "archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sparseHoles returns the holes of h if it is a sparse file, using the
+// sparse map stored in the blocks of its header or its PAX records.
+func (t *tarReader) sparseHoles(h *stdtar.Header) ([]SparseEntry, error) {
+	var data []SparseEntry
+	var err error
+	major, minor := h.PAXRecords["GNU.sparse.major"], h.PAXRecords["GNU.sparse.minor"]
+	switch {
+	case h.Typeflag == stdtar.TypeGNUSparse:
+		// Old GNU format, the map is stored in the header and extension
+		// headers following it.
+		var hdr []byte
+		if hdr, err = t.header(); err == nil {
+			data, err = parseOldGNUSparseMap(hdr[:tarBlockSize], hdr[tarBlockSize:])
+		}
+	case major == "1" && minor == "0":
+		// PAX format 1.0, the map is stored before the contents.
+		var hdr []byte
+		if hdr, err = t.header(); err == nil {
+			data, err = parseGNUSparseMap1x0(hdr[tarBlockSize:])
+		}
+	case (major == "0" && (minor == "0" || minor == "1")) || (major == "" && minor == "" && h.PAXRecords["GNU.sparse.map"] != ""):
+		// PAX format 0.0 and 0.1, the map is stored in the PAX records
+		// (archive/tar converts 0.0 maps).
+		data, err = parseGNUSparseMap0x1(h.PAXRecords["GNU.sparse.map"])
+	default:
+		return nil, nil
+	}
+
+	var holes []SparseEntry
+	if err == nil {
+		holes, err = invertSparseEntries(data, h.Size)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid sparse map of %q: %w", ErrCorrupt, h.Name, err)
+	}
+	return holes, nil
+}
+
+// header returns the blocks of the header of the current entry starting
+// at the header itself, skipping the PAX and GNU long name headers
+// preceding it.
+func (t *tarReader) header() ([]byte, error) {
+	blocks := t.blocks
+	for len(blocks) >= tarBlockSize {
+		switch blocks[156] {
+		case stdtar.TypeXHeader, stdtar.TypeXGlobalHeader, stdtar.TypeGNULongName, stdtar.TypeGNULongLink:
+			size, err := parseTarNumber(blocks[124:136])
+			if err != nil {
+				return nil, err
+			}
+			if skip := tarBlockSize + alignTarBlock(size); skip <= int64(len(blocks)) {
+				blocks = blocks[skip:]
+				continue
+			}
+		default:
+			return blocks, nil
+		}
+		break
+	}
+	return nil, fmt.Errorf("header not found")
+}
+
+// parseOldGNUSparseMap parses the sparse map of old GNU format sparse
+// files from their header hdr and the extension headers ext following
+// it.
+func parseOldGNUSparseMap(hdr, ext []byte) ([]SparseEntry, error) {
+	var data []SparseEntry
+	entries, extended := hdr[386:482], hdr[482]
+	for {
+		for ; len(entries) >= 24; entries = entries[24:] {
+			// Like GNU tar, stop at the first empty entry.
+			if entries[0] == 0 {
+				break
+			}
+
+			offset, err := parseTarNumber(entries[:12])
+			if err != nil {
+				return nil, err
+			}
+			length, err := parseTarNumber(entries[12:24])
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, SparseEntry{Offset: offset, Length: length})
+		}
+
+		if extended == 0 {
+			return data, nil
+		}
+		if len(ext) < tarBlockSize {
+			return nil, fmt.Errorf("missing extension header")
+		}
+		entries, extended, ext = ext[:504], ext[504], ext[tarBlockSize:]
+	}
+}
+
+// parseGNUSparseMap1x0 parses the sparse map of PAX format 1.0 sparse
+// files, a newline separated list of decimal numbers containing the
+// number of entries followed by their offsets and lengths.
+func parseGNUSparseMap1x0(b []byte) ([]SparseEntry, error) {
+	next := func() (int64, error) {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("unterminated number")
+		}
+		n, err := strconv.ParseInt(string(b[:i]), 10, 64)
+		b = b[i+1:]
+		return n, err
+	}
+
+	count, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []SparseEntry
+	for range count {
+		offset, err := next()
+		if err != nil {
+			return nil, err
+		}
+		length, err := next()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, SparseEntry{Offset: offset, Length: length})
+	}
+	return data, nil
+}
+
+// parseGNUSparseMap0x1 parses the sparse map of PAX format 0.1 sparse
+// files, a comma separated list of offsets and lengths.
+func parseGNUSparseMap0x1(s string) ([]SparseEntry, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(s, ",")
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("odd number of fields")
+	}
+
+	data := make([]SparseEntry, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		offset, err := strconv.ParseInt(fields[i], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, SparseEntry{Offset: offset, Length: length})
+	}
+	return data, nil
+}
+
+// invertSparseEntries converts the data regions of a sparse file of the
+// provided size into its holes.
+func invertSparseEntries(data []SparseEntry, size int64) ([]SparseEntry, error) {
+	var holes []SparseEntry
+	var off int64
+	for _, d := range data {
+		if d.Offset < off || d.Length < 0 || d.Offset+d.Length > size {
+			return nil, fmt.Errorf("overlapping or out of range entries")
+		}
+		if d.Offset > off {
+			holes = append(holes, SparseEntry{Offset: off, Length: d.Offset - off})
+		}
+		off = d.Offset + d.Length
+	}
+	if off < size {
+		holes = append(holes, SparseEntry{Offset: off, Length: size - off})
+	}
+	return holes, nil
+}
+
+// holeSkipper is implemented by archives that can skip the holes of the
+// current file (see [Header.SparseHoles]) without reading them.
+type holeSkipper interface {
+	// skipHole skips the next n bytes of the current file, which must be
+	// part of a hole.
+	skipHole(n int64) error
+}
+
+// tarSparseReader reads the contents of a sparse file of a tar archive,
+// returning zeros for its holes unless they're skipped using skipHole.
+type tarSparseReader struct {
+	r *tarReader
+
+	// holes contains the remaining holes of the file.
+	holes []SparseEntry
+
+	// pos is the offset in the file, and size its size.
+	pos, size int64
+}
+
+// Read implements [io.Reader].
+func (r *tarSparseReader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+
+	if len(r.holes) > 0 && r.pos >= r.holes[0].Offset {
+		n := int(min(int64(len(p)), r.holes[0].Offset+r.holes[0].Length-r.pos))
+		clear(p[:n])
+		r.advance(int64(n))
+		return n, nil
+	}
+
+	end := r.size
+	if len(r.holes) > 0 {
+		end = r.holes[0].Offset
+	}
+	n, err := r.r.Read(p[:min(int64(len(p)), end-r.pos)])
+	r.pos += int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// skipHole implements [holeSkipper].
+func (r *tarSparseReader) skipHole(n int64) error {
+	if len(r.holes) == 0 || r.pos < r.holes[0].Offset || n > r.holes[0].Offset+r.holes[0].Length-r.pos {
+		return fmt.Errorf("%d bytes at offset %d aren't part of a hole", n, r.pos)
+	}
+
+	r.advance(n)
+	return nil
+}
+
+// advance advances the offset by n bytes of the current hole.
+func (r *tarSparseReader) advance(n int64) {
+	r.pos += n
+	if r.pos == r.holes[0].Offset+r.holes[0].Length {
+		r.holes = r.holes[1:]
+	}
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package archives
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"gotest.tools/v3/assert"
+)
+
+func TestExtractSparseCreatesHoles(t *testing.T) {
+	b, err := tartest.CreateSparse(tartest.SparseFile{
+		Name:    "disk.img",
+		Size:    64 << 20,
+		Regions: []tartest.Region{{Offset: 1 << 20, Data: []byte("data")}},
+		Format:  tartest.SparsePAX10,
+	})
+	assert.NilError(t, err)
+
+	dest := t.TempDir()
+	assert.NilError(t, Extract(bytes.NewReader(b), dest, ExtractOptions{Extension: ".tar"}))
+
+	fi, err := os.Stat(filepath.Join(dest, "disk.img"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Size(), int64(64<<20))
+
+	// Blocks are counted in units of 512 bytes.
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	assert.Assert(t, ok)
+	assert.Assert(t, st.Blocks*512 < 1<<20, "%d bytes are allocated", st.Blocks*512)
+}
+
+func TestExtractSparseSkipsHoles(t *testing.T) {
+	// Reading the holes of a 1 TiB file as zeros would take seconds.
+	b, err := tartest.CreateSparse(tartest.SparseFile{
+		Name:    "huge.img",
+		Size:    1 << 40,
+		Regions: []tartest.Region{{Offset: 1 << 30, Data: []byte("data")}},
+		Format:  tartest.SparsePAX10,
+	})
+	assert.NilError(t, err)
+
+	dest := t.TempDir()
+	assert.NilError(t, Extract(bytes.NewReader(b), dest, ExtractOptions{Extension: ".tar"}))
+
+	fi, err := os.Stat(filepath.Join(dest, "huge.img"))
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Size(), int64(1<<40))
+}
@@ -1,8 +1,13 @@
 package archives
 
 import (
+	stdtar "archive/tar"
+	"bytes"
+	"crypto/sha256"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
@@ -20,6 +25,11 @@ func TestTarContainers(t *testing.T) {
 		{tartest.ContainerXz, "xz"},
 		{tartest.ContainerBz2, "bz2"},
 		{tartest.ContainerZstd, "zst"},
+		{tartest.ContainerLz4, "lz4"},
+		{tartest.ContainerLzip, "lz"},
+		{tartest.ContainerLzma, "lzma"},
+		{tartest.ContainerBrotli, "br"},
+		{tartest.ContainerCompress, "Z"},
 	}
 	for _, container := range containers {
 		t.Run(container.ext, func(t *testing.T) {
@@ -47,3 +57,189 @@ func TestTarContainers(t *testing.T) {
 		})
 	}
 }
+
+func TestTarTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	tw := stdtar.NewWriter(&buf)
+	assert.NilError(t, tw.WriteHeader(&stdtar.Header{
+		Name:       "a.txt",
+		Typeflag:   stdtar.TypeReg,
+		Size:       5,
+		PAXRecords: map[string]string{"comment": "hello"},
+	}))
+	_, err := tw.Write([]byte("hello"))
+	assert.NilError(t, err)
+	assert.NilError(t, tw.Close())
+	b := buf.Bytes()
+
+	// The archive consists of a PAX header and its records, the header of
+	// a.txt, and its contents and their padding.
+	tests := []struct {
+		name string
+		size int
+		err  error
+	}{
+		{"in PAX header", tarBlockSize + 10, ErrTruncated},
+		{"in header", 2*tarBlockSize + 10, ErrTruncated},
+		{"in contents", 3*tarBlockSize + 2, ErrTruncated},
+		{"in padding", 3*tarBlockSize + 10, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := Open(bytes.NewReader(b[:tc.size]), OpenOptions{Extension: ".tar"})
+			assert.NilError(t, err)
+
+			for err == nil {
+				if _, err = a.Next(); err == nil {
+					_, err = io.Copy(io.Discard, a)
+				}
+			}
+			if tc.err == nil {
+				assert.Equal(t, err, io.EOF)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+// sparseFiles returns a regular file, sparse files stored using format
+// and another regular file, so that reading the entries following the
+// sparse files is tested as well.
+func sparseFiles(format tartest.SparseFormat) []tartest.SparseFile {
+	var regions []tartest.Region
+	for i := range 30 {
+		regions = append(regions, tartest.Region{Offset: int64(i) * 8192, Data: bytes.Repeat([]byte{byte('a' + i%26)}, 1000)})
+	}
+
+	return []tartest.SparseFile{
+		{Name: "before.txt", Size: 6, Regions: []tartest.Region{{Data: []byte("before")}}},
+		{Name: "many", Size: 30 * 8192, Regions: regions, Format: format},
+		{Name: "dir/ends-with-hole", Size: 1 << 20, Regions: []tartest.Region{{Offset: 4096, Data: []byte("data")}}, Format: format},
+		{Name: "empty", Size: 4096, Format: format},
+		{Name: "after.txt", Size: 5, Regions: []tartest.Region{{Data: []byte("after")}}},
+	}
+}
+
+// sparseFormats contains the sparse formats to test.
+var sparseFormats = map[string]tartest.SparseFormat{
+	"gnu":    tartest.SparseGNU,
+	"pax0.1": tartest.SparsePAX01,
+	"pax1.0": tartest.SparsePAX10,
+}
+
+func TestTarSparseHoles(t *testing.T) {
+	for name, format := range sparseFormats {
+		t.Run(name, func(t *testing.T) {
+			files := sparseFiles(format)
+			b, err := tartest.CreateSparse(files...)
+			assert.NilError(t, err)
+
+			a, err := Open(bytes.NewReader(b), OpenOptions{Extension: ".tar"})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			for _, f := range files {
+				h, err := a.Next()
+				assert.NilError(t, err)
+				assert.Equal(t, h.Name, f.Name)
+				assert.Equal(t, h.Size, f.Size)
+
+				var holes []SparseEntry
+				if f.Format != tartest.SparseNone {
+					var off int64
+					for _, r := range f.Regions {
+						if r.Offset > off {
+							holes = append(holes, SparseEntry{Offset: off, Length: r.Offset - off})
+						}
+						off = r.Offset + int64(len(r.Data))
+					}
+					if off < f.Size {
+						holes = append(holes, SparseEntry{Offset: off, Length: f.Size - off})
+					}
+				}
+				assert.DeepEqual(t, h.SparseHoles, holes)
+
+				got, err := io.ReadAll(a)
+				assert.NilError(t, err)
+				assert.Assert(t, bytes.Equal(got, f.Contents()), "contents of %s differ", f.Name)
+			}
+
+			_, err = a.Next()
+			assert.Equal(t, err, io.EOF)
+		})
+	}
+}
+
+func TestTarSkipHoles(t *testing.T) {
+	for name, format := range sparseFormats {
+		t.Run(name, func(t *testing.T) {
+			files := sparseFiles(format)
+			b, err := tartest.CreateSparse(files...)
+			assert.NilError(t, err)
+
+			a, err := Open(bytes.NewReader(b), OpenOptions{Extension: ".tar"})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			skipper, ok := a.(holeSkipper)
+			assert.Assert(t, ok)
+
+			for _, f := range files {
+				h, err := a.Next()
+				assert.NilError(t, err)
+
+				if len(h.SparseHoles) == 0 {
+					assert.ErrorContains(t, skipper.skipHole(1), "aren't part of a hole")
+				}
+
+				got := make([]byte, h.Size)
+				var off int64
+				for _, hole := range h.SparseHoles {
+					_, err := io.ReadFull(a, got[off:hole.Offset])
+					assert.NilError(t, err)
+					assert.NilError(t, skipper.skipHole(hole.Length))
+					off = hole.Offset + hole.Length
+				}
+				_, err = io.ReadFull(a, got[off:])
+				assert.NilError(t, err)
+				assert.Assert(t, bytes.Equal(got, f.Contents()), "contents of %s differ", f.Name)
+
+				n, err := a.Read(make([]byte, 1))
+				assert.Equal(t, n, 0)
+				assert.Equal(t, err, io.EOF)
+			}
+
+			_, err = a.Next()
+			assert.Equal(t, err, io.EOF)
+		})
+	}
+}
+
+func TestExtractSparse(t *testing.T) {
+	for name, format := range sparseFormats {
+		t.Run(name, func(t *testing.T) {
+			files := sparseFiles(format)
+			b, err := tartest.CreateSparse(files...)
+			assert.NilError(t, err)
+
+			dest := t.TempDir()
+			res, err := ExtractWithResult(bytes.NewReader(b), dest, ExtractOptions{Extension: ".tar", Digest: sha256.New})
+			assert.NilError(t, err)
+
+			digests := make(map[string][]byte)
+			for _, e := range res.Written {
+				digests[e.Header.Name] = e.Digest
+			}
+
+			for _, f := range files {
+				got, err := os.ReadFile(filepath.Join(dest, f.Name))
+				assert.NilError(t, err)
+				assert.Assert(t, bytes.Equal(got, f.Contents()), "contents of %s differ", f.Name)
+
+				sum := sha256.Sum256(f.Contents())
+				assert.DeepEqual(t, digests[f.Name], sum[:])
+			}
+		})
+	}
+}
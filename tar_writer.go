@@ -0,0 +1,97 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdtar "archive/tar"
+	"fmt"
+	"io"
+)
+
+// _ ensures that tar implements the [WriterArchiver] interface.
+var _ WriterArchiver = (&tar{})
+
+// Create returns an [ArchiveWriter] writing a tar archive, compressed
+// using the container denoted by ext, to w.
+func (t *tar) Create(w io.Writer, ext string, opts *CreateOptions) (ArchiveWriter, error) {
+	container, err := newContainerWriter(w, tarCodec(ext), opts.Reproducible)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarWriter{stdtar.NewWriter(container), container}, nil
+}
+
+// tarWriter is an implementation of the [ArchiveWriter] interface for
+// tar archives.
+type tarWriter struct {
+	*stdtar.Writer
+	container io.WriteCloser
+}
+
+// WriteHeader implements [ArchiveWriter].
+func (t *tarWriter) WriteHeader(h *Header) error {
+	th := &stdtar.Header{
+		Name:       h.Name,
+		Mode:       int64(fileModeToUnixMode(h.Mode)),
+		Size:       h.Size,
+		ModTime:    h.ModTime,
+		AccessTime: h.AccessTime,
+		Uid:        h.UID,
+		Gid:        h.GID,
+		Uname:      h.Uname,
+		Gname:      h.Gname,
+		Linkname:   h.Linkname,
+		Format:     stdtar.FormatPAX,
+	}
+
+	switch h.Type {
+	case HeaderFile:
+		th.Typeflag = stdtar.TypeReg
+	case HeaderDir:
+		th.Typeflag = stdtar.TypeDir
+		th.Size = 0
+		if th.Name != "" && th.Name[len(th.Name)-1] != '/' {
+			th.Name += "/"
+		}
+	case HeaderSymlink:
+		th.Typeflag = stdtar.TypeSymlink
+		th.Size = 0
+	case HeaderHardlink:
+		th.Typeflag = stdtar.TypeLink
+		th.Size = 0
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupportedEntryType, h.Type)
+	}
+
+	return t.Writer.WriteHeader(th)
+}
+
+// Close finishes the tar archive and flushes the container.
+func (t *tarWriter) Close() error {
+	if err := t.Writer.Close(); err != nil {
+		return fmt.Errorf("failed to close tar writer: %w", err)
+	}
+
+	if err := t.container.Close(); err != nil {
+		return fmt.Errorf("failed to close compression container: %w", err)
+	}
+
+	return nil
+}
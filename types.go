@@ -19,6 +19,7 @@
 package archives
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"time"
@@ -32,23 +33,59 @@ type HeaderType int
 const (
 	HeaderFile HeaderType = iota
 	HeaderDir
+	HeaderSymlink
+	HeaderHardlink
+
+	// HeaderSpecial is an entry of any other type, such as a device or
+	// named pipe, as denoted by its mode. It is not extracted, see
+	// [ErrUnsupportedEntryType].
+	HeaderSpecial
 )
 
+// String returns the name of the header type.
+func (t HeaderType) String() string {
+	switch t {
+	case HeaderFile:
+		return "file"
+	case HeaderDir:
+		return "dir"
+	case HeaderSymlink:
+		return "symlink"
+	case HeaderHardlink:
+		return "hardlink"
+	case HeaderSpecial:
+		return "special"
+	default:
+		return fmt.Sprintf("HeaderType(%d)", int(t))
+	}
+}
+
 // Header represents metadata about a file in an archive.
 type Header struct {
 	// Name is the name of the file or directory.
 	Name string
 
+	// RawName is the name of the file as stored in the archive, before
+	// it was decoded into Name. Only set by formats that decode names
+	// (zip), see [OpenOptions.NameDecoder].
+	RawName []byte
+
 	// Type is the type of header.
 	Type HeaderType
 
 	// Size is the size of the file. If the header is a directory, this
-	// will be 0.
+	// will be 0. If the size is not known ahead of time (e.g., for single
+	// file compressed streams), this will be -1.
 	Size int64
 
 	// Mode is the file mode.
 	Mode os.FileMode
 
+	// Linkname is the target of a symbolic link, or the name of the
+	// earlier entry in the archive a hard link refers to. Only set if
+	// the header is a [HeaderSymlink] or [HeaderHardlink].
+	Linkname string
+
 	// AccessTime is the time the file was last accessed.
 	AccessTime time.Time
 
@@ -60,6 +97,33 @@ type Header struct {
 
 	// GID is the group ID of the file.
 	GID int
+
+	// Uname is the user name of the owner of the file, if known.
+	Uname string
+
+	// Gname is the group name of the owner of the file, if known.
+	Gname string
+
+	// Encrypted is true if the contents of the file are encrypted.
+	// Reading them requires [OpenOptions.Password] or
+	// [OpenOptions.PasswordProvider], callers that don't have a password
+	// can use this to skip the file.
+	Encrypted bool
+
+	// SparseHoles contains the regions of a sparse file that only consist
+	// of zeros and aren't stored in the archive, sorted by offset. They
+	// are read as zeros. Only set by formats supporting sparse files
+	// (tar).
+	SparseHoles []SparseEntry
+}
+
+// SparseEntry is a region of a sparse file, see [Header.SparseHoles].
+type SparseEntry struct {
+	// Offset is the offset of the region in the file.
+	Offset int64
+
+	// Length is the length of the region.
+	Length int64
 }
 
 // Archive represents an archive containing folders and files.
@@ -81,10 +145,60 @@ type Archive interface {
 type Archiver interface {
 	// Open opens the provided reader and returns an archive. Depending on
 	// the implementation, this may read the entire archive into memory
-	// (e.g., zip).
+	// (e.g., zip). ext is the extension of the archive without the
+	// leading period.
 	Open(r io.Reader, ext string) (Archive, error)
 
 	// Extensions should return a list of supported extensions for this
 	// extractor.
 	Extensions() []string
 }
+
+// OptionsArchiver is implemented by [Archiver]s that use [OpenOptions]
+// (e.g., passwords of encrypted archives). Archives are opened using
+// OpenWithOptions instead of Open if implemented.
+type OptionsArchiver interface {
+	Archiver
+
+	// OpenWithOptions opens the provided reader using opts and returns
+	// an archive. ext is the extension of the archive without the
+	// leading period.
+	OpenWithOptions(r io.Reader, ext string, opts *OpenOptions) (Archive, error)
+}
+
+// ReaderAtArchiver is implemented by [Archiver]s that require random
+// access to an archive (e.g., iso). Opening an archive through
+// [OpenReaderAt] allows these formats to read it in place instead of
+// reading it into memory first.
+type ReaderAtArchiver interface {
+	Archiver
+
+	// OpenReaderAt opens the archive of the provided size read from r.
+	// ext is the extension of the archive without the leading period.
+	OpenReaderAt(r io.ReaderAt, size int64, ext string, opts *OpenOptions) (Archive, error)
+}
+
+// ArchiveWriter writes entries to an archive, see [Create].
+type ArchiveWriter interface {
+	// Write writes the contents of the current file. Exactly
+	// [Header.Size] bytes must be written.
+	io.Writer
+
+	// WriteHeader starts a new entry described by h. The contents of
+	// files are written using Write afterwards.
+	WriteHeader(h *Header) error
+
+	// Close finishes writing the archive. It does not close the
+	// underlying writer.
+	Close() error
+}
+
+// WriterArchiver is implemented by [Archiver]s that can create
+// archives.
+type WriterArchiver interface {
+	Archiver
+
+	// Create returns an [ArchiveWriter] writing an archive to w. ext is
+	// the extension of the archive without the leading period.
+	Create(w io.Writer, ext string, opts *CreateOptions) (ArchiveWriter, error)
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+//go:build unix
+
+package archives
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// processUmask returns the umask of the process. On Linux, it is read
+// from /proc/self/status, so changes to it are picked up. Elsewhere, see
+// [syscallUmask].
+func processUmask() os.FileMode {
+	if umask, ok := procUmask(); ok {
+		return umask
+	}
+	return syscallUmask()
+}
+
+// syscallUmask returns the umask of the process, which can only be read
+// by setting it. That affects all goroutines, so it is only read once.
+var syscallUmask = sync.OnceValue(func() os.FileMode {
+	umask := syscall.Umask(0)
+	syscall.Umask(umask)
+	return os.FileMode(umask) & os.ModePerm
+})
+
+// procUmask reads the umask of the process from the "Umask:" field of
+// /proc/self/status, available since Linux 4.7.
+func procUmask() (os.FileMode, bool) {
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		v, ok := bytes.CutPrefix(s.Bytes(), []byte("Umask:"))
+		if !ok {
+			continue
+		}
+
+		umask, err := strconv.ParseUint(string(bytes.TrimSpace(v)), 8, 32)
+		if err != nil {
+			return 0, false
+		}
+		return os.FileMode(umask) & os.ModePerm, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package archives
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProcUmask(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc/self/status is only available on Linux")
+	}
+
+	umask := syscall.Umask(0o027)
+	defer syscall.Umask(umask)
+
+	got, ok := procUmask()
+	assert.Assert(t, ok)
+	assert.Equal(t, got, os.FileMode(0o027))
+}
+
+func TestProcessUmaskChanges(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc/self/status is only available on Linux")
+	}
+
+	umask := syscall.Umask(0o022)
+	defer syscall.Umask(umask)
+	assert.Equal(t, processUmask(), os.FileMode(0o022))
+
+	syscall.Umask(0o077)
+	assert.Equal(t, processUmask(), os.FileMode(0o077))
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Verifier verifies a detached signature over the raw bytes of an
+// archive. When set on [ExtractOptions], the archive stream is written
+// to the Verifier as it is consumed and Verify is called once the
+// stream has been read in full. Nothing is moved into the destination
+// unless Verify returns nil.
+//
+// Verifiers are stateful and must not be reused across archives.
+type Verifier interface {
+	io.Writer
+
+	// Verify returns an error if the data written to the Verifier does
+	// not match the signature it was created with.
+	Verify() error
+}
+
+// decodeSignatureLines returns the non-comment lines of a minisign or
+// signify style key or signature file. If data does not contain any
+// newlines, it is treated as a single base64 line.
+func decodeSignatureLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// decodeBase64Blob decodes a base64 line and ensures that it is of the
+// expected length.
+func decodeBase64Blob(line string, size int) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64: %w", err)
+	}
+
+	if len(b) != size {
+		return nil, fmt.Errorf("unexpected length %d, expected %d", len(b), size)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Contains the algorithm identifiers used by minisign and signify.
+const (
+	// ed25519Legacy signs the message directly. This is the only
+	// algorithm supported by signify.
+	ed25519Legacy = "Ed"
+
+	// ed25519Prehashed signs the BLAKE2b-512 hash of the message. This is
+	// the default for minisign.
+	ed25519Prehashed = "ED"
+)
+
+// Contains the sizes of the decoded minisign/signify blobs.
+const (
+	ed25519KeyIDSize     = 8
+	ed25519PublicKeySize = 2 + ed25519KeyIDSize + ed25519.PublicKeySize
+	ed25519SignatureSize = 2 + ed25519KeyIDSize + ed25519.SignatureSize
+)
+
+// _ ensures that ed25519Verifier implements the [Verifier] interface.
+var _ Verifier = (&ed25519Verifier{})
+
+// ed25519Verifier implements [Verifier] for minisign and signify
+// signatures.
+type ed25519Verifier struct {
+	publicKey ed25519.PublicKey
+	signature []byte
+
+	// trustedComment and globalSignature are only set for minisign
+	// signatures.
+	trustedComment  string
+	globalSignature []byte
+
+	// Only one of hash or buf is set depending on if the signature is
+	// prehashed or not.
+	hash hash.Hash
+	buf  *bytes.Buffer
+}
+
+// NewMinisignVerifier returns a [Verifier] for a minisign signature.
+// publicKey is the contents of a minisign public key file (or the bare
+// base64 key) and signature is the contents of the .minisig file.
+//
+// Both prehashed (the default since minisign 0.10) and legacy
+// signatures are supported. Legacy signatures require the entire
+// archive to be buffered in memory before it can be verified.
+func NewMinisignVerifier(publicKey, signature []byte) (Verifier, error) {
+	v, err := newEd25519Verifier(publicKey, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := decodeSignatureLines(signature)
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "trusted comment: ") {
+		return nil, fmt.Errorf("invalid minisign signature: missing trusted comment")
+	}
+
+	v.trustedComment = strings.TrimPrefix(lines[1], "trusted comment: ")
+	v.globalSignature, err = decodeBase64Blob(lines[2], ed25519.SignatureSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minisign global signature: %w", err)
+	}
+
+	return v, nil
+}
+
+// NewSignifyVerifier returns a [Verifier] for an OpenBSD signify
+// signature. publicKey is the contents of a signify .pub file and
+// signature is the contents of the detached .sig file.
+//
+// signify signs the message directly, so the entire archive is
+// buffered in memory before it can be verified.
+func NewSignifyVerifier(publicKey, signature []byte) (Verifier, error) {
+	v, err := newEd25519Verifier(publicKey, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.hash != nil {
+		return nil, fmt.Errorf("invalid signify signature: unsupported algorithm %q", ed25519Prehashed)
+	}
+
+	return v, nil
+}
+
+// newEd25519Verifier parses the parts of a minisign or signify public
+// key and signature that are shared between the two formats.
+func newEd25519Verifier(publicKey, signature []byte) (*ed25519Verifier, error) {
+	keyLines := decodeSignatureLines(publicKey)
+	if len(keyLines) != 1 {
+		return nil, fmt.Errorf("invalid public key: expected a single key line")
+	}
+
+	pk, err := decodeBase64Blob(keyLines[0], ed25519PublicKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	if string(pk[:2]) != ed25519Legacy {
+		return nil, fmt.Errorf("invalid public key: unsupported algorithm %q", pk[:2])
+	}
+
+	sigLines := decodeSignatureLines(signature)
+	if len(sigLines) == 0 {
+		return nil, fmt.Errorf("invalid signature: missing signature line")
+	}
+
+	sig, err := decodeBase64Blob(sigLines[0], ed25519SignatureSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if !bytes.Equal(pk[2:2+ed25519KeyIDSize], sig[2:2+ed25519KeyIDSize]) {
+		return nil, fmt.Errorf("signature was not created by the provided public key (key ID mismatch)")
+	}
+
+	v := &ed25519Verifier{
+		publicKey: ed25519.PublicKey(pk[2+ed25519KeyIDSize:]),
+		signature: sig[2+ed25519KeyIDSize:],
+	}
+
+	switch string(sig[:2]) {
+	case ed25519Legacy:
+		v.buf = new(bytes.Buffer)
+	case ed25519Prehashed:
+		v.hash, err = blake2b.New512(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create blake2b hash: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid signature: unsupported algorithm %q", sig[:2])
+	}
+
+	return v, nil
+}
+
+// Write implements [io.Writer].
+func (v *ed25519Verifier) Write(p []byte) (int, error) {
+	if v.hash != nil {
+		return v.hash.Write(p)
+	}
+
+	return v.buf.Write(p)
+}
+
+// Verify implements [Verifier].
+func (v *ed25519Verifier) Verify() error {
+	var msg []byte
+	if v.hash != nil {
+		msg = v.hash.Sum(nil)
+	} else {
+		msg = v.buf.Bytes()
+	}
+
+	if !ed25519.Verify(v.publicKey, msg, v.signature) {
+		return errors.New("signature verification failed")
+	}
+
+	// The global signature covers the signature and the trusted
+	// comment, preventing the comment from being tampered with.
+	if v.globalSignature != nil {
+		global := append(append([]byte{}, v.signature...), v.trustedComment...)
+		if !ed25519.Verify(v.publicKey, global, v.globalSignature) {
+			return errors.New("trusted comment verification failed")
+		}
+	}
+
+	return nil
+}
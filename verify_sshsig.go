@@ -0,0 +1,172 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Contains constants from the OpenSSH PROTOCOL.sshsig specification.
+const (
+	sshsigMagic   = "SSHSIG"
+	sshsigVersion = 1
+	sshsigBegin   = "-----BEGIN SSH SIGNATURE-----"
+	sshsigEnd     = "-----END SSH SIGNATURE-----"
+)
+
+// _ ensures that sshsigVerifier implements the [Verifier] interface.
+var _ Verifier = (&sshsigVerifier{})
+
+// sshsigVerifier implements [Verifier] for signatures created by
+// `ssh-keygen -Y sign`.
+type sshsigVerifier struct {
+	publicKey     ssh.PublicKey
+	namespace     string
+	hashAlgorithm string
+	signature     *ssh.Signature
+	hash          hash.Hash
+}
+
+// sshsigBlob is the wire format of an SSH signature.
+type sshsigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshsigSignedData is the data that is signed by an SSH signature.
+type sshsigSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// NewSSHSignatureVerifier returns a [Verifier] for an armored signature
+// created with `ssh-keygen -Y sign`. publicKey is the allowed signer in
+// authorized_keys format and namespace must match the namespace the
+// signature was created with (ssh-keygen uses "file" by convention).
+func NewSSHSignatureVerifier(publicKey, signature []byte, namespace string) (Verifier, error) {
+	allowed, _, _, _, err := ssh.ParseAuthorizedKey(publicKey) //nolint:dogsled // Why: Only the key is needed.
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	raw, err := decodeSSHSignatureArmor(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(raw, []byte(sshsigMagic)) {
+		return nil, fmt.Errorf("invalid ssh signature: missing magic preamble")
+	}
+
+	var blob sshsigBlob
+	if err := ssh.Unmarshal(raw[len(sshsigMagic):], &blob); err != nil {
+		return nil, fmt.Errorf("invalid ssh signature: %w", err)
+	}
+
+	if blob.Version != sshsigVersion {
+		return nil, fmt.Errorf("invalid ssh signature: unsupported version %d", blob.Version)
+	}
+
+	if blob.Namespace != namespace {
+		return nil, fmt.Errorf("ssh signature namespace %q does not match expected namespace %q", blob.Namespace, namespace)
+	}
+
+	signer, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh signature public key: %w", err)
+	}
+
+	if !bytes.Equal(signer.Marshal(), allowed.Marshal()) {
+		return nil, fmt.Errorf("ssh signature was not created by the provided public key")
+	}
+
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(blob.Signature, &sig); err != nil {
+		return nil, fmt.Errorf("invalid ssh signature: %w", err)
+	}
+
+	v := &sshsigVerifier{
+		publicKey:     allowed,
+		namespace:     blob.Namespace,
+		hashAlgorithm: blob.HashAlgorithm,
+		signature:     &sig,
+	}
+
+	switch blob.HashAlgorithm {
+	case "sha256":
+		v.hash = sha256.New()
+	case "sha512":
+		v.hash = sha512.New()
+	default:
+		return nil, fmt.Errorf("invalid ssh signature: unsupported hash algorithm %q", blob.HashAlgorithm)
+	}
+
+	return v, nil
+}
+
+// decodeSSHSignatureArmor decodes the PEM-like armor used by
+// ssh-keygen signatures.
+func decodeSSHSignatureArmor(data []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(s, sshsigBegin) || !strings.HasSuffix(s, sshsigEnd) {
+		return nil, fmt.Errorf("invalid ssh signature: missing armor")
+	}
+
+	s = strings.TrimSuffix(strings.TrimPrefix(s, sshsigBegin), sshsigEnd)
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh signature: failed to decode base64: %w", err)
+	}
+
+	return raw, nil
+}
+
+// Write implements [io.Writer].
+func (v *sshsigVerifier) Write(p []byte) (int, error) {
+	return v.hash.Write(p)
+}
+
+// Verify implements [Verifier].
+func (v *sshsigVerifier) Verify() error {
+	signed := append([]byte(sshsigMagic), ssh.Marshal(sshsigSignedData{
+		Namespace:     v.namespace,
+		HashAlgorithm: v.hashAlgorithm,
+		Hash:          v.hash.Sum(nil),
+	})...)
+
+	if err := v.publicKey.Verify(signed, v.signature); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	return nil
+}
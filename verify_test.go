@@ -0,0 +1,178 @@
+package archives_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+	"gotest.tools/v3/assert"
+)
+
+// minisignSign returns a minisign (or signify, if legacy is true and
+// no trusted comment is provided) public key and signature for msg.
+func minisignSign(t *testing.T, msg []byte, legacy bool, trustedComment string) (pub, sig []byte) {
+	t.Helper()
+
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	keyID := []byte("01234567")
+	pub = []byte("untrusted comment: test key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pk...)) + "\n")
+
+	alg, signed := "ED", msg
+	if legacy {
+		alg = "Ed"
+	} else {
+		h := blake2b.Sum512(msg)
+		signed = h[:]
+	}
+
+	s := ed25519.Sign(sk, signed)
+	sig = []byte("untrusted comment: test signature\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(alg), keyID...), s...)) + "\n")
+	if trustedComment != "" {
+		global := ed25519.Sign(sk, append(append([]byte{}, s...), trustedComment...))
+		sig = append(sig, []byte("trusted comment: "+trustedComment+"\n"+
+			base64.StdEncoding.EncodeToString(global)+"\n")...)
+	}
+
+	return pub, sig
+}
+
+// sshSign returns an authorized_keys line and an armored ssh-keygen
+// style signature for msg.
+func sshSign(t *testing.T, msg []byte, namespace string) (pub, sig []byte) {
+	t.Helper()
+
+	_, sk, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	signer, err := ssh.NewSignerFromKey(sk)
+	assert.NilError(t, err)
+
+	h := sha512.Sum512(msg)
+	signed := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace, Reserved, HashAlgorithm string
+		Hash                               []byte
+	}{namespace, "", "sha512", h[:]})...)
+
+	s, err := signer.Sign(rand.Reader, signed)
+	assert.NilError(t, err)
+
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version                            uint32
+		PublicKey                          []byte
+		Namespace, Reserved, HashAlgorithm string
+		Signature                          []byte
+	}{1, signer.PublicKey().Marshal(), namespace, "", "sha512", ssh.Marshal(s)})...)
+
+	sig = []byte(fmt.Sprintf("-----BEGIN SSH SIGNATURE-----\n%s\n-----END SSH SIGNATURE-----\n",
+		base64.StdEncoding.EncodeToString(blob)))
+	return ssh.MarshalAuthorizedKey(signer.PublicKey()), sig
+}
+
+func TestExtractVerifies(t *testing.T) {
+	tarArchive, err := tartest.Create(tartest.WithContainer(tartest.ContainerGz))
+	assert.NilError(t, err)
+
+	archive, err := io.ReadAll(tarArchive)
+	assert.NilError(t, err)
+
+	tampered := bytes.Clone(archive)
+	tampered[len(tampered)-1] ^= 0xff
+
+	type testCase struct {
+		name     string
+		verifier func(t *testing.T, msg []byte) archives.Verifier
+	}
+
+	testCases := []testCase{
+		{
+			name: "minisign",
+			verifier: func(t *testing.T, msg []byte) archives.Verifier {
+				pub, sig := minisignSign(t, msg, false, "timestamp:0")
+				v, err := archives.NewMinisignVerifier(pub, sig)
+				assert.NilError(t, err)
+				return v
+			},
+		},
+		{
+			name: "minisign legacy",
+			verifier: func(t *testing.T, msg []byte) archives.Verifier {
+				pub, sig := minisignSign(t, msg, true, "timestamp:0")
+				v, err := archives.NewMinisignVerifier(pub, sig)
+				assert.NilError(t, err)
+				return v
+			},
+		},
+		{
+			name: "signify",
+			verifier: func(t *testing.T, msg []byte) archives.Verifier {
+				pub, sig := minisignSign(t, msg, true, "")
+				v, err := archives.NewSignifyVerifier(pub, sig)
+				assert.NilError(t, err)
+				return v
+			},
+		},
+		{
+			name: "ssh",
+			verifier: func(t *testing.T, msg []byte) archives.Verifier {
+				pub, sig := sshSign(t, msg, "file")
+				v, err := archives.NewSSHSignatureVerifier(pub, sig, "file")
+				assert.NilError(t, err)
+				return v
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("should extract when the signature is valid", func(t *testing.T) {
+				dest := filepath.Join(t.TempDir(), "out")
+				assert.NilError(t, archives.Extract(bytes.NewReader(archive), dest, archives.ExtractOptions{
+					Extension: ".tar.gz",
+					Verifier:  tc.verifier(t, archive),
+				}))
+
+				got, err := os.ReadFile(filepath.Join(dest, "file.txt"))
+				assert.NilError(t, err)
+				assert.Equal(t, string(got), "hello world")
+			})
+
+			t.Run("should not extract when the signature is invalid", func(t *testing.T) {
+				dir := t.TempDir()
+				dest := filepath.Join(dir, "out")
+				err := archives.Extract(bytes.NewReader(tampered), dest, archives.ExtractOptions{
+					Extension: ".tar.gz",
+					Verifier:  tc.verifier(t, archive),
+				})
+				assert.ErrorContains(t, err, "failed to verify archive")
+
+				_, err = os.Stat(dest)
+				assert.Assert(t, os.IsNotExist(err), "expected destination to not exist")
+
+				// Staging directory should be cleaned up.
+				entries, err := os.ReadDir(dir)
+				assert.NilError(t, err)
+				assert.Equal(t, len(entries), 0)
+			})
+		})
+	}
+}
+
+func TestNewSSHSignatureVerifierRejectsWrongNamespace(t *testing.T) {
+	pub, sig := sshSign(t, []byte("hello"), "file")
+	_, err := archives.NewSSHSignatureVerifier(pub, sig, "git")
+	assert.ErrorContains(t, err, "does not match expected namespace")
+}
@@ -0,0 +1,263 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Volume is a single volume of an archive split into multiple parts,
+// such as data.zip.001 or data.tar.gz.aa.
+type Volume struct {
+	io.ReaderAt
+
+	// Size is the size of the volume in bytes.
+	Size int64
+}
+
+// OpenVolumes opens an archive split into the provided volumes, which
+// must be in order. The volumes are read as a single archive, as if
+// they were concatenated.
+//
+// Both archives split into parts after being created (e.g., using
+// split(1)) and spanned zip archives (e.g., created using zip -s),
+// whose volumes each start their own offsets, are supported. Single
+// volumes can also be opened, in which case this is the same as
+// [OpenReaderAt].
+func OpenVolumes(vols []Volume, opts OpenOptions) (Archive, error) {
+	if len(vols) == 0 {
+		return nil, fmt.Errorf("at least one volume must be provided")
+	} else if opts.Extension == "" {
+		return nil, fmt.Errorf("extension must be provided (set opts.Extension)")
+	}
+
+	v := newVolumeReader(vols)
+	r, size := io.ReaderAt(v), v.size
+	if strings.TrimPrefix(opts.Extension, ".") == "zip" && len(vols) > 1 {
+		var err error
+		if r, size, err = newZipSpannedReader(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return OpenReaderAt(r, size, opts)
+}
+
+// OpenVolumeFiles opens an archive split into the files matching the
+// provided pattern (see [filepath.Glob]), for example "data.zip.*",
+// "data.tar.gz.*" or "data.z*" for a spanned zip archive made up of
+// data.z01, data.z02, … and data.zip. The files are ordered by name,
+// comparing numbers by their value so that data.z100 is after data.z99.
+//
+// If opts.Extension is empty, it is determined from the name of the
+// first file, ignoring volume suffixes (e.g., .001, .aa or .z01). The
+// files are closed when the returned archive is closed.
+func OpenVolumeFiles(pattern string, opts OpenOptions) (Archive, error) {
+	names, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find volumes: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no volumes matching %q found", pattern)
+	}
+	sort.Slice(names, func(i, j int) bool { return compareVolumeNames(names[i], names[j]) < 0 })
+
+	if opts.Extension == "" {
+		opts.Extension = volumeExt(names[0])
+	}
+
+	a := &volumeFilesArchive{}
+	vols := make([]Volume, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			a.closeFiles() //nolint:errcheck // Why: Best effort.
+			return nil, fmt.Errorf("failed to open volume: %w", err)
+		}
+		a.files = append(a.files, f)
+
+		info, err := f.Stat()
+		if err != nil {
+			a.closeFiles() //nolint:errcheck // Why: Best effort.
+			return nil, fmt.Errorf("failed to stat volume: %w", err)
+		}
+		vols = append(vols, Volume{ReaderAt: f, Size: info.Size()})
+	}
+
+	a.Archive, err = OpenVolumes(vols, opts)
+	if err != nil {
+		a.closeFiles() //nolint:errcheck // Why: Best effort.
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// volumeFilesArchive is an [Archive] opened by [OpenVolumeFiles], which
+// closes the volume files once closed.
+type volumeFilesArchive struct {
+	Archive
+
+	files []*os.File
+}
+
+// Close closes the archive and the volume files.
+func (a *volumeFilesArchive) Close() error {
+	return errors.Join(a.Archive.Close(), a.closeFiles())
+}
+
+// closeFiles closes the volume files.
+func (a *volumeFilesArchive) closeFiles() error {
+	var errs []error
+	for _, f := range a.files {
+		errs = append(errs, f.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// volumeReader reads multiple volumes as if they were concatenated.
+type volumeReader struct {
+	vols []Volume
+
+	// offsets contains the offset of the start of each volume.
+	offsets []int64
+	size    int64
+}
+
+// _ ensures that volumeReader implements [io.ReaderAt].
+var _ io.ReaderAt = (&volumeReader{})
+
+// newVolumeReader returns a new [volumeReader] of vols.
+func newVolumeReader(vols []Volume) *volumeReader {
+	v := &volumeReader{vols: vols, offsets: make([]int64, len(vols))}
+	for i := range vols {
+		v.offsets[i] = v.size
+		v.size += vols[i].Size
+	}
+
+	return v
+}
+
+// ReadAt implements [io.ReaderAt].
+func (v *volumeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	// Find the last volume starting at or before off.
+	i := sort.Search(len(v.offsets), func(i int) bool { return v.offsets[i] > off }) - 1
+
+	var n int
+	for ; len(p) > 0 && i >= 0 && i < len(v.vols); i++ {
+		vol := v.vols[i]
+		rel := off - v.offsets[i]
+		if rel >= vol.Size {
+			continue
+		}
+
+		want := min(int64(len(p)), vol.Size-rel)
+		m, err := vol.ReadAt(p[:want], rel)
+		n += m
+		off += int64(m)
+		p = p[m:]
+
+		// A volume may return io.EOF along with the final bytes.
+		if err != nil && (err != io.EOF || int64(m) != want) {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, fmt.Errorf("failed to read volume %d: %w", i+1, err)
+		}
+	}
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// compareVolumeNames compares the names of two volumes, comparing runs
+// of digits by their numerical value.
+func compareVolumeNames(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da == "" || db == "" {
+			if a[0] != b[0] {
+				return int(a[0]) - int(b[0])
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		// Compare the numbers by value, ignoring leading zeros.
+		na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+		if len(na) != len(nb) {
+			return len(na) - len(nb)
+		}
+		if c := strings.Compare(na, nb); c != 0 {
+			return c
+		}
+		a, b = a[len(da):], b[len(db):]
+	}
+
+	return len(a) - len(b)
+}
+
+// leadingDigits returns the digits at the start of s.
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	return s[:i]
+}
+
+// volumeExt returns the extension of the archive that name is a volume
+// of. Volume suffixes (e.g., .001, .aa or .z01) are ignored, unless
+// they are a supported extension themselves.
+func volumeExt(name string) string {
+	ext := Ext(name)
+	if Supported(ext) {
+		return ext
+	}
+
+	suffix := strings.TrimPrefix(filepath.Ext(name), ".")
+	switch {
+	case suffix != "" && leadingDigits(suffix) == suffix:
+		// data.zip.001
+		return Ext(strings.TrimSuffix(name, "."+suffix))
+	case len(suffix) > 1 && strings.EqualFold(suffix[:1], "z") &&
+		leadingDigits(suffix[1:]) == suffix[1:]:
+		// data.z01, the first volume of a spanned zip archive.
+		return ".zip"
+	case len(suffix) == 2 && strings.Trim(suffix, "abcdefghijklmnopqrstuvwxyz") == "":
+		// data.tar.gz.aa, as created by split(1).
+		return Ext(strings.TrimSuffix(name, "."+suffix))
+	}
+
+	return ext
+}
@@ -0,0 +1,206 @@
+package archives_test
+
+import (
+	stdzip "archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/tartest"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"gotest.tools/v3/assert"
+)
+
+// volumeFiles returns the files of a zip archive used to test split
+// archives, large enough to span multiple volumes.
+func volumeFiles() []ziptest.File {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec // Why: Deterministic test data.
+	big := make([]byte, 40000)
+	r.Read(big)
+
+	return []ziptest.File{
+		{Name: "big.bin", Contents: big},
+		{Name: "dir/small.txt", Contents: []byte("hello world"), Method: stdzip.Deflate},
+		{Name: "dir/other.bin", Contents: big[:10000]},
+	}
+}
+
+// splitBytes splits b into parts of the provided size.
+func splitBytes(b []byte, size int) [][]byte {
+	var parts [][]byte
+	for len(b) > size {
+		parts = append(parts, b[:size])
+		b = b[size:]
+	}
+	return append(parts, b)
+}
+
+// toVolumes returns parts as volumes.
+func toVolumes(parts [][]byte) []archives.Volume {
+	vols := make([]archives.Volume, 0, len(parts))
+	for _, p := range parts {
+		vols = append(vols, archives.Volume{ReaderAt: bytes.NewReader(p), Size: int64(len(p))})
+	}
+	return vols
+}
+
+// readAll returns the contents of every file in a, keyed by name.
+func readAll(t *testing.T, a archives.Archive) map[string][]byte {
+	t.Helper()
+
+	files := make(map[string][]byte)
+	for {
+		h, err := a.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+
+		b, err := io.ReadAll(a)
+		assert.NilError(t, err)
+		files[h.Name] = b
+	}
+	return files
+}
+
+// assertVolumeFiles asserts that a contains [volumeFiles].
+func assertVolumeFiles(t *testing.T, a archives.Archive) {
+	t.Helper()
+
+	got := readAll(t, a)
+	want := volumeFiles()
+	assert.Equal(t, len(got), len(want))
+	for _, f := range want {
+		assert.Assert(t, bytes.Equal(got[f.Name], f.Contents), "contents of %s mismatch", f.Name)
+	}
+}
+
+func TestOpenVolumesZipSpanned(t *testing.T) {
+	b, err := ziptest.Create(volumeFiles())
+	assert.NilError(t, err)
+
+	parts, err := ziptest.Split(b, 4096)
+	assert.NilError(t, err)
+	assert.Assert(t, len(parts) > 10)
+
+	a, err := archives.OpenVolumes(toVolumes(parts), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	assertVolumeFiles(t, a)
+}
+
+func TestOpenVolumesZipSpannedMissingVolume(t *testing.T) {
+	b, err := ziptest.Create(volumeFiles())
+	assert.NilError(t, err)
+
+	parts, err := ziptest.Split(b, 4096)
+	assert.NilError(t, err)
+
+	parts = append(parts[:3], parts[4:]...)
+	_, err = archives.OpenVolumes(toVolumes(parts), archives.OpenOptions{Extension: ".zip"})
+	assert.ErrorContains(t, err, fmt.Sprintf("spanned zip archive has %d volumes, got %d", len(parts)+1, len(parts)))
+}
+
+func TestOpenVolumesZipSplit(t *testing.T) {
+	b, err := ziptest.Create(volumeFiles())
+	assert.NilError(t, err)
+
+	a, err := archives.OpenVolumes(toVolumes(splitBytes(b, 10000)), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	assertVolumeFiles(t, a)
+}
+
+func TestOpenVolumesTarSplit(t *testing.T) {
+	r, err := tartest.Create(tartest.WithContainer(tartest.ContainerGz))
+	assert.NilError(t, err)
+	b, err := io.ReadAll(r)
+	assert.NilError(t, err)
+
+	a, err := archives.OpenVolumes(toVolumes(splitBytes(b, 7)), archives.OpenOptions{Extension: ".tar.gz"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	assert.DeepEqual(t, readAll(t, a), map[string][]byte{"file.txt": []byte("hello world")})
+}
+
+func TestOpenVolumeFiles(t *testing.T) {
+	b, err := ziptest.Create(volumeFiles())
+	assert.NilError(t, err)
+	spanned, err := ziptest.Split(b, 4096)
+	assert.NilError(t, err)
+
+	r, err := tartest.Create(tartest.WithContainer(tartest.ContainerGz))
+	assert.NilError(t, err)
+	tgz, err := io.ReadAll(r)
+	assert.NilError(t, err)
+
+	write := func(t *testing.T, dir string, names []string, parts [][]byte) {
+		t.Helper()
+		assert.Equal(t, len(names), len(parts))
+		for i, name := range names {
+			assert.NilError(t, os.WriteFile(filepath.Join(dir, name), parts[i], 0o600))
+		}
+	}
+
+	t.Run("zip-spanned", func(t *testing.T) {
+		// data.z01, …, data.z10, data.z11, …, data.zip, ensuring the
+		// volumes are ordered numerically.
+		names := make([]string, 0, len(spanned))
+		for i := 1; i < len(spanned); i++ {
+			names = append(names, fmt.Sprintf("data.z%02d", i))
+		}
+		names = append(names, "data.zip")
+
+		dir := t.TempDir()
+		write(t, dir, names, spanned)
+
+		a, err := archives.OpenVolumeFiles(filepath.Join(dir, "data.z*"), archives.OpenOptions{})
+		assert.NilError(t, err)
+		defer a.Close()
+
+		assertVolumeFiles(t, a)
+	})
+
+	t.Run("zip-numbered", func(t *testing.T) {
+		parts := splitBytes(b, 4096)
+		names := make([]string, 0, len(parts))
+		for i := range parts {
+			names = append(names, fmt.Sprintf("data.zip.%d", i+1))
+		}
+
+		dir := t.TempDir()
+		write(t, dir, names, parts)
+
+		a, err := archives.OpenVolumeFiles(filepath.Join(dir, "data.zip.*"), archives.OpenOptions{})
+		assert.NilError(t, err)
+		defer a.Close()
+
+		assertVolumeFiles(t, a)
+	})
+
+	t.Run("tar-split", func(t *testing.T) {
+		parts := splitBytes(tgz, len(tgz)/2+1)
+
+		dir := t.TempDir()
+		write(t, dir, []string{"data.tar.gz.aa", "data.tar.gz.ab"}, parts)
+
+		a, err := archives.OpenVolumeFiles(filepath.Join(dir, "data.tar.gz.*"), archives.OpenOptions{})
+		assert.NilError(t, err)
+		defer a.Close()
+
+		assert.DeepEqual(t, readAll(t, a), map[string][]byte{"file.txt": []byte("hello world")})
+	})
+
+	t.Run("no-match", func(t *testing.T) {
+		_, err := archives.OpenVolumeFiles(filepath.Join(t.TempDir(), "data.zip.*"), archives.OpenOptions{})
+		assert.ErrorContains(t, err, "no volumes matching")
+	})
+}
@@ -21,14 +21,37 @@ package archives
 import (
 	stdzip "archive/zip"
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Contains constants of the zip name encodings.
+const (
+	// zipFlagUTF8 is the general purpose flag set for entries whose name
+	// is encoded using UTF-8.
+	zipFlagUTF8 = 0x800
+
+	// zipExtraUnicodePath is the ID of the Info-ZIP Unicode Path extra
+	// field, storing the UTF-8 name of entries whose name is stored using
+	// a legacy encoding.
+	zipExtraUnicodePath = 0x7075
 )
 
 // _ ensures that tar implements the [Archiver] interface.
 var _ Archiver = (&zip{})
 
+// _ ensures that zip implements the [ReaderAtArchiver] interface.
+var _ ReaderAtArchiver = (&zip{})
+
+// _ ensures that zip implements the [OptionsArchiver] interface.
+var _ OptionsArchiver = (&zip{})
+
 // zip implements the [Archiver] interface for zip archives.
 type zip struct{}
 
@@ -40,17 +63,35 @@ func (z *zip) Extensions() []string {
 // Open creates a new [Archive] from the provided reader using the zip
 // format. Due to the nature of zip archives, the entire archive is read
 // into memory.
-func (z *zip) Open(r io.Reader, _ string) (Archive, error) {
+func (z *zip) Open(r io.Reader, ext string) (Archive, error) {
+	return z.OpenWithOptions(r, ext, &OpenOptions{})
+}
+
+// OpenWithOptions implements [OptionsArchiver].
+func (z *zip) OpenWithOptions(r io.Reader, ext string, opts *OpenOptions) (Archive, error) {
 	b, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read archive: %w", err)
 	}
 
-	zr, err := stdzip.NewReader(bytes.NewReader(b), int64(len(b)))
+	return z.OpenReaderAt(bytes.NewReader(b), int64(len(b)), ext, opts)
+}
+
+// OpenReaderAt implements [ReaderAtArchiver].
+func (z *zip) OpenReaderAt(r io.ReaderAt, size int64, _ string, opts *OpenOptions) (Archive, error) {
+	zr, err := stdzip.NewReader(r, size)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create zip reader: %w", err)
 	}
-	return &zipArchive{zr: zr}, nil
+
+	for method, dcomp := range zipDecompressors {
+		// Store and deflate are built into [stdzip.Reader].
+		if method != stdzip.Store && method != stdzip.Deflate {
+			zr.RegisterDecompressor(method, dcomp)
+		}
+	}
+
+	return &zipArchive{zr: zr, opts: opts}, nil
 }
 
 // zipArchive is an implementation of the Archive interface for zip
@@ -58,9 +99,10 @@ func (z *zip) Open(r io.Reader, _ string) (Archive, error) {
 type zipArchive struct {
 	io.ReadCloser
 
-	mu  sync.Mutex
-	pos int
-	zr  *stdzip.Reader
+	mu   sync.Mutex
+	pos  int
+	zr   *stdzip.Reader
+	opts *OpenOptions
 }
 
 // Close closes the zipArchive, rendering it unusable for I/O.
@@ -75,6 +117,12 @@ func (z *zipArchive) Close() error {
 // Next returns the next file in the archive and updates the
 // zipArchive's ReadCloser to point to the file's contents.
 func (z *zipArchive) Next() (*Header, error) {
+	h, err := z.next()
+	return h, classifyError(err)
+}
+
+// next returns the header of the next entry.
+func (z *zipArchive) next() (*Header, error) {
 	z.mu.Lock()
 	defer z.mu.Unlock()
 
@@ -85,22 +133,152 @@ func (z *zipArchive) Next() (*Header, error) {
 	f := z.zr.File[z.pos]
 	z.pos++
 
-	var err error
-	z.ReadCloser, err = f.Open()
+	fType := HeaderFile
+	switch {
+	case f.FileInfo().IsDir():
+		fType = HeaderDir
+	case f.Mode()&specialModes != 0:
+		fType = HeaderSpecial
+	}
+
+	name, err := z.decodeName(f)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
 
-	fType := HeaderFile
-	if f.FileInfo().IsDir() {
-		fType = HeaderDir
+	h := &Header{
+		Name:      name,
+		RawName:   []byte(f.Name),
+		Type:      fType,
+		Size:      int64(f.UncompressedSize64), // #nosec // Why: Not an overflow.
+		Mode:      f.Mode(),
+		ModTime:   f.Modified,
+		Encrypted: f.Flags&zipFlagEncrypted != 0,
+	}
+
+	z.ReadCloser = &zipFile{open: func() (io.ReadCloser, error) {
+		if h.Encrypted {
+			return openZipEncrypted(f, h, z.opts)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		return rc, nil
+	}}
+
+	return h, nil
+}
+
+// Comment returns the comment of the archive.
+func (z *zipArchive) Comment() string {
+	return z.zr.Comment
+}
+
+// zipFile is the contents of a zip entry. The entry is only opened once
+// it is read, so that skipping entries doesn't decompress them, and
+// callers can skip encrypted entries without providing a password.
+type zipFile struct {
+	open func() (io.ReadCloser, error)
+
+	rc  io.ReadCloser
+	err error
+}
+
+// Read implements [io.Reader].
+func (f *zipFile) Read(p []byte) (int, error) {
+	if f.rc == nil && f.err == nil {
+		f.rc, f.err = f.open()
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	n, err := f.rc.Read(p)
+	return n, classifyError(err)
+}
+
+// Close implements [io.Closer].
+func (f *zipFile) Close() error {
+	if f.rc != nil {
+		return f.rc.Close()
+	}
+
+	return nil
+}
+
+// decodeName returns the name of f, decoded into UTF-8. Names are
+// decoded unless the entry is marked as UTF-8, preferring the Info-ZIP
+// Unicode Path extra field if present.
+func (z *zipArchive) decodeName(f *stdzip.File) (string, error) {
+	if f.Flags&zipFlagUTF8 != 0 {
+		return f.Name, nil
+	}
+	if name, ok := parseZipUnicodePathExtra(f.Extra, f.Name); ok {
+		return name, nil
+	}
+
+	// Names using only ASCII are the same in every supported encoding.
+	// Without a caller-supplied decoder, names that are valid UTF-8 are
+	// kept as is, as many zip tools write UTF-8 without setting the flag.
+	dec := z.opts.NameDecoder
+	if !zipNeedsDecoding(f.Name, dec != nil) {
+		return f.Name, nil
+	}
+	if dec == nil {
+		dec = charmap.CodePage437.NewDecoder()
+	}
+
+	name, err := dec.String(f.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode name %q: %w", f.Name, err)
+	}
+
+	return name, nil
+}
+
+// zipNeedsDecoding returns true if name, which isn't marked as UTF-8,
+// needs to be decoded. If decodeUTF8 is false, names that are valid
+// UTF-8 are not decoded.
+func zipNeedsDecoding(name string, decodeUTF8 bool) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] >= utf8.RuneSelf {
+			return decodeUTF8 || !utf8.ValidString(name)
+		}
+	}
+
+	return false
+}
+
+// parseZipUnicodePathExtra returns the name stored in the Info-ZIP
+// Unicode Path extra field, if present and still matching the name
+// stored in the header.
+func parseZipUnicodePathExtra(extra []byte, name string) (string, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			break
+		}
+
+		data := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if id != zipExtraUnicodePath || size < 5 || data[0] != 1 {
+			continue
+		}
+
+		// The field is only valid if the header name wasn't changed by a
+		// tool unaware of it since.
+		if binary.LittleEndian.Uint32(data[1:]) != crc32.ChecksumIEEE([]byte(name)) {
+			return "", false
+		}
+		if !utf8.Valid(data[5:]) {
+			return "", false
+		}
+
+		return string(data[5:]), true
 	}
 
-	return &Header{
-		Name:    f.Name,
-		Type:    fType,
-		Size:    int64(f.UncompressedSize64), // #nosec // Why: Not an overflow.
-		Mode:    f.Mode(),
-		ModTime: f.Modified,
-	}, nil
+	return "", false
 }
@@ -0,0 +1,331 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdzip "archive/zip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // Why: Required by the WinZip AES format.
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Contains constants of the zip encryption formats.
+const (
+	// zipFlagEncrypted is the general purpose flag set for encrypted
+	// entries.
+	zipFlagEncrypted = 0x1
+
+	// zipFlagDataDescriptor is the general purpose flag set for entries
+	// whose sizes and checksum are stored after their contents.
+	zipFlagDataDescriptor = 0x8
+
+	// zipMethodAES is the compression method of entries encrypted using
+	// WinZip AES, the actual compression method is stored in the AES
+	// extra field.
+	zipMethodAES = 99
+
+	// zipExtraAES is the ID of the WinZip AES extra field.
+	zipExtraAES = 0x9901
+
+	// zipCryptoHeaderSize is the size of the encryption header of
+	// traditional PKWARE encryption.
+	zipCryptoHeaderSize = 12
+
+	// zipAESIterations is the number of PBKDF2 iterations used to derive
+	// WinZip AES keys, zipAESAuthCodeSize the size of the authentication
+	// code stored after the encrypted contents.
+	zipAESIterations   = 1000
+	zipAESAuthCodeSize = 10
+)
+
+// openZipEncrypted returns a reader of the decrypted and decompressed
+// contents of the encrypted entry f, described by h.
+func openZipEncrypted(f *stdzip.File, h *Header, opts *OpenOptions) (io.ReadCloser, error) {
+	password, err := opts.password(h)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	method := f.Method
+	checksum := true
+
+	var r io.Reader
+	if method == zipMethodAES {
+		ae, err := parseZipAESExtra(f.Extra)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err = newZipAESReader(raw, int64(f.CompressedSize64), password, ae.strength) //nolint:gosec // Why: Not an overflow.
+		if err != nil {
+			return nil, err
+		}
+
+		// AE-2 doesn't store the checksum, the contents are authenticated
+		// instead.
+		method = ae.method
+		checksum = ae.version == 1
+	} else {
+		check := byte(f.CRC32 >> 24)
+		if f.Flags&zipFlagDataDescriptor != 0 {
+			check = byte(f.ModifiedTime >> 8) //nolint:staticcheck // Why: The raw MS-DOS time is the password check.
+		}
+
+		r, err = newZipCryptoReader(raw, password, check)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dcomp, ok := zipDecompressors[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: zip compression method %d", ErrUnsupportedFormat, method)
+	}
+
+	rc := dcomp(r)
+	if !checksum {
+		return rc, nil
+	}
+
+	return &zipChecksumReader{rc: rc, hash: crc32.NewIEEE(), want: f.CRC32}, nil
+}
+
+// zipChecksumReader verifies the CRC-32 of the contents of a decrypted
+// entry.
+type zipChecksumReader struct {
+	rc   io.ReadCloser
+	hash hash.Hash32
+	want uint32
+}
+
+// Read implements [io.Reader].
+func (c *zipChecksumReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.hash.Write(p[:n])
+	if err == io.EOF && c.hash.Sum32() != c.want {
+		return n, stdzip.ErrChecksum
+	}
+
+	return n, err
+}
+
+// Close implements [io.Closer].
+func (c *zipChecksumReader) Close() error {
+	return c.rc.Close()
+}
+
+// zipCryptoReader decrypts traditional PKWARE encryption, also known as
+// ZipCrypto.
+type zipCryptoReader struct {
+	r    io.Reader
+	keys [3]uint32
+}
+
+// newZipCryptoReader returns a reader decrypting r using password. check
+// is the expected last byte of the decrypted encryption header, used to
+// verify the password.
+func newZipCryptoReader(r io.Reader, password string, check byte) (io.Reader, error) {
+	z := &zipCryptoReader{r: r, keys: [3]uint32{0x12345678, 0x23456789, 0x34567890}}
+	for i := 0; i < len(password); i++ {
+		z.update(password[i])
+	}
+
+	var hdr [zipCryptoHeaderSize]byte
+	if _, err := io.ReadFull(z, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+	if hdr[zipCryptoHeaderSize-1] != check {
+		return nil, ErrIncorrectPassword
+	}
+
+	return z, nil
+}
+
+// update updates the keys with the plaintext byte b.
+func (z *zipCryptoReader) update(b byte) {
+	z.keys[0] = crc32.IEEETable[byte(z.keys[0])^b] ^ (z.keys[0] >> 8)
+	z.keys[1] = (z.keys[1]+(z.keys[0]&0xff))*134775813 + 1
+	z.keys[2] = crc32.IEEETable[byte(z.keys[2])^byte(z.keys[1]>>24)] ^ (z.keys[2] >> 8)
+}
+
+// Read implements [io.Reader].
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := range p[:n] {
+		t := z.keys[2] | 2
+		p[i] ^= byte((t * (t ^ 1)) >> 8)
+		z.update(p[i])
+	}
+
+	return n, err
+}
+
+// zipAESExtra is the WinZip AES extra field of an entry.
+type zipAESExtra struct {
+	// version is the AE-x version of the format.
+	version uint16
+
+	// strength is the key size, 1 for AES-128, 2 for AES-192 and 3 for
+	// AES-256.
+	strength byte
+
+	// method is the actual compression method of the entry.
+	method uint16
+}
+
+// parseZipAESExtra finds and parses the WinZip AES extra field.
+func parseZipAESExtra(extra []byte) (zipAESExtra, error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			break
+		}
+
+		data := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if id != zipExtraAES {
+			continue
+		}
+
+		if size < 7 || string(data[2:4]) != "AE" {
+			return zipAESExtra{}, fmt.Errorf("%w: invalid AES extra field", ErrCorrupt)
+		}
+
+		return zipAESExtra{
+			version:  binary.LittleEndian.Uint16(data),
+			strength: data[4],
+			method:   binary.LittleEndian.Uint16(data[5:]),
+		}, nil
+	}
+
+	return zipAESExtra{}, fmt.Errorf("%w: AES encrypted entry is missing the AES extra field", ErrCorrupt)
+}
+
+// zipAESReader decrypts and authenticates WinZip AES encrypted
+// contents.
+type zipAESReader struct {
+	r   io.Reader
+	raw io.Reader
+	mac hash.Hash
+
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	pos     int
+
+	// verified is true once the authentication code has been checked.
+	verified bool
+}
+
+// newZipAESReader returns a reader decrypting the size bytes of r using
+// password. strength is the key size from the AES extra field.
+func newZipAESReader(r io.Reader, size int64, password string, strength byte) (io.Reader, error) {
+	var keyLen int
+	switch strength {
+	case 1:
+		keyLen = 16
+	case 2:
+		keyLen = 24
+	case 3:
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("%w: AES strength %d", ErrUnsupportedFormat, strength)
+	}
+
+	saltLen := keyLen / 2
+	if size < int64(saltLen+2+zipAESAuthCodeSize) {
+		return nil, fmt.Errorf("%w: encrypted entry is too short", ErrTruncated)
+	}
+
+	hdr := make([]byte, saltLen+2)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+
+	key := pbkdf2.Key([]byte(password), hdr[:saltLen], zipAESIterations, 2*keyLen+2, sha1.New)
+	if subtle.ConstantTimeCompare(key[2*keyLen:], hdr[saltLen:]) != 1 {
+		return nil, ErrIncorrectPassword
+	}
+
+	block, err := aes.NewCipher(key[:keyLen])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &zipAESReader{
+		r:     io.LimitReader(r, size-int64(saltLen+2+zipAESAuthCodeSize)),
+		raw:   r,
+		mac:   hmac.New(sha1.New, key[keyLen:2*keyLen]),
+		block: block,
+		pos:   aes.BlockSize,
+	}, nil
+}
+
+// Read implements [io.Reader].
+func (z *zipAESReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.mac.Write(p[:n])
+
+	// The contents are encrypted using AES in counter mode, with a
+	// little endian counter starting at one.
+	for i := range p[:n] {
+		if z.pos == aes.BlockSize {
+			for j := range z.counter {
+				z.counter[j]++
+				if z.counter[j] != 0 {
+					break
+				}
+			}
+			z.block.Encrypt(z.stream[:], z.counter[:])
+			z.pos = 0
+		}
+
+		p[i] ^= z.stream[z.pos]
+		z.pos++
+	}
+
+	if err == io.EOF && !z.verified {
+		z.verified = true
+
+		var code [zipAESAuthCodeSize]byte
+		if _, err := io.ReadFull(z.raw, code[:]); err != nil {
+			return n, fmt.Errorf("failed to read authentication code: %w", err)
+		}
+		if !hmac.Equal(z.mac.Sum(nil)[:zipAESAuthCodeSize], code[:]) {
+			return n, fmt.Errorf("%w: authentication code mismatch", ErrCorrupt)
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdzip "archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ulikunitz/xz/lzma"
+	"go.rgst.io/jaredallard/archives/v2/internal/deflate64"
+)
+
+// Contains the compression methods of zip archives supported in
+// addition to store and deflate.
+const (
+	zipMethodDeflate64 = 9
+	zipMethodBzip2     = 12
+	zipMethodLZMA      = 14
+	zipMethodZstd      = 93
+	zipMethodXZ        = 95
+)
+
+// zipDecompressors contains the decompressors of the compression
+// methods supported in zip archives.
+var zipDecompressors = map[uint16]stdzip.Decompressor{
+	stdzip.Store:       io.NopCloser,
+	stdzip.Deflate:     flate.NewReader,
+	zipMethodDeflate64: deflate64.NewReader,
+	zipMethodBzip2:     newBzip2Reader,
+	zipMethodLZMA:      zipDecompressor(newZipLZMAReader),
+	zipMethodZstd:      zipDecompressor(newZstdReader),
+	zipMethodXZ:        zipDecompressor(newXZReader),
+}
+
+// zipDecompressor adapts a constructor that may fail into a
+// [stdzip.Decompressor]. Errors are returned when reading.
+func zipDecompressor(fn func(io.Reader) (io.ReadCloser, error)) stdzip.Decompressor {
+	return func(r io.Reader) io.ReadCloser {
+		rc, err := fn(r)
+		if err != nil {
+			return io.NopCloser(&errReader{err: err})
+		}
+		return rc
+	}
+}
+
+// errReader is an [io.Reader] that always returns err.
+type errReader struct {
+	err error
+}
+
+// Read implements [io.Reader].
+func (e *errReader) Read(_ []byte) (int, error) {
+	return 0, e.err
+}
+
+// newZipLZMAReader creates a new reader for LZMA compressed zip entries.
+// Unlike the .lzma format, the properties are preceded by a version and
+// their size, and the uncompressed size is not stored.
+func newZipLZMAReader(r io.Reader) (io.ReadCloser, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("failed to read lzma header: %w", err)
+	}
+	if size := binary.LittleEndian.Uint16(hdr[2:]); size != 5 {
+		return nil, fmt.Errorf("%w: unsupported lzma properties size %d", ErrCorrupt, size)
+	}
+
+	// Construct a classic .lzma header with an unknown uncompressed size
+	// from the properties.
+	classic := make([]byte, lzma.HeaderLen)
+	if _, err := io.ReadFull(r, classic[:5]); err != nil {
+		return nil, fmt.Errorf("failed to read lzma properties: %w", err)
+	}
+	for i := 5; i < len(classic); i++ {
+		classic[i] = 0xff
+	}
+
+	lr, err := lzma.NewReader(bufio.NewReader(io.MultiReader(bytes.NewReader(classic), r)))
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&zipLZMAReader{r: lr}), nil
+}
+
+// zipLZMAReader reads LZMA compressed zip entries, which may not have an
+// end of stream marker. As the uncompressed size is unknown to the LZMA
+// reader, it reports the end of such entries as an unexpected EOF once
+// the compressed data is exhausted. The size and checksum of the entry
+// are still verified by the zip reader.
+type zipLZMAReader struct {
+	r io.Reader
+
+	// exhausted is true once the compressed data is exhausted.
+	exhausted bool
+}
+
+// Read implements [io.Reader].
+func (z *zipLZMAReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) && !z.exhausted {
+		z.exhausted = true
+		err = nil
+	}
+	return n, err
+}
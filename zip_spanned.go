@@ -0,0 +1,347 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Contains constants of the zip records used to read spanned archives.
+const (
+	// zipSigDirectoryHeader, zipSigDirectoryEnd, zipSigDirectory64End and
+	// zipSigDirectory64Locator are the signatures of the central
+	// directory records.
+	zipSigDirectoryHeader    = 0x02014b50
+	zipSigDirectoryEnd       = 0x06054b50
+	zipSigDirectory64End     = 0x06064b50
+	zipSigDirectory64Locator = 0x07064b50
+
+	// zipDirectoryHeaderLen, zipDirectoryEndLen, zipDirectory64EndLen and
+	// zipDirectory64LocatorLen are the sizes of the fixed parts of the
+	// central directory records.
+	zipDirectoryHeaderLen    = 46
+	zipDirectoryEndLen       = 22
+	zipDirectory64EndLen     = 56
+	zipDirectory64LocatorLen = 20
+
+	// zipExtraZip64 is the ID of the zip64 extended information extra
+	// field.
+	zipExtraZip64 = 0x0001
+
+	// zipMaxComment is the maximum size of the archive comment, which
+	// bounds how far from the end the end of central directory record is.
+	zipMaxComment = 0xffff
+)
+
+// zipDirectoryEnd contains the fields of the end of central directory
+// record used to locate the central directory.
+type zipDirectoryEnd struct {
+	// disk is the number of the disk containing the record, which is the
+	// last disk of the archive.
+	disk uint32
+
+	// dirDisk is the disk the central directory starts on, dirOffset the
+	// offset of the central directory relative to the start of that disk.
+	dirDisk   uint32
+	dirOffset uint64
+	dirSize   uint64
+	records   uint64
+
+	comment []byte
+}
+
+// newZipSpannedReader returns a reader of the zip archive spanning the
+// volumes of v, and its size. Offsets in spanned archives are relative
+// to the start of the volume (disk) they point into, which
+// [stdzip.Reader] doesn't support. The central directory is rewritten
+// to use offsets relative to the start of the first volume and appended
+// to the volumes.
+//
+// Archives whose end of central directory record doesn't reference
+// other disks (e.g., split using split(1)) are returned as is.
+func newZipSpannedReader(v *volumeReader) (io.ReaderAt, int64, error) {
+	end, err := readZipDirectoryEnd(v, v.size)
+	if err != nil {
+		return nil, 0, err
+	}
+	if end.disk == 0 {
+		return v, v.size, nil
+	}
+	if int(end.disk)+1 != len(v.vols) {
+		return nil, 0, fmt.Errorf("%w: spanned zip archive has %d volumes, got %d", ErrTruncated, end.disk+1, len(v.vols))
+	}
+
+	diskOffset := func(disk uint32, offset uint64) (int64, error) {
+		if int(disk) >= len(v.offsets) {
+			return 0, fmt.Errorf("%w: invalid disk number %d", ErrCorrupt, disk)
+		}
+		return v.offsets[disk] + int64(offset), nil //nolint:gosec // Why: Validated by the reads.
+	}
+
+	dirStart, err := diskOffset(end.dirDisk, end.dirOffset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if end.dirSize > uint64(v.size) { //nolint:gosec // Why: Size is positive.
+		return nil, 0, fmt.Errorf("%w: invalid central directory size %d", ErrCorrupt, end.dirSize)
+	}
+
+	dir := make([]byte, end.dirSize)
+	if _, err := v.ReadAt(dir, dirStart); err != nil {
+		return nil, 0, fmt.Errorf("failed to read central directory: %w", err)
+	}
+
+	out := new(bytes.Buffer)
+	for range end.records {
+		var n int
+		if n, err = rewriteZipDirectoryHeader(out, dir, diskOffset); err != nil {
+			return nil, 0, err
+		}
+		dir = dir[n:]
+	}
+
+	dirSize := uint64(out.Len())                            //nolint:gosec // Why: Length is positive.
+	writeZipDirectoryEnd(out, uint64(v.size), dirSize, end) //nolint:gosec // Why: Size is positive.
+
+	tail := out.Bytes()
+	r := newVolumeReader(append(v.vols[:len(v.vols):len(v.vols)], Volume{
+		ReaderAt: bytes.NewReader(tail),
+		Size:     int64(len(tail)),
+	}))
+	return r, r.size, nil
+}
+
+// readZipDirectoryEnd reads the end of central directory record of the
+// archive of the provided size, including its zip64 counterpart.
+func readZipDirectoryEnd(r io.ReaderAt, size int64) (*zipDirectoryEnd, error) {
+	bufLen := min(size, zipDirectoryEndLen+zipMaxComment)
+	buf := make([]byte, bufLen)
+	if _, err := r.ReadAt(buf, size-bufLen); err != nil {
+		return nil, fmt.Errorf("failed to read end of central directory: %w", err)
+	}
+
+	// Search backwards for the signature, the record is followed by the
+	// comment.
+	pos := -1
+	for i := len(buf) - zipDirectoryEndLen; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(buf[i:]) != zipSigDirectoryEnd {
+			continue
+		}
+
+		commentLen := int(binary.LittleEndian.Uint16(buf[i+20:]))
+		if i+zipDirectoryEndLen+commentLen <= len(buf) {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return nil, fmt.Errorf("%w: failed to find end of central directory, not a zip archive", ErrCorrupt)
+	}
+
+	b := buf[pos:]
+	end := &zipDirectoryEnd{
+		disk:      uint32(binary.LittleEndian.Uint16(b[4:])),
+		dirDisk:   uint32(binary.LittleEndian.Uint16(b[6:])),
+		records:   uint64(binary.LittleEndian.Uint16(b[10:])),
+		dirSize:   uint64(binary.LittleEndian.Uint32(b[12:])),
+		dirOffset: uint64(binary.LittleEndian.Uint32(b[16:])),
+		comment:   b[zipDirectoryEndLen : zipDirectoryEndLen+int(binary.LittleEndian.Uint16(b[20:]))],
+	}
+	if end.disk != 0xffff && end.dirDisk != 0xffff && end.records != 0xffff &&
+		end.dirSize != 0xffffffff && end.dirOffset != 0xffffffff {
+		return end, nil
+	}
+
+	// The zip64 end of central directory locator precedes the record.
+	if pos < zipDirectory64LocatorLen {
+		return nil, fmt.Errorf("%w: failed to find zip64 end of central directory locator", ErrCorrupt)
+	}
+	loc := buf[pos-zipDirectory64LocatorLen : pos]
+	if binary.LittleEndian.Uint32(loc) != zipSigDirectory64Locator {
+		return nil, fmt.Errorf("%w: failed to find zip64 end of central directory locator", ErrCorrupt)
+	}
+
+	// The locator doesn't say which disk it is on, it is the last one.
+	// Its offset is relative to the disk containing the zip64 record,
+	// which is also the last one for the archives we support.
+	end64Disk := binary.LittleEndian.Uint32(loc[4:])
+	end64Offset := binary.LittleEndian.Uint64(loc[8:])
+	rel := int64(pos - zipDirectory64LocatorLen - zipDirectory64EndLen)
+	if end64Disk != binary.LittleEndian.Uint32(loc[16:])-1 || rel < 0 {
+		return nil, fmt.Errorf("%w: zip64 end of central directory location (disk %d, offset %d)", ErrUnsupportedFormat, end64Disk, end64Offset)
+	}
+
+	b = buf[rel:]
+	if binary.LittleEndian.Uint32(b) != zipSigDirectory64End {
+		return nil, fmt.Errorf("%w: invalid zip64 end of central directory", ErrCorrupt)
+	}
+
+	end.disk = binary.LittleEndian.Uint32(b[16:])
+	end.dirDisk = binary.LittleEndian.Uint32(b[20:])
+	end.records = binary.LittleEndian.Uint64(b[32:])
+	end.dirSize = binary.LittleEndian.Uint64(b[40:])
+	end.dirOffset = binary.LittleEndian.Uint64(b[48:])
+	return end, nil
+}
+
+// rewriteZipDirectoryHeader writes the central directory header at the
+// start of dir to w, replacing the disk and offset of the local header
+// with the offset returned by diskOffset. It returns the size of the
+// header in dir.
+func rewriteZipDirectoryHeader(w *bytes.Buffer, dir []byte,
+	diskOffset func(disk uint32, offset uint64) (int64, error)) (int, error) {
+	if len(dir) < zipDirectoryHeaderLen || binary.LittleEndian.Uint32(dir) != zipSigDirectoryHeader {
+		return 0, fmt.Errorf("%w: invalid central directory header", ErrCorrupt)
+	}
+
+	nameLen := int(binary.LittleEndian.Uint16(dir[28:]))
+	extraLen := int(binary.LittleEndian.Uint16(dir[30:]))
+	commentLen := int(binary.LittleEndian.Uint16(dir[32:]))
+	n := zipDirectoryHeaderLen + nameLen + extraLen + commentLen
+	if n > len(dir) {
+		return 0, fmt.Errorf("%w: invalid central directory header", ErrCorrupt)
+	}
+
+	hdr := bytes.Clone(dir[:zipDirectoryHeaderLen])
+	name := dir[zipDirectoryHeaderLen : zipDirectoryHeaderLen+nameLen]
+	extra := dir[zipDirectoryHeaderLen+nameLen : zipDirectoryHeaderLen+nameLen+extraLen]
+	comment := dir[zipDirectoryHeaderLen+nameLen+extraLen : n]
+
+	// The fields of the zip64 extra field are only present if the header
+	// field they replace is set to its maximum value, in this order.
+	sizes := []uint64{
+		uint64(binary.LittleEndian.Uint32(hdr[24:])), // uncompressed size
+		uint64(binary.LittleEndian.Uint32(hdr[20:])), // compressed size
+	}
+	offset := uint64(binary.LittleEndian.Uint32(hdr[42:]))
+	disk := uint32(binary.LittleEndian.Uint16(hdr[34:]))
+
+	var newExtra []byte
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			break
+		}
+
+		data := extra[4 : 4+size]
+		if id != zipExtraZip64 {
+			newExtra = append(newExtra, extra[:4+size]...)
+			extra = extra[4+size:]
+			continue
+		}
+		extra = extra[4+size:]
+
+		for i := range sizes {
+			if sizes[i] == 0xffffffff && len(data) >= 8 {
+				sizes[i] = binary.LittleEndian.Uint64(data)
+				data = data[8:]
+			}
+		}
+		if offset == 0xffffffff && len(data) >= 8 {
+			offset = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		}
+		if disk == 0xffff && len(data) >= 4 {
+			disk = binary.LittleEndian.Uint32(data)
+		}
+	}
+
+	abs, err := diskOffset(disk, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	// Rebuild the zip64 extra field, which no longer contains the disk.
+	var zip64 []byte
+	binary.LittleEndian.PutUint16(hdr[34:], 0)
+	binary.LittleEndian.PutUint32(hdr[24:], uint32(min(sizes[0], 0xffffffff))) //nolint:gosec // Why: Bounded.
+	binary.LittleEndian.PutUint32(hdr[20:], uint32(min(sizes[1], 0xffffffff))) //nolint:gosec // Why: Bounded.
+	for _, size := range sizes {
+		if size >= 0xffffffff {
+			zip64 = binary.LittleEndian.AppendUint64(zip64, size)
+		}
+	}
+	binary.LittleEndian.PutUint32(hdr[42:], uint32(min(uint64(abs), 0xffffffff))) //nolint:gosec // Why: Bounded.
+	if abs >= 0xffffffff {
+		zip64 = binary.LittleEndian.AppendUint64(zip64, uint64(abs))
+	}
+	if len(zip64) > 0 {
+		field := binary.LittleEndian.AppendUint16(nil, zipExtraZip64)
+		field = binary.LittleEndian.AppendUint16(field, uint16(len(zip64))) //nolint:gosec // Why: At most 24 bytes.
+		newExtra = append(append(field, zip64...), newExtra...)
+	}
+	if len(newExtra) > 0xffff {
+		return 0, fmt.Errorf("extra field of %q is too large", name)
+	}
+	binary.LittleEndian.PutUint16(hdr[30:], uint16(len(newExtra)))
+
+	w.Write(hdr)
+	w.Write(name)
+	w.Write(newExtra)
+	w.Write(comment)
+	return n, nil
+}
+
+// writeZipDirectoryEnd writes the end of central directory record of a
+// single disk archive whose central directory of size dirSize starts at
+// dirOffset. A zip64 record is written if required.
+func writeZipDirectoryEnd(w *bytes.Buffer, dirOffset, dirSize uint64, end *zipDirectoryEnd) {
+	records := end.records
+	if records >= 0xffff || dirSize >= 0xffffffff || dirOffset >= 0xffffffff {
+		end64Offset := dirOffset + dirSize
+
+		var b []byte
+		b = binary.LittleEndian.AppendUint32(b, zipSigDirectory64End)
+		b = binary.LittleEndian.AppendUint64(b, zipDirectory64EndLen-12) // size of the remaining record
+		b = binary.LittleEndian.AppendUint16(b, 45)                      // version made by
+		b = binary.LittleEndian.AppendUint16(b, 45)                      // version needed to extract
+		b = binary.LittleEndian.AppendUint32(b, 0)                       // number of this disk
+		b = binary.LittleEndian.AppendUint32(b, 0)                       // disk the directory starts on
+		b = binary.LittleEndian.AppendUint64(b, records)                 // records on this disk
+		b = binary.LittleEndian.AppendUint64(b, records)                 // total records
+		b = binary.LittleEndian.AppendUint64(b, dirSize)
+		b = binary.LittleEndian.AppendUint64(b, dirOffset)
+
+		b = binary.LittleEndian.AppendUint32(b, zipSigDirectory64Locator)
+		b = binary.LittleEndian.AppendUint32(b, 0) // disk of the zip64 record
+		b = binary.LittleEndian.AppendUint64(b, end64Offset)
+		b = binary.LittleEndian.AppendUint32(b, 1) // total disks
+		w.Write(b)
+
+		records = min(records, 0xffff)
+		dirSize = min(dirSize, 0xffffffff)
+		dirOffset = min(dirOffset, 0xffffffff)
+	}
+
+	var b []byte
+	b = binary.LittleEndian.AppendUint32(b, zipSigDirectoryEnd)
+	b = binary.LittleEndian.AppendUint16(b, 0)                        // number of this disk
+	b = binary.LittleEndian.AppendUint16(b, 0)                        // disk the directory starts on
+	b = binary.LittleEndian.AppendUint16(b, uint16(records))          //nolint:gosec // Why: Bounded.
+	b = binary.LittleEndian.AppendUint16(b, uint16(records))          //nolint:gosec // Why: Bounded.
+	b = binary.LittleEndian.AppendUint32(b, uint32(dirSize))          //nolint:gosec // Why: Bounded.
+	b = binary.LittleEndian.AppendUint32(b, uint32(dirOffset))        //nolint:gosec // Why: Bounded.
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(end.comment))) //nolint:gosec // Why: Read from a uint16.
+	b = append(b, end.comment...)
+	w.Write(b)
+}
@@ -3,10 +3,18 @@ package archives_test
 import (
 	stdzip "archive/zip"
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"go.rgst.io/jaredallard/archives/v2"
+	"go.rgst.io/jaredallard/archives/v2/internal/ziptest"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
 	"gotest.tools/v3/assert"
 )
 
@@ -32,3 +40,266 @@ func TestZip(t *testing.T) {
 
 	assert.Equal(t, string(b), "hello world")
 }
+
+func TestZipEncrypted(t *testing.T) {
+	encryptions := []struct {
+		name       string
+		encryption ziptest.Encryption
+		aesVersion int
+	}{
+		{"ZipCrypto", ziptest.EncryptionZipCrypto, 1},
+		{"AES128", ziptest.EncryptionAES128, 1},
+		{"AES192", ziptest.EncryptionAES192, 1},
+		{"AES256-AE1", ziptest.EncryptionAES256, 1},
+		{"AES256-AE2", ziptest.EncryptionAES256, 2},
+	}
+	for _, e := range encryptions {
+		t.Run(e.name, func(t *testing.T) {
+			contents := bytes.Repeat([]byte("hello world "), 100)
+			b, err := ziptest.Create([]ziptest.File{
+				{Name: "stored.txt", Contents: []byte("stored")},
+				{Name: "deflated.txt", Contents: contents, Method: stdzip.Deflate},
+			}, ziptest.WithEncryption(e.encryption, "secret"), ziptest.WithAESVersion(e.aesVersion))
+			assert.NilError(t, err)
+
+			a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+				Extension: ".zip",
+				Password:  "secret",
+			})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			for _, want := range []string{"stored", string(contents)} {
+				h, err := a.Next()
+				assert.NilError(t, err)
+				assert.Assert(t, h.Encrypted)
+
+				got, err := io.ReadAll(a)
+				assert.NilError(t, err)
+				assert.Equal(t, string(got), want)
+			}
+		})
+	}
+}
+
+func TestZipEncryptedIncorrectPassword(t *testing.T) {
+	for _, e := range []ziptest.Encryption{ziptest.EncryptionZipCrypto, ziptest.EncryptionAES256} {
+		b, err := ziptest.Create([]ziptest.File{
+			{Name: "file.txt", Contents: []byte("hello world")},
+		}, ziptest.WithEncryption(e, "secret"))
+		assert.NilError(t, err)
+
+		a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+			Extension: ".zip",
+			Password:  "wrong",
+		})
+		assert.NilError(t, err)
+
+		_, err = a.Next()
+		assert.NilError(t, err)
+
+		_, err = io.ReadAll(a)
+		assert.Assert(t, errors.Is(err, archives.ErrIncorrectPassword))
+		assert.NilError(t, a.Close())
+	}
+}
+
+func TestZipEncryptedCorrupt(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "file.txt", Contents: []byte("hello world")},
+	}, ziptest.WithEncryption(ziptest.EncryptionAES256, "secret"), ziptest.WithAESVersion(2))
+	assert.NilError(t, err)
+
+	// Flip a bit of the encrypted contents, stored after the 16 byte salt
+	// and the password verification value.
+	i := bytes.Index(b, []byte("file.txt")) + len("file.txt") + 11 + 16 + 2
+	b[i] ^= 0x01
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+		Extension: ".zip",
+		Password:  "secret",
+	})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = a.Next()
+	assert.NilError(t, err)
+
+	_, err = io.ReadAll(a)
+	assert.ErrorContains(t, err, "authentication code mismatch")
+}
+
+func TestZipEncryptedSkip(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "secret.txt", Contents: []byte("secret")},
+		{Name: "other.txt", Contents: []byte("other")},
+	}, ziptest.WithEncryption(ziptest.EncryptionAES256, "secret"))
+	assert.NilError(t, err)
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	h, err := a.Next()
+	assert.NilError(t, err)
+	assert.Assert(t, h.Encrypted)
+
+	_, err = io.ReadAll(a)
+	assert.Assert(t, errors.Is(err, archives.ErrPasswordRequired))
+
+	// Entries can be skipped without reading them.
+	h, err = a.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, h.Name, "other.txt")
+}
+
+func TestZipEncryptedPasswordProvider(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "dir/file.txt", Contents: []byte("hello world")},
+	}, ziptest.WithEncryption(ziptest.EncryptionZipCrypto, "per-file"))
+	assert.NilError(t, err)
+
+	var names []string
+	dest := t.TempDir()
+	assert.NilError(t, archives.Extract(bytes.NewReader(b), dest, archives.ExtractOptions{
+		Extension: ".zip",
+		PasswordProvider: func(h *archives.Header) (string, error) {
+			names = append(names, h.Name)
+			return "per-file", nil
+		},
+	}))
+	assert.DeepEqual(t, names, []string{"dir/file.txt"})
+
+	got, err := os.ReadFile(filepath.Join(dest, "dir", "file.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), "hello world")
+}
+
+// bzip2Hello is "hello bzip2\n" compressed using bzip2(1), as there is
+// no bzip2 writer available.
+var bzip2Hello = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xab, 0x6b,
+	0xa1, 0xf1, 0x00, 0x00, 0x02, 0xd9, 0x80, 0x00, 0x10, 0x40, 0x00, 0x10,
+	0x00, 0x12, 0x64, 0xc0, 0x10, 0x20, 0x00, 0x31, 0x00, 0xd3, 0x4d, 0x04,
+	0x00, 0x1e, 0xa3, 0xef, 0x4e, 0x51, 0xa2, 0x07, 0x8b, 0xb9, 0x22, 0x9c,
+	0x28, 0x48, 0x55, 0xb5, 0xd0, 0xf8, 0x80,
+}
+
+func TestZipMethods(t *testing.T) {
+	contents := append(bytes.Repeat([]byte("hello world "), 10000), "end"...)
+	tests := []struct {
+		name    string
+		file    ziptest.File
+		options []ziptest.OptionFn
+		want    []byte
+	}{
+		{"deflate64", ziptest.File{Method: ziptest.MethodDeflate64, Contents: contents}, nil, contents},
+		{"bzip2", ziptest.File{Method: ziptest.MethodBzip2, Contents: []byte("hello bzip2\n"), Compressed: bzip2Hello}, nil, []byte("hello bzip2\n")},
+		{"lzma", ziptest.File{Method: ziptest.MethodLZMA, Contents: contents}, nil, contents},
+		{"lzma-eos", ziptest.File{Method: ziptest.MethodLZMA, Contents: contents}, []ziptest.OptionFn{ziptest.WithLZMAEndMarker()}, contents},
+		{"zstd", ziptest.File{Method: ziptest.MethodZstd, Contents: contents}, nil, contents},
+		{"xz", ziptest.File{Method: ziptest.MethodXZ, Contents: contents}, nil, contents},
+		{"aes-xz", ziptest.File{Method: ziptest.MethodXZ, Contents: contents}, []ziptest.OptionFn{
+			ziptest.WithEncryption(ziptest.EncryptionAES256, "secret"),
+		}, contents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.file.Name = "file.bin"
+			b, err := ziptest.Create([]ziptest.File{tt.file}, tt.options...)
+			assert.NilError(t, err)
+
+			a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+				Extension: ".zip",
+				Password:  "secret",
+			})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			_, err = a.Next()
+			assert.NilError(t, err)
+
+			got, err := io.ReadAll(a)
+			assert.NilError(t, err)
+			assert.Assert(t, bytes.Equal(got, tt.want), "mismatch (len %d != %d)", len(got), len(tt.want))
+		})
+	}
+}
+
+func TestZipMethodsChecksum(t *testing.T) {
+	b, err := ziptest.Create([]ziptest.File{
+		{Name: "file.bin", Method: ziptest.MethodLZMA, Contents: []byte("hello world")},
+	})
+	assert.NilError(t, err)
+
+	// Corrupt the stored CRC-32 in the local and central headers. A zero
+	// CRC-32 isn't verified, so flip its bits instead.
+	sum := crc32.ChecksumIEEE([]byte("hello world"))
+	crc := binary.LittleEndian.AppendUint32(nil, sum)
+	b = bytes.ReplaceAll(b, crc, binary.LittleEndian.AppendUint32(nil, ^sum))
+
+	a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{Extension: ".zip"})
+	assert.NilError(t, err)
+	defer a.Close()
+
+	_, err = a.Next()
+	assert.NilError(t, err)
+
+	_, err = io.ReadAll(a)
+	assert.ErrorIs(t, err, stdzip.ErrChecksum)
+}
+
+// unicodePathExtra returns an Info-ZIP Unicode Path extra field storing
+// name for an entry whose header name is raw.
+func unicodePathExtra(raw, name string) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, 0x7075)
+	b = binary.LittleEndian.AppendUint16(b, uint16(5+len(name))) //nolint:gosec // Why: Test data.
+	b = append(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE([]byte(raw)))
+	return append(b, name...)
+}
+
+func TestZipNames(t *testing.T) {
+	shiftJIS, err := japanese.ShiftJIS.NewEncoder().String("日本語.txt")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name    string
+		file    ziptest.File
+		decoder *encoding.Decoder
+		want    string
+	}{
+		{"utf8", ziptest.File{Name: "café.txt"}, nil, "café.txt"},
+		{"utf8-without-flag", ziptest.File{Name: "café.txt", NonUTF8: true}, nil, "café.txt"},
+		{"cp437", ziptest.File{Name: "caf\x82.txt", NonUTF8: true}, nil, "café.txt"},
+		{"decoder", ziptest.File{Name: shiftJIS, NonUTF8: true}, japanese.ShiftJIS.NewDecoder(), "日本語.txt"},
+		{"unicode-path", ziptest.File{
+			Name:    "caf\x82.txt",
+			NonUTF8: true,
+			Extra:   unicodePathExtra("caf\x82.txt", "café ☕.txt"),
+		}, nil, "café ☕.txt"},
+		{"unicode-path-outdated", ziptest.File{
+			Name:    "caf\x82.txt",
+			NonUTF8: true,
+			Extra:   unicodePathExtra("renamed.txt", "café ☕.txt"),
+		}, nil, "café.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ziptest.Create([]ziptest.File{tt.file})
+			assert.NilError(t, err)
+
+			a, err := archives.Open(bytes.NewReader(b), archives.OpenOptions{
+				Extension:   ".zip",
+				NameDecoder: tt.decoder,
+			})
+			assert.NilError(t, err)
+			defer a.Close()
+
+			h, err := a.Next()
+			assert.NilError(t, err)
+			assert.Equal(t, h.Name, tt.want)
+			assert.DeepEqual(t, h.RawName, []byte(tt.file.Name))
+		})
+	}
+}
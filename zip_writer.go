@@ -0,0 +1,109 @@
+// Copyright (C) 2026 archives contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package archives
+
+import (
+	stdzip "archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// _ ensures that zip implements the [WriterArchiver] interface.
+var _ WriterArchiver = (&zip{})
+
+// Create returns an [ArchiveWriter] writing a zip archive to w. Files
+// are compressed using deflate.
+func (z *zip) Create(w io.Writer, _ string, opts *CreateOptions) (ArchiveWriter, error) {
+	zw := stdzip.NewWriter(w)
+	if opts.Reproducible {
+		zw.RegisterCompressor(stdzip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, flate.BestCompression)
+		})
+	}
+
+	return &zipWriter{zw: zw}, nil
+}
+
+// zipWriter is an implementation of the [ArchiveWriter] interface for
+// zip archives.
+type zipWriter struct {
+	zw *stdzip.Writer
+
+	// w is the writer of the contents of the current entry.
+	w io.Writer
+}
+
+// WriteHeader implements [ArchiveWriter].
+func (z *zipWriter) WriteHeader(h *Header) error {
+	fh := &stdzip.FileHeader{
+		Name:     h.Name,
+		Modified: h.ModTime,
+		Method:   stdzip.Deflate,
+	}
+
+	mode := h.Mode &^ os.ModeType
+	switch h.Type {
+	case HeaderFile:
+		fh.UncompressedSize64 = uint64(max(h.Size, 0))
+	case HeaderDir:
+		fh.Method = stdzip.Store
+		mode |= os.ModeDir
+		if !strings.HasSuffix(fh.Name, "/") {
+			fh.Name += "/"
+		}
+	case HeaderSymlink:
+		// The target of symbolic links is stored as their contents.
+		fh.Method = stdzip.Store
+		mode |= os.ModeSymlink
+	default:
+		return fmt.Errorf("%w: %v in zip archives", ErrUnsupportedEntryType, h.Type)
+	}
+	fh.SetMode(mode)
+
+	w, err := z.zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	z.w = w
+
+	if h.Type == HeaderSymlink {
+		if _, err := io.WriteString(w, h.Linkname); err != nil {
+			return fmt.Errorf("failed to write symlink target: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Write implements [io.Writer].
+func (z *zipWriter) Write(p []byte) (int, error) {
+	if z.w == nil {
+		return 0, fmt.Errorf("WriteHeader must be called before Write")
+	}
+
+	return z.w.Write(p)
+}
+
+// Close finishes the zip archive.
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}